@@ -0,0 +1,309 @@
+package backfill
+
+// Adds a `backfill` command that runs the summarizer, tagger and
+// SingleFile archiver over links that were saved before those
+// integrations were configured. For example:
+//
+//	lynxapp backfill --user <id> --stages summarize,tags,archive \
+//	  --since 2025-01-01 --concurrency 4 --dry-run
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cobra"
+
+	"main/lynx/embeddings"
+	"main/lynx/linkstatus"
+	"main/lynx/singlefile"
+	"main/lynx/summarizer"
+	"main/lynx/tagger"
+)
+
+const (
+	StageSummarize = "summarize"
+	StageTags      = "tags"
+	StageArchive   = "archive"
+	StageEmbed     = "embed"
+)
+
+// Every stage that can be passed to --stages.
+var KnownStages = []string{StageSummarize, StageTags, StageArchive, StageEmbed}
+
+// The stages run when --stages isn't given. Embeddings are opt-in since
+// they're only useful to users who search semantically.
+var AllStages = []string{StageSummarize, StageTags, StageArchive}
+
+// The linkstatus stage each stage records its outcome under. Stages
+// that don't record one are checked by looking for their output.
+var statusStages = map[string]string{
+	StageSummarize: linkstatus.StageSummary,
+	StageTags:      linkstatus.StageTags,
+	StageArchive:   linkstatus.StageArchive,
+}
+
+// The functions invoked for each stage. Overridden in tests.
+var stageFuncs = map[string]func(app core.App, linkID string) error{
+	StageSummarize: summarizer.MaybeSummarizeLink,
+	StageTags:      tagger.MaybeSuggestTagsForLink,
+	StageArchive:   singlefile.MaybeArchiveLink,
+	StageEmbed:     embeddings.MaybeEmbedLink,
+}
+
+type Options struct {
+	// Only backfill links for this user. Empty means all users.
+	UserID string
+	Stages []string
+	// Only backfill links added to the library at or after this time.
+	Since       time.Time
+	Concurrency int
+	// Minimum time between starting work on two links, to avoid
+	// hammering OpenRouter or the SingleFile service.
+	Interval time.Duration
+	DryRun   bool
+	Out      io.Writer
+}
+
+type Result struct {
+	Processed int
+	Failed    int
+}
+
+func MustRegister(app core.App, rootCmd *cobra.Command) {
+	rootCmd.AddCommand(NewCommand(app))
+}
+
+func NewCommand(app core.App) *cobra.Command {
+	var (
+		opts   Options
+		stages string
+		since  string
+	)
+
+	cmd := &cobra.Command{
+		Use:          "backfill",
+		Short:        "Summarize, tag and archive links saved before these features were configured",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			opts.Stages, err = ParseStages(stages)
+			if err != nil {
+				return err
+			}
+			if since != "" {
+				opts.Since, err = time.Parse(time.DateOnly, since)
+				if err != nil {
+					return fmt.Errorf("invalid --since date, expected YYYY-MM-DD: %w", err)
+				}
+			}
+			opts.Out = cmd.OutOrStdout()
+
+			result, err := Run(app, opts)
+			if err != nil {
+				return err
+			}
+			if result.Failed > 0 {
+				return fmt.Errorf("%d of %d links failed", result.Failed, result.Processed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.UserID, "user", "", "only backfill links for this user ID")
+	cmd.Flags().StringVar(&stages, "stages", strings.Join(AllStages, ","), "comma separated stages to run ("+strings.Join(KnownStages, ", ")+")")
+	cmd.Flags().StringVar(&since, "since", "", "only backfill links added on or after this date (YYYY-MM-DD)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 1, "number of links to process at once")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", time.Second, "minimum delay between starting each link")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "list the links that would be processed without changing anything")
+
+	return cmd
+}
+
+func ParseStages(value string) ([]string, error) {
+	var stages []string
+	for _, stage := range strings.Split(value, ",") {
+		stage = strings.TrimSpace(stage)
+		if stage == "" {
+			continue
+		}
+		if _, ok := stageFuncs[stage]; !ok {
+			return nil, fmt.Errorf("unknown stage %q, expected one of %s", stage, strings.Join(KnownStages, ", "))
+		}
+		stages = append(stages, stage)
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("at least one stage is required")
+	}
+	return stages, nil
+}
+
+// Run finds links that are missing the output of any of the requested
+// stages and runs those stages for each of them.
+func Run(app core.App, opts Options) (Result, error) {
+	var result Result
+	if opts.Out == nil {
+		opts.Out = io.Discard
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	linkIDs, err := findLinkIDs(app, opts)
+	if err != nil {
+		return result, fmt.Errorf("failed to find links: %w", err)
+	}
+
+	total := len(linkIDs)
+	fmt.Fprintf(opts.Out, "Found %d links to backfill (stages: %s)\n", total, strings.Join(opts.Stages, ", "))
+	if total == 0 {
+		return result, nil
+	}
+
+	var throttle <-chan time.Time
+	if opts.Interval > 0 && !opts.DryRun {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	var mu sync.Mutex
+	ids := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for linkID := range ids {
+				summary, failed := processLink(app, linkID, opts)
+
+				mu.Lock()
+				result.Processed++
+				if failed {
+					result.Failed++
+				}
+				fmt.Fprintf(opts.Out, "[%d/%d] %s\n", result.Processed, total, summary)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i, linkID := range linkIDs {
+		if throttle != nil && i > 0 {
+			<-throttle
+		}
+		ids <- linkID
+	}
+	close(ids)
+	wg.Wait()
+
+	fmt.Fprintf(opts.Out, "Done: %d processed, %d failed\n", result.Processed, result.Failed)
+	return result, nil
+}
+
+func findLinkIDs(app core.App, opts Options) ([]string, error) {
+	var missing []dbx.Expression
+	for _, stage := range opts.Stages {
+		switch stage {
+		case StageSummarize:
+			missing = append(missing, dbx.HashExp{"summary": ""})
+		case StageTags:
+			missing = append(missing, dbx.HashExp{"tags_suggested_at": ""})
+		case StageArchive:
+			missing = append(missing, dbx.HashExp{"archive": ""})
+		case StageEmbed:
+			missing = append(missing, dbx.NewExp("NOT EXISTS (SELECT 1 FROM embeddings WHERE embeddings.link = links.id AND embeddings.highlight = '')"))
+		}
+	}
+
+	query := app.RecordQuery("links").
+		Select("links.id").
+		AndWhere(dbx.Or(missing...)).
+		OrderBy("added_to_library ASC")
+	if opts.UserID != "" {
+		query = query.AndWhere(dbx.HashExp{"user": opts.UserID})
+	}
+	if !opts.Since.IsZero() {
+		query = query.AndWhere(dbx.NewExp("added_to_library >= {:since}", dbx.Params{
+			"since": opts.Since.UTC().Format("2006-01-02 15:04:05.000Z"),
+		}))
+	}
+
+	var ids []string
+	if err := query.Column(&ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// processLink runs each requested stage for the link and returns a
+// one-line description of what happened.
+func processLink(app core.App, linkID string, opts Options) (string, bool) {
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return fmt.Sprintf("%s: failed to load link: %v", linkID, err), true
+	}
+
+	var stages []string
+	for _, stage := range opts.Stages {
+		if needsStage(app, link, stage) {
+			stages = append(stages, stage)
+		}
+	}
+
+	description := fmt.Sprintf("%s %q", link.Id, link.GetString("title"))
+	if opts.DryRun {
+		return fmt.Sprintf("%s: would run %s", description, strings.Join(stages, ", ")), false
+	}
+
+	failed := false
+	var outcomes []string
+	for _, stage := range stages {
+		if err := stageFuncs[stage](app, link.Id); err != nil {
+			failed = true
+			outcomes = append(outcomes, fmt.Sprintf("%s failed (%v)", stage, err))
+		} else {
+			outcomes = append(outcomes, stage+" "+stageOutcome(app, link.Id, stage))
+		}
+	}
+	return fmt.Sprintf("%s: %s", description, strings.Join(outcomes, ", ")), failed
+}
+
+// stageOutcome reports whether a stage that ran without an error did
+// its work or skipped the link, e.g. because the user hasn't set up
+// the integration.
+func stageOutcome(app core.App, linkID string, stage string) string {
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return "done"
+	}
+	if statusStage, ok := statusStages[stage]; ok {
+		if linkstatus.Get(link, statusStage) == linkstatus.Skipped {
+			return "skipped"
+		}
+		return "done"
+	}
+	if needsStage(app, link, stage) {
+		return "skipped"
+	}
+	return "done"
+}
+
+func needsStage(app core.App, link *core.Record, stage string) bool {
+	switch stage {
+	case StageSummarize:
+		return link.GetString("summary") == ""
+	case StageTags:
+		return link.GetDateTime("tags_suggested_at").IsZero()
+	case StageArchive:
+		return link.GetString("archive") == ""
+	case StageEmbed:
+		_, err := app.FindFirstRecordByFilter("embeddings", "link = {:link} && highlight = ''", dbx.Params{"link": link.Id})
+		return err != nil
+	}
+	return false
+}
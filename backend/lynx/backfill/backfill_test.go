@@ -0,0 +1,178 @@
+package backfill
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/linkstatus"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+func setupTestApp(t *testing.T) *tests.TestApp {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(testApp.Cleanup)
+	return testApp
+}
+
+// stubStages replaces the stage functions for the duration of the test
+// and records which stages were run for which links.
+func stubStages(t *testing.T, failing string) *map[string][]string {
+	original := stageFuncs
+	t.Cleanup(func() { stageFuncs = original })
+
+	var mu sync.Mutex
+	calls := map[string][]string{}
+	stageFuncs = map[string]func(app core.App, linkID string) error{}
+	for stage := range original {
+		stageFuncs[stage] = func(app core.App, linkID string) error {
+			mu.Lock()
+			calls[linkID] = append(calls[linkID], stage)
+			mu.Unlock()
+			if stage == failing {
+				return errors.New("service unavailable")
+			}
+			return nil
+		}
+	}
+	return &calls
+}
+
+func TestParseStages(t *testing.T) {
+	stages, err := ParseStages("summarize, archive")
+	require.NoError(t, err)
+	assert.Equal(t, []string{StageSummarize, StageArchive}, stages)
+
+	_, err = ParseStages("summarize,translate")
+	assert.EqualError(t, err, `unknown stage "translate", expected one of summarize, tags, archive, embed`)
+
+	_, err = ParseStages(" , ")
+	assert.Error(t, err)
+}
+
+func TestRunProcessesMissingStages(t *testing.T) {
+	testApp := setupTestApp(t)
+	calls := stubStages(t, StageArchive)
+
+	// Already summarized, so only tags and archive should run
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("summary", "An existing summary")
+	require.NoError(t, testApp.Save(link))
+
+	var out bytes.Buffer
+	result, err := Run(testApp, Options{
+		Stages:      AllStages,
+		Concurrency: 2,
+		Out:         &out,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Result{Processed: 1, Failed: 1}, result)
+	assert.Equal(t, []string{StageTags, StageArchive}, (*calls)[testLinkID])
+	assert.Contains(t, out.String(), "[1/1] "+testLinkID)
+	assert.Contains(t, out.String(), "tags done, archive failed (service unavailable)")
+}
+
+func TestRunReportsSkippedStages(t *testing.T) {
+	testApp := setupTestApp(t)
+	stubStages(t, "")
+	stageFuncs[StageTags] = func(app core.App, linkID string) error {
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Skipped, nil)
+		return nil
+	}
+
+	var out bytes.Buffer
+	result, err := Run(testApp, Options{
+		Stages: []string{StageSummarize, StageTags, StageEmbed},
+		Out:    &out,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Result{Processed: 1}, result)
+	// The stubbed embed stage doesn't create an embedding
+	assert.Contains(t, out.String(), "summarize done, tags skipped, embed skipped")
+}
+
+func TestRunDryRun(t *testing.T) {
+	testApp := setupTestApp(t)
+	calls := stubStages(t, "")
+
+	var out bytes.Buffer
+	result, err := Run(testApp, Options{
+		Stages: []string{StageSummarize},
+		DryRun: true,
+		Out:    &out,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Result{Processed: 1}, result)
+	assert.Empty(t, *calls)
+	assert.Contains(t, out.String(), "would run summarize")
+}
+
+func TestRunFilters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     Options
+		expected int
+	}{
+		{name: "Matching user", opts: Options{UserID: testUserID}, expected: 1},
+		{name: "Other user", opts: Options{UserID: "h4oofx0tx2eupnq"}, expected: 0},
+		{name: "Added after since", opts: Options{Since: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)}, expected: 1},
+		{name: "Added before since", opts: Options{Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testApp := setupTestApp(t)
+			stubStages(t, "")
+
+			tc.opts.Stages = AllStages
+			result, err := Run(testApp, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result.Processed)
+		})
+	}
+}
+
+func TestRunEmbedStage(t *testing.T) {
+	testApp := setupTestApp(t)
+	calls := stubStages(t, "")
+
+	stages, err := ParseStages("embed")
+	require.NoError(t, err)
+
+	result, err := Run(testApp, Options{Stages: stages})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Processed: 1}, result)
+	assert.Equal(t, []string{StageEmbed}, (*calls)[testLinkID])
+
+	// Links that already have an embedding are left alone
+	collection, err := testApp.FindCollectionByNameOrId("embeddings")
+	require.NoError(t, err)
+	embedding := core.NewRecord(collection)
+	embedding.Set("user", testUserID)
+	embedding.Set("link", testLinkID)
+	embedding.Set("model", "openai/text-embedding-3-small")
+	embedding.Set("vector", []float32{1, 0})
+	require.NoError(t, testApp.Save(embedding))
+
+	result, err = Run(testApp, Options{Stages: stages})
+	require.NoError(t, err)
+	assert.Equal(t, Result{}, result)
+}
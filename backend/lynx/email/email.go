@@ -0,0 +1,276 @@
+package email
+
+// An optional SMTP listener for saving email to the library, e.g. for
+// forwarding newsletters. Each user can create addresses at the
+// listener's domain, and mail sent to one is saved as a link for the
+// user who owns it.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-smtp"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/security"
+
+	"main/lynx/url_parser"
+)
+
+// Domain used for addresses when SMTP_DOMAIN isn't set
+const defaultDomain = "lynx.local"
+
+// Largest email accepted, which is plenty for newsletters
+const maxMessageBytes = 25 * 1024 * 1024
+
+// Most links saved from a single email
+const maxSavedLinks = 20
+
+// Tokens are lowercase since mail clients can't be trusted to keep
+// the case of addresses
+const tokenAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Domain returns the domain email addresses are at.
+func Domain() string {
+	if domain := os.Getenv("SMTP_DOMAIN"); domain != "" {
+		return strings.ToLower(domain)
+	}
+	return defaultDomain
+}
+
+// Address returns the email address for one of the user's
+// email_addresses records.
+func Address(record *core.Record) string {
+	return record.GetString("token") + "@" + Domain()
+}
+
+// NewToken generates the part of a new address before the @.
+func NewToken() string {
+	return security.RandomStringWithAlphabet(24, tokenAlphabet)
+}
+
+// Listener accepts mail over SMTP when SMTP_LISTEN_ADDR is set.
+type Listener struct {
+	app core.App
+
+	mu       sync.Mutex
+	server   *smtp.Server
+	listener net.Listener
+}
+
+func NewListener(app core.App) *Listener {
+	return &Listener{app: app}
+}
+
+// Start listens on SMTP_LISTEN_ADDR, if it's set. There's no
+// authentication or TLS, so the address should be one that only
+// trusted mail servers can reach, like 127.0.0.1:2525.
+func (l *Listener) Start() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.server != nil {
+		return nil
+	}
+
+	addr := os.Getenv("SMTP_LISTEN_ADDR")
+	if addr == "" {
+		l.app.Logger().Info("SMTP_LISTEN_ADDR not set, not accepting email")
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for email: %w", err)
+	}
+
+	server := smtp.NewServer(&backend{app: l.app, domain: Domain()})
+	server.Domain = Domain()
+	server.AuthDisabled = true
+	server.MaxMessageBytes = maxMessageBytes
+	server.MaxRecipients = 10
+	server.ReadTimeout = time.Minute
+	server.WriteTimeout = time.Minute
+	server.ErrorLog = slog.NewLogLogger(l.app.Logger().Handler(), slog.LevelError)
+
+	l.server, l.listener = server, listener
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			l.app.Logger().Error("Stopped accepting email", "error", err)
+		}
+	}()
+	l.app.Logger().Info("Accepting email", "addr", listener.Addr().String(), "domain", Domain())
+	return nil
+}
+
+// Addr returns the address being listened on, or nil if the listener
+// isn't running.
+func (l *Listener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.listener == nil {
+		return nil
+	}
+	return l.listener.Addr()
+}
+
+// Stop closes the listener and any open connections.
+func (l *Listener) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.server == nil {
+		return
+	}
+	if err := l.server.Close(); err != nil {
+		l.app.Logger().Error("Failed to stop accepting email", "error", err)
+	}
+	l.server, l.listener = nil, nil
+}
+
+type backend struct {
+	app    core.App
+	domain string
+}
+
+func (b *backend) Login(state *smtp.ConnectionState, username, password string) (smtp.Session, error) {
+	return nil, smtp.ErrAuthUnsupported
+}
+
+func (b *backend) AnonymousLogin(state *smtp.ConnectionState) (smtp.Session, error) {
+	return &session{backend: b}, nil
+}
+
+// session is a single SMTP connection, which can deliver several
+// messages, each to several of the users' addresses.
+type session struct {
+	backend    *backend
+	recipients []*core.Record
+}
+
+var errNoSuchMailbox = &smtp.SMTPError{
+	Code:         550,
+	EnhancedCode: smtp.EnhancedCode{5, 1, 1},
+	Message:      "No such mailbox",
+}
+
+func (s *session) Mail(from string, opts smtp.MailOptions) error {
+	return nil
+}
+
+func (s *session) Rcpt(to string) error {
+	recipient, err := s.backend.findRecipient(to)
+	if err != nil {
+		return errNoSuchMailbox
+	}
+	s.recipients = append(s.recipients, recipient)
+	return nil
+}
+
+func (s *session) Data(r io.Reader) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	saved := false
+	for _, recipient := range s.recipients {
+		if _, err := Ingest(s.backend.app, recipient, raw); err != nil {
+			s.backend.app.Logger().Error("Failed to save email", "addressID", recipient.Id, "error", err)
+			continue
+		}
+		saved = true
+	}
+	if !saved {
+		return &smtp.SMTPError{
+			Code:         554,
+			EnhancedCode: smtp.EnhancedCode{5, 6, 0},
+			Message:      "Message could not be saved",
+		}
+	}
+	return nil
+}
+
+func (s *session) Reset() {
+	s.recipients = nil
+}
+
+func (s *session) Logout() error {
+	return nil
+}
+
+// findRecipient returns the email_addresses record for an address at
+// the listener's domain.
+func (b *backend) findRecipient(to string) (*core.Record, error) {
+	address, err := mail.ParseAddress(to)
+	if err != nil {
+		return nil, err
+	}
+	at := strings.LastIndex(address.Address, "@")
+	if at < 0 || !strings.EqualFold(address.Address[at+1:], b.domain) {
+		return nil, errors.New("address is at another domain")
+	}
+	return b.app.FindFirstRecordByData("email_addresses", "token", strings.ToLower(address.Address[:at]))
+}
+
+// Ingest saves an email sent to one of the user's addresses as a link,
+// and if the address is set up to, saves the links in it as well. The
+// links in the email are saved in the background.
+func Ingest(app core.App, address *core.Record, raw []byte) (*core.Record, error) {
+	msg, err := parseMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var content *url_parser.Content
+	if msg.HTML != "" {
+		content, err = url_parser.ExtractorForUpload("text/html", []byte(msg.HTML)).Extract([]byte(msg.HTML), nil)
+	} else {
+		content, err = url_parser.TextExtractor{}.Extract([]byte(msg.Text), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The email's own details are better than whatever was guessed
+	// from its body
+	if msg.Subject != "" {
+		content.Title = msg.Subject
+	}
+	if msg.From != "" {
+		content.Author = msg.From
+	}
+	if msg.Date != nil {
+		content.PublishedTime = msg.Date
+	}
+
+	userID := address.GetString("user")
+	record, err := url_parser.SaveContent(app, userID, content, nil, url_parser.OnDuplicateReturn)
+	if err != nil {
+		return nil, err
+	}
+
+	address.Set("last_used_at", time.Now().UTC())
+	if err := app.Save(address); err != nil {
+		app.Logger().Error("Failed to update email address last used timestamp", "addressID", address.Id, "error", err)
+	}
+
+	if address.GetBool("save_links") && msg.HTML != "" {
+		links := messageLinks(msg.HTML, maxSavedLinks)
+		routine.FireAndForget(func() {
+			for _, link := range links {
+				if _, err := url_parser.HandleParseURLViaParams(app, userID, link, nil, url_parser.OnDuplicateReturn); err != nil {
+					app.Logger().Warn("Failed to save link from email", "url", link.String(), "linkID", record.Id, "error", err)
+				}
+			}
+		})
+	}
+
+	return record, nil
+}
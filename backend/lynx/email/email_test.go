@@ -0,0 +1,122 @@
+package email
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserID = "h4oofx0tx2eupnq"
+
+func createTestAddress(t *testing.T, app core.App, saveLinks bool) *core.Record {
+	t.Helper()
+	collection, err := app.FindCollectionByNameOrId("email_addresses")
+	require.NoError(t, err)
+	address := core.NewRecord(collection)
+	address.Set("user", testUserID)
+	address.Set("name", "Newsletters")
+	address.Set("token", NewToken())
+	address.Set("save_links", saveLinks)
+	require.NoError(t, app.Save(address))
+	return address
+}
+
+func startTestListener(t *testing.T, app core.App) string {
+	t.Helper()
+	t.Setenv("SMTP_LISTEN_ADDR", "127.0.0.1:0")
+	listener := NewListener(app)
+	require.NoError(t, listener.Start())
+	t.Cleanup(listener.Stop)
+	return listener.Addr().String()
+}
+
+func TestListener(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	address := createTestAddress(t, testApp, false)
+	addr := startTestListener(t, testApp)
+
+	// Addresses are case insensitive
+	to := strings.ToUpper(address.GetString("token")) + "@LYNX.local"
+	assert.Equal(t, address.GetString("token")+"@lynx.local", Address(address))
+	require.NoError(t, smtp.SendMail(addr, nil, "news@cafe.example.com", []string{to}, crlf(newsletter)))
+
+	link, err := testApp.FindFirstRecordByFilter("links", "user = {:user} && title = {:title}", dbx.Params{"user": testUserID, "title": "This week at the café"})
+	require.NoError(t, err)
+	assert.Equal(t, "Café Weekly", link.GetString("author"))
+	assert.Equal(t, "article", link.GetString("kind"))
+	assert.Contains(t, link.GetString("raw_text_content"), "Café news")
+	assert.Equal(t, "2025-01-07 08:30:00.000Z", link.GetString("article_date"))
+	assert.Empty(t, link.GetString("original_url"))
+
+	address, err = testApp.FindRecordById("email_addresses", address.Id)
+	require.NoError(t, err)
+	assert.False(t, address.GetDateTime("last_used_at").IsZero())
+
+	// Unknown addresses and other domains are turned away
+	err = smtp.SendMail(addr, nil, "news@cafe.example.com", []string{"nobody@lynx.local"}, crlf(newsletter))
+	assert.ErrorContains(t, err, "550")
+	err = smtp.SendMail(addr, nil, "news@cafe.example.com", []string{address.GetString("token") + "@example.com"}, crlf(newsletter))
+	assert.ErrorContains(t, err, "550")
+
+	// As is mail that can't be saved
+	err = smtp.SendMail(addr, nil, "news@cafe.example.com", []string{to}, []byte("not an email"))
+	assert.ErrorContains(t, err, "554")
+}
+
+func TestListenerSavesLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Linked Post</title></head><body><article><h1>Linked Post</h1><p>The post the newsletter links to, with enough words in it that readability keeps it around.</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	address := createTestAddress(t, testApp, true)
+	addr := startTestListener(t, testApp)
+
+	message := crlf(`From: news@example.com
+Subject: Links this week
+Content-Type: text/html
+
+<p>Read <a href="` + server.URL + `/post">this post</a>, or <a href="` + server.URL + `/unsubscribe">unsubscribe</a>.</p>
+`)
+	require.NoError(t, smtp.SendMail(addr, nil, "news@example.com", []string{Address(address)}, message))
+
+	_, err = testApp.FindFirstRecordByData("links", "title", "Links this week")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := testApp.FindFirstRecordByData("links", "title", "Linked Post")
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	links, err := testApp.FindAllRecords("links", dbx.HashExp{"user": testUserID})
+	require.NoError(t, err)
+	assert.Len(t, links, 2)
+}
+
+func TestListenerDisabled(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	t.Setenv("SMTP_LISTEN_ADDR", "")
+	listener := NewListener(testApp)
+	require.NoError(t, listener.Start())
+	assert.Nil(t, listener.Addr())
+	listener.Stop()
+}
@@ -0,0 +1,209 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+
+	"main/lynx/url_parser"
+)
+
+// message is the parts of an email that are saved to the library.
+type message struct {
+	Subject string
+	From    string
+	Date    *time.Time
+	HTML    string
+	Text    string
+}
+
+// Decodes headers in any charset, not just the handful mime supports
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// Prefixes mail clients add to the subject of forwarded mail
+var forwardPrefix = regexp.MustCompile(`(?i)^\s*((fwd?|fw)\s*:\s*)+`)
+
+// parseMessage reads an email, keeping its first HTML body and its
+// first plain text body. Mail forwarded as an attachment is read in
+// place of the mail it's attached to, since that's what was meant to
+// be saved.
+func parseMessage(raw []byte) (*message, error) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	msg := &message{}
+	if subject, err := wordDecoder.DecodeHeader(parsed.Header.Get("Subject")); err == nil {
+		msg.Subject = strings.TrimSpace(forwardPrefix.ReplaceAllString(subject, ""))
+	}
+	addressParser := &mail.AddressParser{WordDecoder: wordDecoder}
+	if from, err := addressParser.Parse(parsed.Header.Get("From")); err == nil {
+		msg.From = from.Name
+		if msg.From == "" {
+			msg.From = from.Address
+		}
+	}
+	if date, err := parsed.Header.Date(); err == nil {
+		date = date.UTC()
+		msg.Date = &date
+	}
+
+	var forwarded *message
+	err = readPart(textproto.MIMEHeader(parsed.Header), parsed.Body, msg, &forwarded)
+	if err != nil {
+		return nil, err
+	}
+	if forwarded != nil && (forwarded.HTML != "" || forwarded.Text != "") {
+		return forwarded, nil
+	}
+	if msg.HTML == "" && msg.Text == "" {
+		return nil, errors.New("message has no text")
+	}
+	return msg, nil
+}
+
+// readPart reads a part of a message, and any parts inside it, into
+// msg.
+func readPart(header textproto.MIMEHeader, body io.Reader, msg *message, forwarded **message) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" && mediaType != "message/rfc822" {
+		return nil
+	}
+
+	// The multipart reader decodes quoted-printable parts itself, but
+	// not the message body or base64 parts
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := parts.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read message part: %w", err)
+			}
+			if err := readPart(part.Header, part, msg, forwarded); err != nil {
+				return err
+			}
+		}
+	case mediaType == "message/rfc822":
+		if *forwarded != nil {
+			return nil
+		}
+		attached, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("failed to read forwarded message: %w", err)
+		}
+		if *forwarded, err = parseMessage(attached); err != nil {
+			// Not worth failing over, the forwarding message may still
+			// have something to save
+			*forwarded = nil
+		}
+		return nil
+	case mediaType == "text/html" && msg.HTML == "":
+		text, err := decodeText(body, params["charset"])
+		msg.HTML = text
+		return err
+	case mediaType == "text/plain" && msg.Text == "":
+		text, err := decodeText(body, params["charset"])
+		msg.Text = text
+		return err
+	}
+	return nil
+}
+
+// decodeText reads text in the given charset as UTF-8.
+func decodeText(body io.Reader, charsetLabel string) (string, error) {
+	if charsetLabel != "" && !strings.EqualFold(charsetLabel, "utf-8") && !strings.EqualFold(charsetLabel, "us-ascii") {
+		reader, err := charset.NewReaderLabel(charsetLabel, body)
+		if err == nil {
+			body = reader
+		}
+	}
+	text, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message text: %w", err)
+	}
+	return string(text), nil
+}
+
+// Links in newsletters that are about the newsletter, not part of it
+var boilerplateLink = regexp.MustCompile(`(?i)unsubscribe|preferences|view (it )?(in|on) (your |a )?browser|view online|forward to a friend`)
+
+// messageLinks returns the web pages an email links to, up to limit,
+// leaving out links for managing the subscription.
+func messageLinks(body string, limit int) []*url.URL {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var links []*url.URL
+	seen := map[string]bool{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if len(links) >= limit {
+			return
+		}
+		if node.Type == html.ElementNode && node.DataAtom == atom.A {
+			href := ""
+			for _, attr := range node.Attr {
+				if attr.Key == "href" {
+					href = strings.TrimSpace(attr.Val)
+				}
+			}
+			link, err := url.Parse(href)
+			if err == nil && (link.Scheme == "http" || link.Scheme == "https") && link.Host != "" &&
+				!boilerplateLink.MatchString(href) && !boilerplateLink.MatchString(nodeText(node)) {
+				if normalized := url_parser.NormalizeURL(link); !seen[normalized] {
+					seen[normalized] = true
+					links = append(links, link)
+				}
+			}
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+func nodeText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		text.WriteString(nodeText(child))
+	}
+	return text.String()
+}
@@ -0,0 +1,139 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// crlf converts a message written with plain newlines to the line
+// endings mail is sent with.
+func crlf(message string) []byte {
+	return []byte(strings.ReplaceAll(message, "\n", "\r\n"))
+}
+
+const newsletter = `From: =?UTF-8?Q?Caf=C3=A9_Weekly?= <news@cafe.example.com>
+To: abc@lynx.local
+Subject: Fwd: =?UTF-8?B?VGhpcyB3ZWVrIGF0IHRoZSBjYWbDqQ==?=
+Date: Tue, 07 Jan 2025 09:30:00 +0100
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="alt"
+
+--alt
+Content-Type: text/plain; charset=utf-8
+
+Plain version
+--alt
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+<html><body><p style=3D"margin:0">Caf=C3=A9 news</p></body></html>
+--alt--
+`
+
+func TestParseMessage(t *testing.T) {
+	msg, err := parseMessage(crlf(newsletter))
+	require.NoError(t, err)
+	assert.Equal(t, "This week at the café", msg.Subject)
+	assert.Equal(t, "Café Weekly", msg.From)
+	require.NotNil(t, msg.Date)
+	assert.Equal(t, time.Date(2025, 1, 7, 8, 30, 0, 0, time.UTC), *msg.Date)
+	assert.Equal(t, `<html><body><p style="margin:0">Café news</p></body></html>`, strings.TrimSpace(msg.HTML))
+	assert.Equal(t, "Plain version", strings.TrimSpace(msg.Text))
+}
+
+func TestParseMessageEncodings(t *testing.T) {
+	// Single part, base64 and in another charset
+	msg, err := parseMessage(crlf(`From: someone@example.com
+Subject: Latin-1
+Content-Type: text/html; charset=iso-8859-1
+Content-Transfer-Encoding: base64
+
+PHA+Q2Fm6TwvcD4=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "someone@example.com", msg.From)
+	assert.Equal(t, "<p>Café</p>", msg.HTML)
+	assert.Nil(t, msg.Date)
+
+	// No content type at all is plain text
+	msg, err = parseMessage(crlf("Subject: Note\n\nJust text\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "Just text\r\n", msg.Text)
+	assert.Empty(t, msg.HTML)
+}
+
+func TestParseMessageForwardedAsAttachment(t *testing.T) {
+	msg, err := parseMessage(crlf(`From: Me <me@example.com>
+Subject: Fwd: Something to read
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: text/plain
+
+Thought you'd like this
+--outer
+Content-Type: application/pdf
+Content-Disposition: attachment; filename="invoice.pdf"
+
+%PDF-1.4
+--outer
+Content-Type: message/rfc822
+Content-Disposition: attachment
+
+From: The Newsletter <news@example.com>
+Subject: Something to read
+Content-Type: text/html
+
+<p>The newsletter</p>
+--outer--
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "Something to read", msg.Subject)
+	assert.Equal(t, "The Newsletter", msg.From)
+	assert.Equal(t, "<p>The newsletter</p>", strings.TrimSpace(msg.HTML))
+	assert.Empty(t, msg.Text)
+}
+
+func TestParseMessageWithoutText(t *testing.T) {
+	_, err := parseMessage(crlf(`Subject: Just an attachment
+Content-Type: multipart/mixed; boundary="b"
+
+--b
+Content-Type: image/png
+Content-Disposition: attachment; filename="photo.png"
+
+xyz
+--b--
+`))
+	assert.EqualError(t, err, "message has no text")
+
+	_, err = parseMessage([]byte("not an email"))
+	assert.Error(t, err)
+}
+
+func TestMessageLinks(t *testing.T) {
+	links := messageLinks(`<html><body>
+		<a href="https://example.com/view?id=1">View in browser</a>
+		<a href="https://blog.example.com/post-one?utm_source=newsletter">Post one</a>
+		<a href="https://blog.example.com/post-one">Post one again</a>
+		<a href="mailto:editor@example.com">Write to us</a>
+		<a href="/relative">Relative</a>
+		<a href="https://blog.example.com/post-two"><img src="x.png"></a>
+		<a href="https://example.com/unsubscribe?u=1">Stop these emails</a>
+		<a href="https://example.com/account">Manage your <b>preferences</b></a>
+		<a href="https://blog.example.com/post-three">Post three</a>
+	</body></html>`, 2)
+
+	var urls []string
+	for _, link := range links {
+		urls = append(urls, link.String())
+	}
+	assert.Equal(t, []string{
+		"https://blog.example.com/post-one?utm_source=newsletter",
+		"https://blog.example.com/post-two",
+	}, urls)
+}
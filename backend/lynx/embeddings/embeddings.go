@@ -0,0 +1,189 @@
+package embeddings
+
+// Computes embedding vectors for links and highlights with the user's
+// configured provider and stores them in the `embeddings` collection,
+// so that content can be searched by meaning rather than keywords.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/llm"
+)
+
+// Used when the user hasn't picked an embedding model. Self-hosted
+// OpenAI-compatible servers have no sensible default, so those users
+// need to set one explicitly.
+const (
+	defaultOpenRouterModel = "openai/text-embedding-3-small"
+	defaultOpenAIModel     = "text-embedding-3-small"
+)
+
+// Embedding models typically accept around 8k tokens, so only the
+// start of long articles is embedded.
+const maxInputChars = 24_000
+
+// Overridden in tests to point at a mock server
+var newEmbedder = llm.EmbedderFromSettings
+
+var (
+	ErrSettingsNotFound = errors.New("user_settings not found for user")
+	ErrMissingModel     = errors.New("embedding model not set for user")
+)
+
+// ModelForSettings returns the embedding model to use for a user, or
+// an empty string if embeddings can't be computed for them.
+func ModelForSettings(settings *core.Record) string {
+	if model := settings.GetString("embedding_model"); model != "" {
+		return model
+	}
+	switch llm.ProviderName(settings) {
+	case llm.ProviderOpenRouter:
+		return defaultOpenRouterModel
+	case llm.ProviderOpenAI:
+		if settings.GetString("openai_base_url") == "" {
+			return defaultOpenAIModel
+		}
+	}
+	return ""
+}
+
+// MaybeEmbedLink computes an embedding for the link's title and text
+// if it doesn't already have an up to date one. Users without a
+// provider that supports embeddings are skipped.
+func MaybeEmbedLink(app core.App, linkID string) error {
+	logger := app.Logger().With("action", "embedLink", "linkID", linkID)
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		logger.Error("Embedding failed, failed to find link", "error", err)
+		return fmt.Errorf("failed to find link: %w", err)
+	}
+
+	text := strings.TrimSpace(link.GetString("raw_text_content"))
+	if text == "" {
+		logger.Info("Embedding skipped, link has no text content")
+		return nil
+	}
+	if title := link.GetString("title"); title != "" {
+		text = title + "\n\n" + text
+	}
+
+	return maybeEmbed(app, logger, link.GetString("user"), text, dbx.HashExp{"link": link.Id, "highlight": ""}, func(record *core.Record) {
+		record.Set("link", link.Id)
+	})
+}
+
+// MaybeEmbedHighlight computes an embedding for the highlighted text
+// if it doesn't already have an up to date one.
+func MaybeEmbedHighlight(app core.App, highlightID string) error {
+	logger := app.Logger().With("action", "embedHighlight", "highlightID", highlightID)
+
+	highlight, err := app.FindRecordById("highlights", highlightID)
+	if err != nil {
+		logger.Error("Embedding failed, failed to find highlight", "error", err)
+		return fmt.Errorf("failed to find highlight: %w", err)
+	}
+
+	text := strings.TrimSpace(highlight.GetString("highlighted_text"))
+	if text == "" {
+		logger.Info("Embedding skipped, highlight has no text")
+		return nil
+	}
+
+	return maybeEmbed(app, logger, highlight.GetString("user"), text, dbx.HashExp{"highlight": highlight.Id}, func(record *core.Record) {
+		record.Set("link", highlight.GetString("link"))
+		record.Set("highlight", highlight.Id)
+	})
+}
+
+// maybeEmbed embeds text for the user and saves it to the embeddings
+// record matching existing, creating one with setSource if needed.
+func maybeEmbed(app core.App, logger *slog.Logger, userID string, text string, existing dbx.HashExp, setSource func(record *core.Record)) error {
+	userSettings, err := app.FindFirstRecordByFilter("user_settings", "user = {:user}",
+		dbx.Params{
+			"user": userID,
+		})
+	if err != nil {
+		logger.Info("Embedding skipped, user_settings not found", "userID", userID)
+		return nil
+	}
+
+	model := ModelForSettings(userSettings)
+	if model == "" {
+		logger.Info("Embedding skipped, embedding model not set for user", "userID", userID)
+		return nil
+	}
+
+	embedder, err := newEmbedder(userSettings)
+	if errors.Is(err, llm.ErrEmbeddingsNotSupported) || errors.Is(err, llm.ErrMissingAPIKey) {
+		logger.Info("Embedding skipped, provider can't compute embeddings", "userID", userID, "reason", err)
+		return nil
+	}
+	if err != nil {
+		logger.Error("Embedding failed, LLM provider not configured for user", "userID", userID, "error", err)
+		return err
+	}
+
+	text = truncate(text)
+	hash := contentHash(model, text)
+
+	record := &core.Record{}
+	err = app.RecordQuery("embeddings").AndWhere(existing).Limit(1).One(record)
+	if err == nil && record.GetString("content_hash") == hash {
+		logger.Info("Embedding skipped, embedding is up to date")
+		return nil
+	}
+	if err != nil {
+		collection, err := app.FindCollectionByNameOrId("embeddings")
+		if err != nil {
+			return fmt.Errorf("failed to find embeddings collection: %w", err)
+		}
+		record = core.NewRecord(collection)
+		record.Set("user", userID)
+		setSource(record)
+	}
+
+	resp, err := embedder.Embed(llm.EmbeddingRequest{
+		Model: model,
+		Input: []string{text},
+	})
+	if err != nil {
+		logger.Error("Embedding failed due to API error", "error", err)
+		return err
+	}
+	if len(resp.Vectors) != 1 || len(resp.Vectors[0]) == 0 {
+		return fmt.Errorf("no embedding in response")
+	}
+
+	record.Set("model", model)
+	record.Set("content_hash", hash)
+	record.Set("vector", resp.Vectors[0])
+	if err := app.Save(record); err != nil {
+		logger.Error("Embedding failed, failed to save embedding", "error", err)
+		return fmt.Errorf("failed to save embedding: %w", err)
+	}
+
+	logger.Info("Saved embedding", "model", model, "dimensions", len(resp.Vectors[0]), "promptTokens", resp.Usage.PromptTokens)
+	return nil
+}
+
+func contentHash(model string, text string) string {
+	sum := sha256.Sum256([]byte(model + "\n" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(text string) string {
+	if utf8.RuneCountInString(text) <= maxInputChars {
+		return text
+	}
+	return string([]rune(text)[:maxInputChars])
+}
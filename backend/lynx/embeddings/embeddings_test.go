@@ -0,0 +1,229 @@
+package embeddings
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/llm"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+// fakeEmbedder returns a two dimensional vector for each input,
+// pointing towards "databases" or "cooking" depending on the words it
+// contains.
+type fakeEmbedder struct {
+	requests []llm.EmbeddingRequest
+	err      error
+}
+
+func (e *fakeEmbedder) Embed(req llm.EmbeddingRequest) (*llm.EmbeddingResponse, error) {
+	e.requests = append(e.requests, req)
+	if e.err != nil {
+		return nil, e.err
+	}
+	resp := &llm.EmbeddingResponse{Usage: llm.Usage{PromptTokens: 10}}
+	for _, input := range req.Input {
+		vector := []float32{0.1, 0.1}
+		if strings.Contains(strings.ToLower(input), "sqlite") {
+			vector[0] = 1
+		}
+		if strings.Contains(strings.ToLower(input), "recipe") {
+			vector[1] = 1
+		}
+		resp.Vectors = append(resp.Vectors, vector)
+	}
+	return resp, nil
+}
+
+func setupTestApp(t *testing.T, embedder llm.Embedder, embedderErr error) *tests.TestApp {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+
+	originalNewEmbedder := newEmbedder
+	newEmbedder = func(settings *core.Record) (llm.Embedder, error) {
+		return embedder, embedderErr
+	}
+	t.Cleanup(func() {
+		newEmbedder = originalNewEmbedder
+	})
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("title", "Choosing a Database")
+	link.Set("raw_text_content", "For most small projects, SQLite is all you need.")
+	require.NoError(t, testApp.Save(link))
+
+	return testApp
+}
+
+func createUserSettings(t *testing.T, app core.App, userID string, values map[string]any) *core.Record {
+	collection, err := app.FindCollectionByNameOrId("user_settings")
+	require.NoError(t, err)
+
+	settings := core.NewRecord(collection)
+	settings.Set("user", userID)
+	for key, value := range values {
+		settings.Set(key, value)
+	}
+	require.NoError(t, app.Save(settings))
+	return settings
+}
+
+func createHighlight(t *testing.T, app core.App, userID string, linkID string, text string) *core.Record {
+	collection, err := app.FindCollectionByNameOrId("highlights")
+	require.NoError(t, err)
+
+	highlight := core.NewRecord(collection)
+	highlight.Set("user", userID)
+	highlight.Set("link", linkID)
+	highlight.Set("highlighted_text", text)
+	highlight.Set("link_backup_title", "Backup title")
+	highlight.Set("serialized_range", "0:0")
+	require.NoError(t, app.Save(highlight))
+	return highlight
+}
+
+func findEmbeddings(t *testing.T, app core.App, filter string, params dbx.Params) []*core.Record {
+	records, err := app.FindRecordsByFilter("embeddings", filter, "", 0, 0, params)
+	require.NoError(t, err)
+	return records
+}
+
+func TestModelForSettings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   map[string]any
+		expected string
+	}{
+		{name: "Explicit model", values: map[string]any{"llm_provider": "anthropic", "embedding_model": "nomic-embed-text"}, expected: "nomic-embed-text"},
+		{name: "OpenRouter default", values: map[string]any{}, expected: defaultOpenRouterModel},
+		{name: "OpenAI default", values: map[string]any{"llm_provider": "openai"}, expected: defaultOpenAIModel},
+		{name: "Self-hosted without a model", values: map[string]any{"llm_provider": "openai", "openai_base_url": "http://localhost:11434/v1"}, expected: ""},
+		{name: "Anthropic without a model", values: map[string]any{"llm_provider": "anthropic"}, expected: ""},
+	}
+
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+	collection, err := testApp.FindCollectionByNameOrId("user_settings")
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := core.NewRecord(collection)
+			for key, value := range tc.values {
+				settings.Set(key, value)
+			}
+			assert.Equal(t, tc.expected, ModelForSettings(settings))
+		})
+	}
+}
+
+func TestMaybeEmbedLink(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	testApp := setupTestApp(t, embedder, nil)
+
+	// Without settings there's nothing to do
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	assert.Empty(t, embedder.requests)
+
+	createUserSettings(t, testApp, testUserID, map[string]any{"openrouter_api_key": "key"})
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	require.Len(t, embedder.requests, 1)
+	assert.Equal(t, llm.EmbeddingRequest{
+		Model: defaultOpenRouterModel,
+		Input: []string{"Choosing a Database\n\nFor most small projects, SQLite is all you need."},
+	}, embedder.requests[0])
+
+	records := findEmbeddings(t, testApp, "link = {:link}", dbx.Params{"link": testLinkID})
+	require.Len(t, records, 1)
+	assert.Equal(t, testUserID, records[0].GetString("user"))
+	assert.Equal(t, defaultOpenRouterModel, records[0].GetString("model"))
+	assert.Empty(t, records[0].GetString("highlight"))
+	var vector []float32
+	require.NoError(t, records[0].UnmarshalJSONField("vector", &vector))
+	assert.Equal(t, []float32{1, 0.1}, vector)
+
+	// Unchanged text isn't embedded again
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	assert.Len(t, embedder.requests, 1)
+
+	// Changed text updates the existing embedding
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("raw_text_content", "A recipe for bread.")
+	require.NoError(t, testApp.Save(link))
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	assert.Len(t, embedder.requests, 2)
+
+	updated := findEmbeddings(t, testApp, "link = {:link}", dbx.Params{"link": testLinkID})
+	require.Len(t, updated, 1)
+	assert.Equal(t, records[0].Id, updated[0].Id)
+	assert.NotEqual(t, records[0].GetString("content_hash"), updated[0].GetString("content_hash"))
+}
+
+func TestMaybeEmbedLinkTruncatesLongText(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	testApp := setupTestApp(t, embedder, nil)
+	createUserSettings(t, testApp, testUserID, map[string]any{"embedding_model": "custom-model"})
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("raw_text_content", strings.Repeat("a", maxInputChars*2))
+	require.NoError(t, testApp.Save(link))
+
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	require.Len(t, embedder.requests, 1)
+	assert.Equal(t, "custom-model", embedder.requests[0].Model)
+	assert.Len(t, embedder.requests[0].Input[0], maxInputChars)
+}
+
+func TestMaybeEmbedLinkSkipsUnsupportedProviders(t *testing.T) {
+	testApp := setupTestApp(t, nil, llm.ErrEmbeddingsNotSupported)
+	createUserSettings(t, testApp, testUserID, map[string]any{"embedding_model": "custom-model"})
+
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	assert.Empty(t, findEmbeddings(t, testApp, "link = {:link}", dbx.Params{"link": testLinkID}))
+}
+
+func TestMaybeEmbedLinkAPIError(t *testing.T) {
+	embedder := &fakeEmbedder{err: errors.New("API request failed with status 500: oops")}
+	testApp := setupTestApp(t, embedder, nil)
+	createUserSettings(t, testApp, testUserID, map[string]any{})
+
+	err := MaybeEmbedLink(testApp, testLinkID)
+	assert.EqualError(t, err, "API request failed with status 500: oops")
+	assert.Empty(t, findEmbeddings(t, testApp, "link = {:link}", dbx.Params{"link": testLinkID}))
+}
+
+func TestMaybeEmbedHighlight(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	testApp := setupTestApp(t, embedder, nil)
+	createUserSettings(t, testApp, testUserID, map[string]any{})
+
+	highlight := createHighlight(t, testApp, testUserID, testLinkID, "SQLite is all you need")
+	require.NoError(t, MaybeEmbedHighlight(testApp, highlight.Id))
+	require.Len(t, embedder.requests, 1)
+	assert.Equal(t, []string{"SQLite is all you need"}, embedder.requests[0].Input)
+
+	records := findEmbeddings(t, testApp, "highlight = {:highlight}", dbx.Params{"highlight": highlight.Id})
+	require.Len(t, records, 1)
+	assert.Equal(t, testLinkID, records[0].GetString("link"))
+
+	// The link's own embedding is tracked separately
+	require.NoError(t, MaybeEmbedLink(testApp, testLinkID))
+	assert.Len(t, embedder.requests, 2)
+	assert.Len(t, findEmbeddings(t, testApp, "link = {:link}", dbx.Params{"link": testLinkID}), 2)
+}
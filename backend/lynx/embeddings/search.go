@@ -0,0 +1,232 @@
+package embeddings
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/llm"
+)
+
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 50
+)
+
+const (
+	ResultTypeLink      = "link"
+	ResultTypeHighlight = "highlight"
+)
+
+var (
+	ErrEmptyQuery  = errors.New("query is required")
+	ErrNoEmbedding = errors.New("link has no embedding")
+)
+
+type SearchResult struct {
+	// Either "link" or "highlight"
+	Type string `json:"type"`
+	// ID of the link or highlight
+	ID     string `json:"id"`
+	LinkID string `json:"link"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	// The highlighted text, only set for highlights
+	Text  string  `json:"text,omitempty"`
+	Score float64 `json:"score"`
+}
+
+// Search embeds the query and returns the user's links and highlights
+// whose embeddings are closest to it by cosine similarity. Libraries
+// are small enough that comparing against every stored vector is fast
+// enough, so there is no index.
+func Search(app core.App, userID string, query string, limit int) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	userSettings, err := app.FindFirstRecordByFilter("user_settings", "user = {:user}",
+		dbx.Params{
+			"user": userID,
+		})
+	if err != nil {
+		return nil, ErrSettingsNotFound
+	}
+
+	model := ModelForSettings(userSettings)
+	if model == "" {
+		return nil, ErrMissingModel
+	}
+
+	embedder, err := newEmbedder(userSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := embedder.Embed(llm.EmbeddingRequest{
+		Model: model,
+		Input: []string{query},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Vectors) != 1 || len(resp.Vectors[0]) == 0 {
+		return nil, fmt.Errorf("no embedding in response")
+	}
+	queryVector := resp.Vectors[0]
+
+	// Vectors from different models aren't comparable, so only look at
+	// embeddings made with the model the user has now.
+	records, err := app.FindRecordsByFilter(
+		"embeddings",
+		"user = {:user} && model = {:model}",
+		"",
+		0,
+		0,
+		dbx.Params{"user": userID, "model": model},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embeddings: %w", err)
+	}
+
+	type match struct {
+		record *core.Record
+		score  float64
+	}
+	var matches []match
+	for _, record := range records {
+		var vector []float32
+		if err := record.UnmarshalJSONField("vector", &vector); err != nil {
+			continue
+		}
+		score, ok := cosineSimilarity(queryVector, vector)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{record: record, score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	results := make([]SearchResult, 0, len(matches))
+	for _, m := range matches {
+		result, err := buildResult(app, m.record)
+		if err != nil {
+			// The link or highlight was deleted since it was embedded
+			continue
+		}
+		result.Score = m.score
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func buildResult(app core.App, embedding *core.Record) (SearchResult, error) {
+	if highlightID := embedding.GetString("highlight"); highlightID != "" {
+		highlight, err := app.FindRecordById("highlights", highlightID)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		result := SearchResult{
+			Type:   ResultTypeHighlight,
+			ID:     highlight.Id,
+			LinkID: highlight.GetString("link"),
+			Title:  highlight.GetString("link_backup_title"),
+			URL:    highlight.GetString("link_backup_url"),
+			Text:   highlight.GetString("highlighted_text"),
+		}
+		if link, err := app.FindRecordById("links", result.LinkID); err == nil {
+			result.Title = link.GetString("title")
+			result.URL = link.GetString("cleaned_url")
+		}
+		return result, nil
+	}
+
+	link, err := app.FindRecordById("links", embedding.GetString("link"))
+	if err != nil {
+		return SearchResult{}, err
+	}
+	return SearchResult{
+		Type:   ResultTypeLink,
+		ID:     link.Id,
+		LinkID: link.Id,
+		Title:  link.GetString("title"),
+		URL:    link.GetString("cleaned_url"),
+	}, nil
+}
+
+// cosineSimilarity returns false if the vectors can't be compared,
+// i.e. they have different dimensions or one of them is all zeros.
+func cosineSimilarity(a []float32, b []float32) (float64, bool) {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0, false
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, false
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), true
+}
+
+// LinkSimilarities returns the cosine similarity between the link's
+// embedding and the embeddings of the user's other links, keyed by
+// link ID. Returns ErrNoEmbedding if the link hasn't been embedded.
+func LinkSimilarities(app core.App, linkID string) (map[string]float64, error) {
+	target := &core.Record{}
+	err := app.RecordQuery("embeddings").
+		AndWhere(dbx.HashExp{"link": linkID, "highlight": ""}).
+		Limit(1).
+		One(target)
+	if err != nil {
+		return nil, ErrNoEmbedding
+	}
+
+	var targetVector []float32
+	if err := target.UnmarshalJSONField("vector", &targetVector); err != nil {
+		return nil, fmt.Errorf("failed to read embedding: %w", err)
+	}
+
+	records, err := app.FindRecordsByFilter(
+		"embeddings",
+		"user = {:user} && model = {:model} && highlight = '' && link != {:link}",
+		"",
+		0,
+		0,
+		dbx.Params{"user": target.GetString("user"), "model": target.GetString("model"), "link": linkID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embeddings: %w", err)
+	}
+
+	similarities := make(map[string]float64, len(records))
+	for _, record := range records {
+		var vector []float32
+		if err := record.UnmarshalJSONField("vector", &vector); err != nil {
+			continue
+		}
+		if score, ok := cosineSimilarity(targetVector, vector); ok {
+			similarities[record.GetString("link")] = score
+		}
+	}
+	return similarities, nil
+}
@@ -0,0 +1,123 @@
+package embeddings
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveEmbedding(t *testing.T, app core.App, userID string, linkID string, highlightID string, model string, vector []float32) {
+	collection, err := app.FindCollectionByNameOrId("embeddings")
+	require.NoError(t, err)
+
+	record := core.NewRecord(collection)
+	record.Set("user", userID)
+	record.Set("link", linkID)
+	record.Set("highlight", highlightID)
+	record.Set("model", model)
+	record.Set("vector", vector)
+	require.NoError(t, app.Save(record))
+}
+
+func TestSearch(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	testApp := setupTestApp(t, embedder, nil)
+	createUserSettings(t, testApp, testUserID, map[string]any{})
+
+	highlight := createHighlight(t, testApp, testUserID, testLinkID, "Bread recipe")
+	saveEmbedding(t, testApp, testUserID, testLinkID, "", defaultOpenRouterModel, []float32{1, 0})
+	saveEmbedding(t, testApp, testUserID, testLinkID, highlight.Id, defaultOpenRouterModel, []float32{0, 1})
+	// Made with a different model, so not comparable
+	saveEmbedding(t, testApp, testUserID, testLinkID, "", "other-model", []float32{1, 0})
+	// Belongs to another user
+	saveEmbedding(t, testApp, "h4oofx0tx2eupnq", "", "", defaultOpenRouterModel, []float32{1, 0})
+
+	results, err := Search(testApp, testUserID, "  sqlite  ", 0)
+	require.NoError(t, err)
+	require.Len(t, embedder.requests, 1)
+	assert.Equal(t, []string{"sqlite"}, embedder.requests[0].Input)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, ResultTypeLink, results[0].Type)
+	assert.Equal(t, testLinkID, results[0].ID)
+	assert.Equal(t, "Choosing a Database", results[0].Title)
+	assert.InDelta(t, 0.995, results[0].Score, 0.001)
+
+	assert.Equal(t, ResultTypeHighlight, results[1].Type)
+	assert.Equal(t, highlight.Id, results[1].ID)
+	assert.Equal(t, testLinkID, results[1].LinkID)
+	assert.Equal(t, "Choosing a Database", results[1].Title)
+	assert.Equal(t, "Bread recipe", results[1].Text)
+	assert.InDelta(t, 0.0995, results[1].Score, 0.001)
+
+	results, err = Search(testApp, testUserID, "recipe", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, highlight.Id, results[0].ID)
+}
+
+func TestSearchErrors(t *testing.T) {
+	testApp := setupTestApp(t, &fakeEmbedder{}, nil)
+
+	_, err := Search(testApp, testUserID, " ", 0)
+	assert.ErrorIs(t, err, ErrEmptyQuery)
+
+	_, err = Search(testApp, testUserID, "sqlite", 0)
+	assert.ErrorIs(t, err, ErrSettingsNotFound)
+
+	createUserSettings(t, testApp, testUserID, map[string]any{"llm_provider": "anthropic"})
+	_, err = Search(testApp, testUserID, "sqlite", 0)
+	assert.ErrorIs(t, err, ErrMissingModel)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	score, ok := cosineSimilarity([]float32{1, 0}, []float32{1, 0})
+	assert.True(t, ok)
+	assert.InDelta(t, 1, score, 1e-9)
+
+	score, ok = cosineSimilarity([]float32{1, 1}, []float32{-1, -1})
+	assert.True(t, ok)
+	assert.InDelta(t, -1, score, 1e-9)
+
+	score, ok = cosineSimilarity([]float32{3, 4}, []float32{4, 3})
+	assert.True(t, ok)
+	assert.InDelta(t, 24.0/25.0, score, 1e-9)
+	assert.False(t, math.IsNaN(score))
+
+	_, ok = cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0})
+	assert.False(t, ok)
+
+	_, ok = cosineSimilarity([]float32{0, 0}, []float32{1, 0})
+	assert.False(t, ok)
+}
+
+func TestLinkSimilarities(t *testing.T) {
+	testApp := setupTestApp(t, &fakeEmbedder{}, nil)
+
+	_, err := LinkSimilarities(testApp, testLinkID)
+	assert.ErrorIs(t, err, ErrNoEmbedding)
+
+	linksCollection, err := testApp.FindCollectionByNameOrId("links")
+	require.NoError(t, err)
+	other := core.NewRecord(linksCollection)
+	other.Set("user", testUserID)
+	other.Set("original_url", "https://example.com/other")
+	other.Set("cleaned_url", "https://example.com/other")
+	other.Set("added_to_library", "2024-08-15 00:00:00.000Z")
+	require.NoError(t, testApp.Save(other))
+
+	highlight := createHighlight(t, testApp, testUserID, other.Id, "Bread recipe")
+	saveEmbedding(t, testApp, testUserID, testLinkID, "", defaultOpenRouterModel, []float32{1, 0})
+	saveEmbedding(t, testApp, testUserID, other.Id, "", defaultOpenRouterModel, []float32{1, 1})
+	// Highlights and other models are ignored
+	saveEmbedding(t, testApp, testUserID, other.Id, highlight.Id, defaultOpenRouterModel, []float32{0, 1})
+	saveEmbedding(t, testApp, testUserID, other.Id, "", "other-model", []float32{1, 0})
+
+	similarities, err := LinkSimilarities(testApp, testLinkID)
+	require.NoError(t, err)
+	require.Len(t, similarities, 1)
+	assert.InDelta(t, math.Sqrt(0.5), similarities[other.Id], 1e-6)
+}
@@ -234,7 +234,7 @@ func MaybeConvertFeedItemToLink(app core.App, feedItemId string) {
 		return
 	}
 
-	link, err := url_parser.HandleParseURLViaParams(app, feedItem.GetString("user"), urlObj, feedItem)
+	link, err := url_parser.HandleParseURLViaParams(app, feedItem.GetString("user"), urlObj, feedItem, url_parser.OnDuplicateReturn)
 	if err != nil {
 		logger.Error("Unable to convert feed item to link", "error", err)
 		return
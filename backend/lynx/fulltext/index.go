@@ -0,0 +1,103 @@
+package fulltext
+
+// Full-text search over links and highlights using the SQLite FTS5
+// table `search_index`. The table isn't a PocketBase collection, so
+// it's kept in sync by record hooks calling the functions below.
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	TypeLink      = "link"
+	TypeHighlight = "highlight"
+)
+
+// Link fields copied into the index. Saving a link without changing
+// any of these doesn't need to touch the index.
+var indexedLinkFields = []string{"title", "author", "excerpt", "raw_text_content", "summary"}
+
+// LinkChanged reports whether any indexed field differs from when the
+// link was loaded.
+func LinkChanged(link *core.Record) bool {
+	original := link.Original()
+	for _, field := range indexedLinkFields {
+		if original.GetString(field) != link.GetString(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexLink adds the link to the index, replacing any existing entry.
+// Highlights show the title of their link, so they're updated too if
+// the title changed.
+func IndexLink(app core.App, link *core.Record) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		if err := Remove(txApp, link.Id); err != nil {
+			return err
+		}
+		_, err := txApp.DB().Insert("search_index", dbx.Params{
+			"record_id":        link.Id,
+			"link_id":          link.Id,
+			"user":             link.GetString("user"),
+			"type":             TypeLink,
+			"title":            link.GetString("title"),
+			"author":           link.GetString("author"),
+			"excerpt":          link.GetString("excerpt"),
+			"raw_text":         link.GetString("raw_text_content"),
+			"summary":          link.GetString("summary"),
+			"highlighted_text": "",
+		}).Execute()
+		if err != nil {
+			return err
+		}
+
+		if link.Original().GetString("title") == link.GetString("title") {
+			return nil
+		}
+		_, err = txApp.DB().Update(
+			"search_index",
+			dbx.Params{"title": link.GetString("title")},
+			dbx.HashExp{"link_id": link.Id, "type": TypeHighlight},
+		).Execute()
+		return err
+	})
+}
+
+// IndexHighlight adds the highlight to the index, replacing any
+// existing entry.
+func IndexHighlight(app core.App, highlight *core.Record) error {
+	title := highlight.GetString("link_backup_title")
+	if linkID := highlight.GetString("link"); linkID != "" {
+		if link, err := app.FindRecordById("links", linkID); err == nil && link.GetString("title") != "" {
+			title = link.GetString("title")
+		}
+	}
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		if err := Remove(txApp, highlight.Id); err != nil {
+			return err
+		}
+		_, err := txApp.DB().Insert("search_index", dbx.Params{
+			"record_id":        highlight.Id,
+			"link_id":          highlight.GetString("link"),
+			"user":             highlight.GetString("user"),
+			"type":             TypeHighlight,
+			"title":            title,
+			"author":           "",
+			"excerpt":          "",
+			"raw_text":         "",
+			"summary":          "",
+			"highlighted_text": highlight.GetString("highlighted_text"),
+		}).Execute()
+		return err
+	})
+}
+
+// Remove deletes the entry for a link or highlight from the index.
+func Remove(app core.App, recordID string) error {
+	_, err := app.DB().Delete("search_index", dbx.HashExp{"record_id": recordID}).Execute()
+	return err
+}
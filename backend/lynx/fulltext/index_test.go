@@ -0,0 +1,77 @@
+package fulltext
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexLink(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	// Existing links are indexed by the migration
+	results, err := SearchMatch(testApp, testUserID, `"example"`, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, testLinkID, results.Items[0].ID)
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	assert.False(t, LinkChanged(link))
+
+	link.Set("summary", "A summary about gardening")
+	assert.True(t, LinkChanged(link))
+	require.NoError(t, testApp.Save(link))
+	require.NoError(t, IndexLink(testApp, link))
+
+	results, err = SearchMatch(testApp, testUserID, `"gardening"`, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+
+	// Reindexing replaces the old entry rather than adding another
+	results, err = SearchMatch(testApp, testUserID, `"example"`, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, results.Items, 1)
+
+	require.NoError(t, Remove(testApp, testLinkID))
+	results, err = SearchMatch(testApp, testUserID, `"example"`, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, results.Items)
+}
+
+func TestIndexHighlight(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	collection, err := testApp.FindCollectionByNameOrId("highlights")
+	require.NoError(t, err)
+	highlight := core.NewRecord(collection)
+	highlight.Set("user", testUserID)
+	highlight.Set("link", testLinkID)
+	highlight.Set("highlighted_text", "Compost needs both greens and browns")
+	highlight.Set("serialized_range", "0:0")
+	require.NoError(t, testApp.Save(highlight))
+	require.NoError(t, IndexHighlight(testApp, highlight))
+
+	results, err := SearchMatch(testApp, testUserID, `"compost"`, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, TypeHighlight, results.Items[0].Type)
+	assert.Equal(t, highlight.Id, results.Items[0].ID)
+	assert.Equal(t, testLinkID, results.Items[0].LinkID)
+	assert.Equal(t, "Example Article", results.Items[0].Title)
+	assert.Equal(t, "<mark>Compost</mark> needs both greens and browns", results.Items[0].Snippet)
+
+	// Renaming the link updates the title shown for its highlights
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("title", "Gardening basics")
+	require.NoError(t, testApp.Save(link))
+	require.NoError(t, IndexLink(testApp, link))
+
+	results, err = SearchMatch(testApp, testUserID, `"compost"`, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, "Gardening basics", results.Items[0].Title)
+}
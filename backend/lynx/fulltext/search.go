@@ -0,0 +1,160 @@
+package fulltext
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Ranks rows of search_index from best to worst match. Matches in
+// titles count for the most, then authors and summaries. The first
+// four weights are for the unindexed id columns.
+const RankExpression = "bm25(search_index, 0, 0, 0, 0, 10.0, 5.0, 2.0, 1.0, 3.0, 2.0)"
+
+// Snippets mark matches with control characters that can't appear in
+// the escaped text, so they can be swapped for <mark> tags afterwards.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+type Result struct {
+	// Either "link" or "highlight"
+	Type string `json:"type" db:"type"`
+	// ID of the link or highlight
+	ID       string `json:"id" db:"record_id"`
+	LinkID   string `json:"link" db:"link_id"`
+	Title    string `json:"title" db:"title"`
+	URL      string `json:"url" db:"url"`
+	Hostname string `json:"hostname" db:"hostname"`
+	// HTML escaped text around the best match, with matching terms
+	// wrapped in <mark> tags
+	Snippet string  `json:"snippet" db:"snippet"`
+	Score   float64 `json:"score" db:"score"`
+}
+
+type Results struct {
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+	TotalItems int      `json:"totalItems"`
+	TotalPages int      `json:"totalPages"`
+	Items      []Result `json:"items"`
+}
+
+// Quote turns text into an FTS5 string, which matches it as a phrase.
+func Quote(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+// SearchMatch returns the user's links and highlights matching an FTS5
+// query, best match first. See searchquery.Query.Match for building
+// the query from the search box.
+func SearchMatch(app core.App, userID string, match string, page int, perPage int) (*Results, error) {
+	page = max(page, 1)
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+	perPage = min(perPage, MaxPerPage)
+
+	params := dbx.Params{"match": match, "user": userID}
+
+	var total int
+	err := app.DB().NewQuery(
+		"SELECT COUNT(*) FROM search_index WHERE search_index MATCH {:match} AND search_index.user = {:user}",
+	).Bind(params).Row(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	results := &Results{
+		Page:       page,
+		PerPage:    perPage,
+		TotalItems: total,
+		TotalPages: (total + perPage - 1) / perPage,
+		Items:      []Result{},
+	}
+	if total == 0 {
+		return results, nil
+	}
+
+	params["limit"] = perPage
+	params["offset"] = (page - 1) * perPage
+	params["markStart"] = markStart
+	params["markEnd"] = markEnd
+	err = app.DB().NewQuery(`
+		SELECT
+			search_index.record_id,
+			search_index.link_id,
+			search_index.type,
+			search_index.title,
+			snippet(search_index, -1, {:markStart}, {:markEnd}, '…', 24) AS snippet,
+			-` + RankExpression + ` AS score,
+			COALESCE(links.cleaned_url, '') AS url,
+			COALESCE(links.hostname, '') AS hostname
+		FROM search_index
+		LEFT JOIN links ON links.id = search_index.link_id
+		WHERE search_index MATCH {:match} AND search_index.user = {:user}
+		ORDER BY ` + RankExpression + `
+		LIMIT {:limit} OFFSET {:offset}
+	`).Bind(params).All(&results.Items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	for i := range results.Items {
+		results.Items[i].Snippet = formatSnippet(results.Items[i].Snippet)
+	}
+	return results, nil
+}
+
+func formatSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, markStart, "<mark>")
+	return strings.ReplaceAll(snippet, markEnd, "</mark>")
+}
+
+// Matches returns the snippet and score of each of the records that
+// matches an FTS5 query, keyed by record ID. Only Snippet and Score
+// are set.
+func Matches(app core.App, match string, recordIDs []string) (map[string]Result, error) {
+	if len(recordIDs) == 0 {
+		return map[string]Result{}, nil
+	}
+
+	var rows []Result
+	err := app.DB().Select(
+		"search_index.record_id",
+		"snippet(search_index, -1, {:markStart}, {:markEnd}, '…', 24) AS snippet",
+		"-"+RankExpression+" AS score",
+	).
+		From("search_index").
+		Where(dbx.NewExp("search_index MATCH {:match}", dbx.Params{"match": match})).
+		AndWhere(dbx.In("search_index.record_id", toAny(recordIDs)...)).
+		Bind(dbx.Params{"markStart": markStart, "markEnd": markEnd}).
+		All(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search snippets: %w", err)
+	}
+
+	matches := make(map[string]Result, len(rows))
+	for _, row := range rows {
+		matches[row.ID] = Result{Snippet: formatSnippet(row.Snippet), Score: row.Score}
+	}
+	return matches, nil
+}
+
+func toAny(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package fulltext
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+func setupTestApp(t *testing.T) *tests.TestApp {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+	return testApp
+}
+
+func createLink(t *testing.T, app core.App, userID string, values map[string]any) *core.Record {
+	collection, err := app.FindCollectionByNameOrId("links")
+	require.NoError(t, err)
+	link := core.NewRecord(collection)
+	link.Set("user", userID)
+	link.Set("original_url", "https://example.com/article")
+	link.Set("cleaned_url", "https://example.com/article")
+	link.Set("hostname", "example.com")
+	link.Set("added_to_library", "2024-08-15 00:00:00.000Z")
+	for key, value := range values {
+		link.Set(key, value)
+	}
+	require.NoError(t, app.Save(link))
+	require.NoError(t, IndexLink(app, link))
+	return link
+}
+
+func TestFormatSnippet(t *testing.T) {
+	assert.Equal(t,
+		"&lt;b&gt;use <mark>SQLite</mark>&lt;/b&gt; &amp; more",
+		formatSnippet("<b>use "+markStart+"SQLite"+markEnd+"</b> & more"),
+	)
+}
+
+func TestSearchMatch(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	tuning := createLink(t, testApp, testUserID, map[string]any{
+		"title":            "Tuning SQLite",
+		"raw_text_content": "Enable the write ahead log for better concurrency.",
+	})
+	mention := createLink(t, testApp, testUserID, map[string]any{
+		"title":            "Databases roundup",
+		"raw_text_content": "Postgres, MySQL and SQLite each have their place.",
+	})
+	createLink(t, testApp, "h4oofx0tx2eupnq", map[string]any{
+		"title": "Another user's SQLite notes",
+	})
+
+	results, err := SearchMatch(testApp, testUserID, `"sqlite"`, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.TotalItems)
+	require.Len(t, results.Items, 2)
+	// Title matches rank above body matches
+	assert.Equal(t, tuning.Id, results.Items[0].ID)
+	assert.Equal(t, TypeLink, results.Items[0].Type)
+	assert.Equal(t, "https://example.com/article", results.Items[0].URL)
+	assert.Equal(t, mention.Id, results.Items[1].ID)
+	assert.Contains(t, results.Items[1].Snippet, "<mark>SQLite</mark>")
+	assert.Greater(t, results.Items[0].Score, results.Items[1].Score)
+
+	results, err = SearchMatch(testApp, testUserID, `"write ahead log"`, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, tuning.Id, results.Items[0].ID)
+
+	results, err = SearchMatch(testApp, testUserID, `"ahead write"`, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, results.Items)
+
+	results, err = SearchMatch(testApp, testUserID, `"concurren"*`, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, tuning.Id, results.Items[0].ID)
+
+	// Accents are ignored
+	results, err = SearchMatch(testApp, testUserID, `"cóncurrency"`, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, results.Items, 1)
+
+	results, err = SearchMatch(testApp, testUserID, `"sqlite"`, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.TotalPages)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, mention.Id, results.Items[0].ID)
+
+}
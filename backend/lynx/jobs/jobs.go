@@ -0,0 +1,317 @@
+package jobs
+
+// A small persistent job queue backed by the `jobs` collection.
+// Every enrichment step for a link (summarizing, archiving,
+// suggesting tags, embedding) is stored as a job record so that work
+// survives restarts and failures are visible afterwards.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"main/lynx/retry"
+)
+
+const (
+	TypeSummarize   = "summarize"
+	TypeArchive     = "archive"
+	TypeSuggestTags = "suggest_tags"
+	TypeEmbed       = "embed"
+
+	// TypeEmbedHighlight jobs are keyed on a highlight rather than a
+	// link; queue them with EnqueueHighlight.
+	TypeEmbedHighlight = "embed_highlight"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Handler processes a single job for the given record, which is the
+// job's link or, for jobs queued with EnqueueHighlight, its highlight.
+// Returning an error marks the job as failed, unless the error is
+// retryable (see retry.Retryable) in which case the job is rescheduled
+// with backoff.
+type Handler func(app core.App, recordID string) error
+
+type Queue struct {
+	app      core.App
+	workers  int
+	handlers map[string]Handler
+
+	// wake is signalled whenever there may be pending jobs to claim.
+	wake chan struct{}
+
+	mu   sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewQueue(app core.App, workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Queue{
+		app:      app,
+		workers:  workers,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.handlers[jobType] = handler
+}
+
+// Enqueue creates a pending job of the given type for the link and
+// wakes up an idle worker. If an identical job is already waiting to
+// run, that job is returned instead of creating a duplicate, and runs
+// right away rather than waiting out any retry backoff.
+func (q *Queue) Enqueue(link *core.Record, jobType string) (*core.Record, error) {
+	return q.enqueue(jobType, link.GetString("user"), "link", link.Id)
+}
+
+// EnqueueHighlight is like Enqueue, for jobs that work on a single
+// highlight.
+func (q *Queue) EnqueueHighlight(highlight *core.Record, jobType string) (*core.Record, error) {
+	return q.enqueue(jobType, highlight.GetString("user"), "highlight", highlight.Id)
+}
+
+func (q *Queue) enqueue(jobType string, userID string, field string, recordID string) (*core.Record, error) {
+	if _, ok := q.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("unknown job type %q", jobType)
+	}
+
+	existing, _ := q.app.FindFirstRecordByFilter(
+		"jobs",
+		field+" = {:record} && type = {:type} && status = {:status}",
+		dbx.Params{"record": recordID, "type": jobType, "status": StatusPending},
+	)
+	if existing != nil {
+		// Asking for the job again means it should run now, even if
+		// it's waiting to be retried after failing
+		if !existing.GetDateTime("next_attempt_at").IsZero() {
+			existing.Set("next_attempt_at", "")
+			existing.Set("attempts", 0)
+			if err := q.app.Save(existing); err != nil {
+				return nil, fmt.Errorf("failed to save job: %w", err)
+			}
+		}
+		q.notify()
+		return existing, nil
+	}
+
+	collection, err := q.app.FindCollectionByNameOrId("jobs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find jobs collection: %w", err)
+	}
+
+	job := core.NewRecord(collection)
+	job.Set("user", userID)
+	job.Set(field, recordID)
+	job.Set("type", jobType)
+	job.Set("status", StatusPending)
+	if err := q.app.Save(job); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	q.notify()
+	return job, nil
+}
+
+// Start launches the worker pool. Jobs that were left running by a
+// previous process are moved back to pending so they are retried,
+// and any pending jobs are picked up immediately.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stop != nil {
+		return
+	}
+	q.stop = make(chan struct{})
+
+	if err := q.requeueInterrupted(); err != nil {
+		q.app.Logger().Error("Failed to requeue interrupted jobs", "error", err)
+	}
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(q.stop)
+	}
+	q.notify()
+}
+
+// Stop signals all workers to exit and waits for any in-flight jobs
+// to finish.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	if q.stop == nil {
+		q.mu.Unlock()
+		return
+	}
+	close(q.stop)
+	q.stop = nil
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+// RetryDue wakes the workers so that jobs whose next attempt has come
+// due get picked up. Intended to be called periodically.
+func (q *Queue) RetryDue() {
+	q.notify()
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) work(stop <-chan struct{}) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		job, err := q.claimNext()
+		if err != nil {
+			q.app.Logger().Error("Failed to claim job", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-q.wake:
+			case <-stop:
+				return
+			}
+			continue
+		}
+
+		// There may be more work queued up, so give another idle
+		// worker a chance to pick it up while this one is busy.
+		q.notify()
+		q.run(job)
+	}
+}
+
+// claimNext atomically moves the oldest pending job that is due to
+// running and returns it, or returns nil if there is nothing to do.
+func (q *Queue) claimNext() (*core.Record, error) {
+	var claimed *core.Record
+	err := q.app.RunInTransaction(func(txApp core.App) error {
+		records, err := txApp.FindRecordsByFilter(
+			"jobs",
+			"status = {:status} && (next_attempt_at = '' || next_attempt_at <= {:now})",
+			"created",
+			1,
+			0,
+			dbx.Params{"status": StatusPending, "now": types.NowDateTime().String()},
+		)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		job := records[0]
+		job.Set("status", StatusRunning)
+		job.Set("started_at", types.NowDateTime())
+		if err := txApp.Save(job); err != nil {
+			return err
+		}
+		claimed = job
+		return nil
+	})
+	return claimed, err
+}
+
+func (q *Queue) run(job *core.Record) {
+	jobType := job.GetString("type")
+	linkID := job.GetString("link")
+	highlightID := job.GetString("highlight")
+	logger := q.app.Logger().With("action", "runJob", "jobID", job.Id, "type", jobType, "linkID", linkID, "highlightID", highlightID)
+
+	recordID := linkID
+	if highlightID != "" {
+		recordID = highlightID
+	}
+	err := q.runHandler(jobType, recordID)
+
+	attempts := job.GetInt("attempts") + 1
+	job.Set("attempts", attempts)
+	job.Set("finished_at", types.NowDateTime())
+	if err == nil {
+		job.Set("status", StatusSucceeded)
+		job.Set("error", "")
+		job.Set("next_attempt_at", "")
+	} else if retryable, retryAfter := retry.IsRetryable(err); retryable && attempts < retry.MaxAttempts {
+		delay := retry.Backoff(attempts, retryAfter)
+		logger.Warn("Job failed, scheduling retry", "error", err, "attempts", attempts, "delay", delay)
+		job.Set("status", StatusPending)
+		job.Set("error", err.Error())
+		job.Set("next_attempt_at", time.Now().UTC().Add(delay))
+	} else {
+		logger.Error("Job failed", "error", err, "attempts", attempts)
+		job.Set("status", StatusFailed)
+		job.Set("error", err.Error())
+		job.Set("next_attempt_at", "")
+	}
+
+	if err := q.app.Save(job); err != nil {
+		logger.Error("Failed to update job status", "error", err)
+	}
+}
+
+func (q *Queue) runHandler(jobType string, recordID string) (err error) {
+	handler, ok := q.handlers[jobType]
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %q", jobType)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(q.app, recordID)
+}
+
+func (q *Queue) requeueInterrupted() error {
+	records, err := q.app.FindRecordsByFilter(
+		"jobs",
+		"status = {:status}",
+		"",
+		0,
+		0,
+		dbx.Params{"status": StatusRunning},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range records {
+		job.Set("status", StatusPending)
+		if err := q.app.Save(job); err != nil {
+			return err
+		}
+	}
+
+	if len(records) > 0 {
+		q.app.Logger().Info("Requeued interrupted jobs", "count", len(records))
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"main/lynx/retry"
+)
+
+const testLinkID = "8n3iq8dt6vwi4ph"
+
+func setupTestApp(t *testing.T) (*tests.TestApp, *core.Record) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(testApp.Cleanup)
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testApp, link
+}
+
+func waitForStatus(t *testing.T, app core.App, jobID string, status string) *core.Record {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := app.FindRecordById("jobs", jobID)
+		if err != nil {
+			t.Fatalf("Failed to find job: %v", err)
+		}
+		if job.GetString("status") == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected job status %q, got %q", status, job.GetString("status"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueueRunsJobs(t *testing.T) {
+	testApp, link := setupTestApp(t)
+
+	handled := make(chan string, 1)
+	queue := NewQueue(testApp, 2)
+	queue.Register(TypeSummarize, func(app core.App, linkID string) error {
+		handled <- linkID
+		return nil
+	})
+	queue.Start()
+	defer queue.Stop()
+
+	job, err := queue.Enqueue(link, TypeSummarize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.GetString("user") != link.GetString("user") {
+		t.Errorf("Expected job user %q, got %q", link.GetString("user"), job.GetString("user"))
+	}
+
+	select {
+	case linkID := <-handled:
+		if linkID != testLinkID {
+			t.Errorf("Expected handler to be called with %q, got %q", testLinkID, linkID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Handler was not called")
+	}
+
+	job = waitForStatus(t, testApp, job.Id, StatusSucceeded)
+	if job.GetDateTime("started_at").IsZero() || job.GetDateTime("finished_at").IsZero() {
+		t.Error("Expected started_at and finished_at to be set")
+	}
+}
+
+func TestQueueRecordsFailures(t *testing.T) {
+	testApp, link := setupTestApp(t)
+
+	queue := NewQueue(testApp, 1)
+	queue.Register(TypeArchive, func(app core.App, linkID string) error {
+		return errors.New("singlefile service returned status 502")
+	})
+	queue.Register(TypeSuggestTags, func(app core.App, linkID string) error {
+		panic("boom")
+	})
+	queue.Start()
+	defer queue.Stop()
+
+	archiveJob, err := queue.Enqueue(link, TypeArchive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagsJob, err := queue.Enqueue(link, TypeSuggestTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archiveJob = waitForStatus(t, testApp, archiveJob.Id, StatusFailed)
+	if archiveJob.GetString("error") != "singlefile service returned status 502" {
+		t.Errorf("Unexpected job error: %q", archiveJob.GetString("error"))
+	}
+
+	tagsJob = waitForStatus(t, testApp, tagsJob.Id, StatusFailed)
+	if tagsJob.GetString("error") != "job panicked: boom" {
+		t.Errorf("Unexpected job error: %q", tagsJob.GetString("error"))
+	}
+}
+
+func TestQueueEnqueue(t *testing.T) {
+	testApp, link := setupTestApp(t)
+
+	queue := NewQueue(testApp, 1)
+	queue.Register(TypeSummarize, func(app core.App, linkID string) error {
+		return nil
+	})
+
+	if _, err := queue.Enqueue(link, "unknown"); err == nil {
+		t.Error("Expected an error for an unknown job type")
+	}
+
+	// The queue isn't started, so the first job stays pending and the
+	// second enqueue should return it rather than creating another.
+	first, err := queue.Enqueue(link, TypeSummarize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := queue.Enqueue(link, TypeSummarize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Id != second.Id {
+		t.Errorf("Expected duplicate pending job to be reused, got %q and %q", first.Id, second.Id)
+	}
+	if first.GetString("status") != StatusPending {
+		t.Errorf("Expected job to be pending, got %q", first.GetString("status"))
+	}
+
+	// A job backed off after failing is due again once it's re-enqueued
+	first.Set("attempts", 2)
+	first.Set("next_attempt_at", time.Now().UTC().Add(time.Hour))
+	if err := testApp.Save(first); err != nil {
+		t.Fatal(err)
+	}
+	third, err := queue.Enqueue(link, TypeSummarize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Id != first.Id {
+		t.Errorf("Expected backed off job to be reused, got %q and %q", first.Id, third.Id)
+	}
+	third, err = testApp.FindRecordById("jobs", third.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !third.GetDateTime("next_attempt_at").IsZero() || third.GetInt("attempts") != 0 {
+		t.Errorf("Expected backoff to be reset, got next_attempt_at %q and %d attempts", third.GetString("next_attempt_at"), third.GetInt("attempts"))
+	}
+}
+
+func TestQueueRunsHighlightJobs(t *testing.T) {
+	testApp, link := setupTestApp(t)
+
+	collection, err := testApp.FindCollectionByNameOrId("highlights")
+	if err != nil {
+		t.Fatal(err)
+	}
+	highlight := core.NewRecord(collection)
+	highlight.Set("user", link.GetString("user"))
+	highlight.Set("highlighted_text", "Some highlighted text")
+	highlight.Set("serialized_range", "0:0")
+	if err := testApp.Save(highlight); err != nil {
+		t.Fatal(err)
+	}
+
+	handled := make(chan string, 1)
+	queue := NewQueue(testApp, 1)
+	queue.Register(TypeEmbedHighlight, func(app core.App, highlightID string) error {
+		handled <- highlightID
+		return nil
+	})
+
+	first, err := queue.EnqueueHighlight(highlight, TypeEmbedHighlight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.GetString("highlight") != highlight.Id || first.GetString("link") != "" {
+		t.Errorf("Expected job for highlight %q only, got highlight %q and link %q", highlight.Id, first.GetString("highlight"), first.GetString("link"))
+	}
+	second, err := queue.EnqueueHighlight(highlight, TypeEmbedHighlight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Id != second.Id {
+		t.Errorf("Expected duplicate pending job to be reused, got %q and %q", first.Id, second.Id)
+	}
+
+	queue.Start()
+	defer queue.Stop()
+
+	select {
+	case highlightID := <-handled:
+		if highlightID != highlight.Id {
+			t.Errorf("Expected handler to be called with %q, got %q", highlight.Id, highlightID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Handler was not called")
+	}
+
+	waitForStatus(t, testApp, first.Id, StatusSucceeded)
+}
+
+func TestQueueResumesJobsOnStart(t *testing.T) {
+	testApp, link := setupTestApp(t)
+
+	collection, err := testApp.FindCollectionByNameOrId("jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate jobs left behind by a previous process
+	var jobIDs []string
+	for _, status := range []string{StatusPending, StatusRunning} {
+		job := core.NewRecord(collection)
+		job.Set("user", link.GetString("user"))
+		job.Set("link", link.Id)
+		job.Set("type", TypeSummarize)
+		job.Set("status", status)
+		if err := testApp.Save(job); err != nil {
+			t.Fatal(err)
+		}
+		jobIDs = append(jobIDs, job.Id)
+	}
+
+	queue := NewQueue(testApp, 2)
+	queue.Register(TypeSummarize, func(app core.App, linkID string) error {
+		return nil
+	})
+	queue.Start()
+	defer queue.Stop()
+
+	for _, jobID := range jobIDs {
+		waitForStatus(t, testApp, jobID, StatusSucceeded)
+	}
+}
+
+func TestQueueRetriesRetryableFailures(t *testing.T) {
+	testApp, link := setupTestApp(t)
+
+	calls := 0
+	queue := NewQueue(testApp, 1)
+	queue.Register(TypeSummarize, func(app core.App, linkID string) error {
+		calls++
+		return retry.Retryable(errors.New("API request failed with status 429"), 10*time.Minute)
+	})
+	queue.Start()
+	defer queue.Stop()
+
+	job, err := queue.Enqueue(link, TypeSummarize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err = testApp.FindRecordById("jobs", job.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.GetInt("attempts") == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if job.GetString("status") != StatusPending {
+		t.Fatalf("Expected job to be pending a retry, got %q", job.GetString("status"))
+	}
+	if job.GetInt("attempts") != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", job.GetInt("attempts"))
+	}
+	nextAttempt := job.GetDateTime("next_attempt_at").Time()
+	if delay := time.Until(nextAttempt); delay < 9*time.Minute || delay > 11*time.Minute {
+		t.Errorf("Expected next attempt to honour Retry-After, got %v", delay)
+	}
+
+	// The retry isn't due yet, so waking the workers shouldn't run it again
+	queue.RetryDue()
+	time.Sleep(50 * time.Millisecond)
+	if calls != 1 {
+		t.Errorf("Expected handler to be called once, got %d", calls)
+	}
+
+	// Once the job is due it runs again, and gives up after the
+	// maximum number of attempts
+	job.Set("attempts", retry.MaxAttempts-1)
+	job.Set("next_attempt_at", time.Now().UTC().Add(-time.Minute))
+	if err := testApp.Save(job); err != nil {
+		t.Fatal(err)
+	}
+	queue.RetryDue()
+
+	job = waitForStatus(t, testApp, job.Id, StatusFailed)
+	if job.GetInt("attempts") != retry.MaxAttempts {
+		t.Errorf("Expected %d attempts, got %d", retry.MaxAttempts, job.GetInt("attempts"))
+	}
+	if job.GetString("error") != "API request failed with status 429" {
+		t.Errorf("Unexpected job error: %q", job.GetString("error"))
+	}
+}
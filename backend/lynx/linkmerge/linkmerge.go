@@ -0,0 +1,156 @@
+package linkmerge
+
+// Merges duplicate copies of a link into one. Everything the user added
+// to the duplicates (tags, highlights, notes, questions, reading
+// progress, stars) is moved onto the link being kept, and the
+// duplicates are deleted.
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var (
+	ErrNoSources    = errors.New("at least one link to merge is required")
+	ErrSameLink     = errors.New("a link can't be merged into itself")
+	ErrLinkNotFound = errors.New("link not found")
+)
+
+// Collections whose records belong to a link and should follow it to
+// the merged link. Everything else, like jobs and archives, is
+// specific to the copy and is deleted with it. Not every install has
+// all of these collections (notes only exist on older ones), so
+// missing collections are skipped.
+var linkRelations = []struct {
+	collection string
+	field      string
+}{
+	{"highlights", "link"},
+	{"notes", "link"},
+	{"link_questions", "link"},
+	{"feed_items", "saved_as_link"},
+}
+
+// Merge moves everything from the source links onto the target link
+// and deletes the sources. All of the links must belong to the user.
+// Returns the updated target link.
+func Merge(app core.App, userID string, targetID string, sourceIDs []string) (*core.Record, error) {
+	if len(sourceIDs) == 0 {
+		return nil, ErrNoSources
+	}
+	if slices.Contains(sourceIDs, targetID) {
+		return nil, ErrSameLink
+	}
+
+	var target *core.Record
+	err := app.RunInTransaction(func(txApp core.App) error {
+		var err error
+		target, err = findLink(txApp, userID, targetID)
+		if err != nil {
+			return err
+		}
+
+		var sources []*core.Record
+		for _, sourceID := range sourceIDs {
+			// Each source can only be merged and deleted once
+			if slices.ContainsFunc(sources, func(source *core.Record) bool { return source.Id == sourceID }) {
+				continue
+			}
+			source, err := findLink(txApp, userID, sourceID)
+			if err != nil {
+				return err
+			}
+			mergeFields(target, source)
+			if err := moveRelated(txApp, sourceID, target.Id); err != nil {
+				return err
+			}
+			sources = append(sources, source)
+		}
+
+		if err := txApp.Save(target); err != nil {
+			return fmt.Errorf("failed to save merged link: %w", err)
+		}
+
+		for _, source := range sources {
+			if err := txApp.Delete(source); err != nil {
+				return fmt.Errorf("failed to delete link %s: %w", source.Id, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func findLink(app core.App, userID string, linkID string) (*core.Record, error) {
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil || link.GetString("user") != userID {
+		return nil, fmt.Errorf("%w: %s", ErrLinkNotFound, linkID)
+	}
+	return link, nil
+}
+
+// mergeFields combines what the user did with each copy of the link:
+// all of the tags, the furthest reading progress, the latest view, and
+// the earliest star and save.
+func mergeFields(target *core.Record, source *core.Record) {
+	tags := target.GetStringSlice("tags")
+	for _, tag := range source.GetStringSlice("tags") {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	target.Set("tags", tags)
+
+	target.Set("reading_progress", max(target.GetFloat("reading_progress"), source.GetFloat("reading_progress")))
+
+	if viewed := source.GetDateTime("last_viewed_at"); viewed.After(target.GetDateTime("last_viewed_at")) {
+		target.Set("last_viewed_at", viewed)
+	}
+
+	if starred := source.GetDateTime("starred_at"); !starred.IsZero() {
+		if current := target.GetDateTime("starred_at"); current.IsZero() || starred.Before(current) {
+			target.Set("starred_at", starred)
+		}
+	}
+
+	if added := source.GetDateTime("added_to_library"); !added.IsZero() && added.Before(target.GetDateTime("added_to_library")) {
+		target.Set("added_to_library", added)
+	}
+}
+
+func moveRelated(app core.App, sourceID string, targetID string) error {
+	for _, relation := range linkRelations {
+		if _, err := app.FindCachedCollectionByNameOrId(relation.collection); err != nil {
+			continue
+		}
+		records, err := app.FindAllRecords(relation.collection, dbx.HashExp{relation.field: sourceID})
+		if err != nil {
+			return fmt.Errorf("failed to find %s: %w", relation.collection, err)
+		}
+		for _, record := range records {
+			record.Set(relation.field, targetID)
+			if err := app.Save(record); err != nil {
+				return fmt.Errorf("failed to move %s %s: %w", relation.collection, record.Id, err)
+			}
+		}
+	}
+
+	// Highlight embeddings are stored against the link too, and would
+	// otherwise be deleted along with it
+	_, err := app.DB().Update(
+		"embeddings",
+		dbx.Params{"link": targetID},
+		dbx.And(dbx.HashExp{"link": sourceID}, dbx.Not(dbx.HashExp{"highlight": ""})),
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to move embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package linkmerge
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+func setupTestApp(t *testing.T) *tests.TestApp {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+	return testApp
+}
+
+func newRecord(t *testing.T, app core.App, collectionName string, values map[string]any) *core.Record {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	require.NoError(t, err)
+	record := core.NewRecord(collection)
+	for key, value := range values {
+		record.Set(key, value)
+	}
+	require.NoError(t, app.Save(record))
+	return record
+}
+
+func TestMerge(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	tagA := newRecord(t, testApp, "tags", map[string]any{"user": testUserID, "name": "Alpha", "slug": "alpha"})
+	tagB := newRecord(t, testApp, "tags", map[string]any{"user": testUserID, "name": "Beta", "slug": "beta"})
+
+	target, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	target.Set("tags", []string{tagA.Id})
+	target.Set("reading_progress", 0.2)
+	target.Set("added_to_library", "2025-01-02 00:00:00.000Z")
+	require.NoError(t, testApp.Save(target))
+
+	duplicate := newRecord(t, testApp, "links", map[string]any{
+		"user":             testUserID,
+		"original_url":     "https://www.example.com/?utm_source=rss",
+		"cleaned_url":      "https://www.example.com/",
+		"added_to_library": "2025-01-01 00:00:00.000Z",
+		"tags":             []string{tagA.Id, tagB.Id},
+		"reading_progress": 0.6,
+		"last_viewed_at":   "2025-02-01 00:00:00.000Z",
+		"starred_at":       "2025-02-01 00:00:00.000Z",
+	})
+	highlight := newRecord(t, testApp, "highlights", map[string]any{
+		"user":             testUserID,
+		"link":             duplicate.Id,
+		"highlighted_text": "Something worth keeping",
+		"serialized_range": "0:0",
+	})
+
+	// Repeated sources are only merged once
+	merged, err := Merge(testApp, testUserID, testLinkID, []string{duplicate.Id, duplicate.Id})
+	require.NoError(t, err)
+	assert.Equal(t, testLinkID, merged.Id)
+
+	merged, err = testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tagA.Id, tagB.Id}, merged.GetStringSlice("tags"))
+	assert.Equal(t, 0.6, merged.GetFloat("reading_progress"))
+	assert.Equal(t, "2025-02-01 00:00:00.000Z", merged.GetDateTime("last_viewed_at").String())
+	assert.Equal(t, "2025-02-01 00:00:00.000Z", merged.GetDateTime("starred_at").String())
+	assert.Equal(t, "2025-01-01 00:00:00.000Z", merged.GetDateTime("added_to_library").String())
+
+	highlight, err = testApp.FindRecordById("highlights", highlight.Id)
+	require.NoError(t, err)
+	assert.Equal(t, testLinkID, highlight.GetString("link"))
+
+	_, err = testApp.FindRecordById("links", duplicate.Id)
+	assert.Error(t, err, "Expected the duplicate to be deleted")
+}
+
+func TestMergeWithoutOptionalCollections(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	// Installs set up from the migrations alone have no notes
+	notes, err := testApp.FindCollectionByNameOrId("notes")
+	require.NoError(t, err)
+	require.NoError(t, testApp.Delete(notes))
+
+	duplicate := newRecord(t, testApp, "links", map[string]any{
+		"user":             testUserID,
+		"original_url":     "https://www.example.com/",
+		"cleaned_url":      "https://www.example.com/",
+		"added_to_library": "2025-01-01 00:00:00.000Z",
+	})
+
+	_, err = Merge(testApp, testUserID, testLinkID, []string{duplicate.Id})
+	require.NoError(t, err)
+	_, err = testApp.FindRecordById("links", duplicate.Id)
+	assert.Error(t, err, "Expected the duplicate to be deleted")
+}
+
+func TestMergeErrors(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	_, err := Merge(testApp, testUserID, testLinkID, nil)
+	assert.ErrorIs(t, err, ErrNoSources)
+
+	_, err = Merge(testApp, testUserID, testLinkID, []string{testLinkID})
+	assert.ErrorIs(t, err, ErrSameLink)
+
+	_, err = Merge(testApp, testUserID, testLinkID, []string{"doesnotexist000"})
+	assert.ErrorIs(t, err, ErrLinkNotFound)
+
+	// Links belonging to someone else can't be merged
+	other := newRecord(t, testApp, "links", map[string]any{
+		"user":             "h4oofx0tx2eupnq",
+		"original_url":     "https://example.com",
+		"cleaned_url":      "https://example.com",
+		"added_to_library": "2025-01-01 00:00:00.000Z",
+	})
+	_, err = Merge(testApp, testUserID, testLinkID, []string{other.Id})
+	assert.ErrorIs(t, err, ErrLinkNotFound)
+	_, err = testApp.FindRecordById("links", other.Id)
+	assert.NoError(t, err)
+}
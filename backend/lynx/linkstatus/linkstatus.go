@@ -0,0 +1,56 @@
+package linkstatus
+
+// Each enrichment stage (summary, archive, tag suggestions) records
+// its outcome on the link itself in a `<stage>_status` and
+// `<stage>_error` pair of fields, so clients can tell why a link
+// never got a summary or an archive.
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	StageSummary = "summary"
+	StageArchive = "archive"
+	StageTags    = "tags"
+)
+
+const (
+	Succeeded = "succeeded"
+	Skipped   = "skipped"
+	Failed    = "failed"
+)
+
+// Apply sets the status fields for a stage on the link without
+// saving it. Useful when the status should be saved together with
+// the result of the stage.
+func Apply(link *core.Record, stage string, status string, stageErr error) {
+	link.Set(stage+"_status", status)
+	if stageErr != nil {
+		link.Set(stage+"_error", stageErr.Error())
+	} else {
+		link.Set(stage+"_error", "")
+	}
+}
+
+// Get returns the status last recorded for a stage on the link, or ""
+// if the stage hasn't run.
+func Get(link *core.Record, stage string) string {
+	return link.GetString(stage + "_status")
+}
+
+// Record stores the outcome of a stage on the link. Failures to save
+// are logged but otherwise ignored, since the status is informational.
+func Record(app core.App, linkID string, stage string, status string, stageErr error) {
+	err := app.RunInTransaction(func(txApp core.App) error {
+		link, err := txApp.FindRecordById("links", linkID)
+		if err != nil {
+			return err
+		}
+		Apply(link, stage, status, stageErr)
+		return txApp.Save(link)
+	})
+	if err != nil {
+		app.Logger().Error("Failed to record link status", "linkID", linkID, "stage", stage, "error", err)
+	}
+}
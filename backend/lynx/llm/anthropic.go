@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"main/lynx/retry"
+)
+
+const (
+	DefaultAnthropicAPIURL    = "https://api.anthropic.com/v1/messages"
+	anthropicVersion          = "2023-06-01"
+	defaultAnthropicMaxTokens = 1024
+)
+
+// Anthropic talks to Anthropic's Messages API.
+type Anthropic struct {
+	APIURL string
+	APIKey string
+}
+
+func NewAnthropic(apiKey string) *Anthropic {
+	return &Anthropic{
+		APIURL: DefaultAnthropicAPIURL,
+		APIKey: apiKey,
+	}
+}
+
+// Schemas are implemented with forced tool use, which every current
+// model supports.
+func (p *Anthropic) SupportsSchema(model string) (bool, error) {
+	return true, nil
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (p *Anthropic) Complete(req Request) (*Response, error) {
+	// max_tokens is required by the Messages API
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+
+	payload := map[string]interface{}{
+		"model":      req.Model,
+		"max_tokens": maxTokens,
+		"messages":   req.Messages,
+	}
+	if req.System != "" {
+		payload["system"] = req.System
+	}
+	if req.Schema != nil {
+		// There's no JSON response mode, so force the model to call a
+		// tool whose input is the requested schema instead.
+		payload["tools"] = []map[string]interface{}{
+			{
+				"name":         req.Schema.Name,
+				"description":  "Respond with the requested structured data",
+				"input_schema": req.Schema.Schema,
+			},
+		}
+		payload["tool_choice"] = map[string]string{
+			"type": "tool",
+			"name": req.Schema.Name,
+		}
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request payload: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", p.APIURL, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.APIKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, retry.Retryable(fmt.Errorf("failed to send request: %w", err), 0)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, retry.FromResponse(resp, body)
+	}
+
+	var result anthropicResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	response := &Response{
+		Usage: Usage{
+			PromptTokens:     result.Usage.InputTokens,
+			CompletionTokens: result.Usage.OutputTokens,
+		},
+	}
+	for _, block := range result.Content {
+		switch {
+		case req.Schema != nil && block.Type == "tool_use":
+			response.Content = string(block.Input)
+			return response, nil
+		case req.Schema == nil && block.Type == "text":
+			response.Content += block.Text
+		}
+	}
+
+	if response.Content == "" {
+		return nil, fmt.Errorf("no content in response")
+	}
+	return response, nil
+}
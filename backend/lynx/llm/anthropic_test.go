@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "test_api_key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+
+		var requestBody map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+
+		assert.Equal(t, "claude-3-5-haiku-latest", requestBody["model"])
+		assert.Equal(t, "Be helpful.", requestBody["system"])
+		assert.Equal(t, float64(defaultAnthropicMaxTokens), requestBody["max_tokens"])
+		messages := requestBody["messages"].([]interface{})
+		require.Len(t, messages, 1)
+		assert.Equal(t, "user", messages[0].(map[string]interface{})["role"])
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "text", "text": "Part one. "},
+				{"type": "text", "text": "Part two."},
+			},
+			"usage": map[string]int{"input_tokens": 42, "output_tokens": 7},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewAnthropic("test_api_key")
+	provider.APIURL = server.URL
+
+	resp, err := provider.Complete(Request{
+		Model:    "claude-3-5-haiku-latest",
+		System:   "Be helpful.",
+		Messages: []Message{{Role: "user", Content: "Some text"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Part one. Part two.", resp.Content)
+	assert.Equal(t, Usage{PromptTokens: 42, CompletionTokens: 7}, resp.Usage)
+}
+
+func TestAnthropicCompleteWithSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestBody map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+
+		tools := requestBody["tools"].([]interface{})
+		require.Len(t, tools, 1)
+		tool := tools[0].(map[string]interface{})
+		assert.Equal(t, "tag_suggestions", tool["name"])
+		assert.Equal(t, map[string]interface{}{"type": "object"}, tool["input_schema"])
+		assert.Equal(t, map[string]interface{}{"type": "tool", "name": "tag_suggestions"}, requestBody["tool_choice"])
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "tool_use", "name": "tag_suggestions", "input": map[string]interface{}{"suggested_tags": []string{"go"}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewAnthropic("test_api_key")
+	provider.APIURL = server.URL
+
+	resp, err := provider.Complete(Request{
+		Model:    "claude-3-5-haiku-latest",
+		Messages: []Message{{Role: "user", Content: "Some text"}},
+		Schema: &Schema{
+			Name:   "tag_suggestions",
+			Schema: map[string]interface{}{"type": "object"},
+		},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"suggested_tags":["go"]}`, resp.Content)
+}
+
+func TestAnthropicCompleteErrors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		statusCode     int
+		serverResponse interface{}
+		expectedError  string
+	}{
+		{
+			name:           "API error",
+			statusCode:     http.StatusUnauthorized,
+			serverResponse: map[string]string{"error": "invalid x-api-key"},
+			expectedError:  "API request failed with status 401",
+		},
+		{
+			name:           "No content",
+			statusCode:     http.StatusOK,
+			serverResponse: map[string]interface{}{"content": []interface{}{}},
+			expectedError:  "no content in response",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				json.NewEncoder(w).Encode(tc.serverResponse)
+			}))
+			defer server.Close()
+
+			provider := NewAnthropic("test_api_key")
+			provider.APIURL = server.URL
+
+			_, err := provider.Complete(Request{
+				Model:    "claude-3-5-haiku-latest",
+				Messages: []Message{{Role: "user", Content: "Some text"}},
+			})
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package llm
+
+// Lynx talks to language models through a small Provider interface
+// so that each user can choose between OpenRouter, any
+// OpenAI-compatible endpoint (including self-hosted Ollama or
+// llama.cpp servers) and Anthropic's Messages API.
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderOpenAI     = "openai"
+	ProviderAnthropic  = "anthropic"
+)
+
+var (
+	ErrMissingAPIKey   = errors.New("API key not set for user")
+	ErrUnknownProvider = errors.New("unknown LLM provider")
+	// Anthropic doesn't offer an embeddings API
+	ErrEmbeddingsNotSupported = errors.New("embeddings are not supported by this provider")
+)
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type Request struct {
+	Model    string
+	System   string
+	Messages []Message
+	// Zero means the provider's default
+	MaxTokens int
+	// If set, the model is asked to respond with JSON matching the schema
+	Schema *Schema
+}
+
+type Schema struct {
+	Name   string
+	Schema map[string]interface{}
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type Response struct {
+	Content string
+	Usage   Usage
+}
+
+type Provider interface {
+	Complete(req Request) (*Response, error)
+	// SupportsSchema reports whether the model can be asked to
+	// respond with JSON matching a schema (see Request.Schema).
+	SupportsSchema(model string) (bool, error)
+}
+
+type EmbeddingRequest struct {
+	Model string
+	Input []string
+}
+
+type EmbeddingResponse struct {
+	// One vector per input, in the same order
+	Vectors [][]float32
+	Usage   Usage
+}
+
+// Embedder is implemented by providers that can turn text into
+// embedding vectors.
+type Embedder interface {
+	Embed(req EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// ProviderName returns the provider selected in the user's settings,
+// defaulting to OpenRouter for users who haven't chosen one.
+func ProviderName(settings *core.Record) string {
+	if name := settings.GetString("llm_provider"); name != "" {
+		return name
+	}
+	return ProviderOpenRouter
+}
+
+// FromSettings builds the provider selected in a user_settings record.
+func FromSettings(settings *core.Record) (Provider, error) {
+	switch name := ProviderName(settings); name {
+	case ProviderOpenRouter:
+		apiKey := settings.GetString("openrouter_api_key")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OpenRouter %w", ErrMissingAPIKey)
+		}
+		return NewOpenRouter(apiKey), nil
+	case ProviderOpenAI:
+		// Self-hosted servers usually don't need a key, so only
+		// require one when talking to OpenAI itself.
+		apiKey := settings.GetString("openai_api_key")
+		baseURL := settings.GetString("openai_base_url")
+		if apiKey == "" && baseURL == "" {
+			return nil, fmt.Errorf("OpenAI %w", ErrMissingAPIKey)
+		}
+		return NewOpenAI(baseURL, apiKey), nil
+	case ProviderAnthropic:
+		apiKey := settings.GetString("anthropic_api_key")
+		if apiKey == "" {
+			return nil, fmt.Errorf("Anthropic %w", ErrMissingAPIKey)
+		}
+		return NewAnthropic(apiKey), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+}
+
+// EmbedderFromSettings builds the provider selected in a user_settings
+// record, returning ErrEmbeddingsNotSupported if it can't compute
+// embeddings.
+func EmbedderFromSettings(settings *core.Record) (Embedder, error) {
+	provider, err := FromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := provider.(Embedder)
+	if !ok {
+		return nil, ErrEmbeddingsNotSupported
+	}
+	return embedder, nil
+}
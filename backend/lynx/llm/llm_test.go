@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSettings(values map[string]any) *core.Record {
+	collection := core.NewBaseCollection("user_settings")
+	collection.Fields.Add(
+		&core.TextField{Name: "llm_provider"},
+		&core.TextField{Name: "openrouter_api_key"},
+		&core.TextField{Name: "openai_api_key"},
+		&core.TextField{Name: "openai_base_url"},
+		&core.TextField{Name: "anthropic_api_key"},
+	)
+	record := core.NewRecord(collection)
+	for key, value := range values {
+		record.Set(key, value)
+	}
+	return record
+}
+
+func TestFromSettings(t *testing.T) {
+	testCases := []struct {
+		name          string
+		values        map[string]any
+		expectedURL   string
+		expectedError error
+	}{
+		{
+			name:        "Defaults to OpenRouter",
+			values:      map[string]any{"openrouter_api_key": "key"},
+			expectedURL: DefaultOpenRouterAPIURL,
+		},
+		{
+			name:          "OpenRouter without a key",
+			values:        map[string]any{"llm_provider": ProviderOpenRouter},
+			expectedError: ErrMissingAPIKey,
+		},
+		{
+			name:        "OpenAI",
+			values:      map[string]any{"llm_provider": ProviderOpenAI, "openai_api_key": "key"},
+			expectedURL: "https://api.openai.com/v1/chat/completions",
+		},
+		{
+			name:        "Self-hosted without a key",
+			values:      map[string]any{"llm_provider": ProviderOpenAI, "openai_base_url": "http://ollama:11434/v1"},
+			expectedURL: "http://ollama:11434/v1/chat/completions",
+		},
+		{
+			name:          "OpenAI without a key or base URL",
+			values:        map[string]any{"llm_provider": ProviderOpenAI},
+			expectedError: ErrMissingAPIKey,
+		},
+		{
+			name:        "Anthropic",
+			values:      map[string]any{"llm_provider": ProviderAnthropic, "anthropic_api_key": "key"},
+			expectedURL: DefaultAnthropicAPIURL,
+		},
+		{
+			name:          "Anthropic without a key",
+			values:        map[string]any{"llm_provider": ProviderAnthropic, "openrouter_api_key": "key"},
+			expectedError: ErrMissingAPIKey,
+		},
+		{
+			name:          "Unknown provider",
+			values:        map[string]any{"llm_provider": "carrier-pigeon"},
+			expectedError: ErrUnknownProvider,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := FromSettings(newSettings(tc.values))
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+
+			switch p := provider.(type) {
+			case *OpenAICompatible:
+				assert.Equal(t, tc.expectedURL, p.APIURL)
+			case *Anthropic:
+				assert.Equal(t, tc.expectedURL, p.APIURL)
+			default:
+				t.Fatalf("unexpected provider type %T", provider)
+			}
+		})
+	}
+}
+
+func TestMissingAPIKeyMessage(t *testing.T) {
+	_, err := FromSettings(newSettings(nil))
+	assert.EqualError(t, err, "OpenRouter API key not set for user")
+}
+
+func TestEmbedderFromSettings(t *testing.T) {
+	embedder, err := EmbedderFromSettings(newSettings(map[string]any{"openrouter_api_key": "key"}))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOpenRouterEmbeddingsURL, embedder.(*OpenAICompatible).EmbeddingsURL)
+
+	_, err = EmbedderFromSettings(newSettings(map[string]any{"llm_provider": ProviderAnthropic, "anthropic_api_key": "key"}))
+	assert.ErrorIs(t, err, ErrEmbeddingsNotSupported)
+
+	_, err = EmbedderFromSettings(newSettings(map[string]any{}))
+	assert.ErrorIs(t, err, ErrMissingAPIKey)
+}
@@ -0,0 +1,302 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"main/lynx/retry"
+)
+
+const (
+	DefaultOpenRouterAPIURL        = "https://openrouter.ai/api/v1/chat/completions"
+	DefaultOpenRouterModelsAPIURL  = "https://openrouter.ai/api/v1/models"
+	DefaultOpenRouterEmbeddingsURL = "https://openrouter.ai/api/v1/embeddings"
+	DefaultOpenAIBaseURL           = "https://api.openai.com/v1"
+)
+
+// How long to remember which models support structured outputs
+const modelsCacheTTL = 12 * time.Hour
+
+// OpenAICompatible talks to any server implementing the OpenAI chat
+// completions API. OpenRouter, OpenAI, Ollama and llama.cpp all do.
+type OpenAICompatible struct {
+	APIURL string
+	// Lists models along with their supported parameters. Only
+	// OpenRouter provides this; when empty no model is assumed to
+	// support JSON schema responses, and plain JSON is asked for
+	// instead.
+	ModelsURL     string
+	EmbeddingsURL string
+	APIKey        string
+	Headers       map[string]string
+}
+
+func NewOpenRouter(apiKey string) *OpenAICompatible {
+	return &OpenAICompatible{
+		APIURL:        DefaultOpenRouterAPIURL,
+		ModelsURL:     DefaultOpenRouterModelsAPIURL,
+		EmbeddingsURL: DefaultOpenRouterEmbeddingsURL,
+		APIKey:        apiKey,
+		Headers: map[string]string{
+			"X-Title":      "Lynx",
+			"HTTP-Referer": "https://github.com/brendanv/lynx",
+		},
+	}
+}
+
+// NewOpenAI returns a provider for the OpenAI API, or for a
+// self-hosted server if baseURL is set (e.g. http://localhost:11434/v1
+// for Ollama).
+func NewOpenAI(baseURL string, apiKey string) *OpenAICompatible {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &OpenAICompatible{
+		APIURL:        baseURL + "/chat/completions",
+		EmbeddingsURL: baseURL + "/embeddings",
+		APIKey:        apiKey,
+	}
+}
+
+func (p *OpenAICompatible) Complete(req Request) (*Response, error) {
+	var messages []Message
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	payload := map[string]interface{}{
+		"model":    req.Model,
+		"messages": messages,
+	}
+	if req.MaxTokens > 0 {
+		payload["max_tokens"] = req.MaxTokens
+	}
+	if req.Schema != nil {
+		payload["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   req.Schema.Name,
+				"strict": true,
+				"schema": req.Schema.Schema,
+			},
+		}
+	}
+
+	body, err := p.post(p.APIURL, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	choices, ok := result["choices"].([]interface{})
+	if !ok || len(choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	choice, ok := choices[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid choice format")
+	}
+
+	message, ok := choice["message"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid message format")
+	}
+
+	content, ok := message["content"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid content format")
+	}
+
+	response := &Response{Content: content}
+	if usage, ok := result["usage"].(map[string]interface{}); ok {
+		promptTokens, _ := usage["prompt_tokens"].(float64)
+		completionTokens, _ := usage["completion_tokens"].(float64)
+		response.Usage = Usage{
+			PromptTokens:     int(promptTokens),
+			CompletionTokens: int(completionTokens),
+		}
+	}
+	return response, nil
+}
+
+// Embed returns one vector per input, in the same order.
+func (p *OpenAICompatible) Embed(req EmbeddingRequest) (*EmbeddingResponse, error) {
+	if p.EmbeddingsURL == "" {
+		return nil, ErrEmbeddingsNotSupported
+	}
+
+	body, err := p.post(p.EmbeddingsURL, map[string]interface{}{
+		"model": req.Model,
+		"input": req.Input,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(result.Data) != len(req.Input) {
+		return nil, fmt.Errorf("expected %d embeddings in response, got %d", len(req.Input), len(result.Data))
+	}
+
+	vectors := make([][]float32, len(req.Input))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("invalid embedding index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+
+	return &EmbeddingResponse{
+		Vectors: vectors,
+		Usage:   Usage{PromptTokens: result.Usage.PromptTokens},
+	}, nil
+}
+
+// post sends a JSON payload to the API and returns the body of a
+// successful response.
+func (p *OpenAICompatible) post(url string, payload interface{}) ([]byte, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request payload: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	for key, value := range p.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, retry.Retryable(fmt.Errorf("failed to send request: %w", err), 0)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, retry.FromResponse(resp, body)
+	}
+	return body, nil
+}
+
+type modelsCacheEntry struct {
+	fetchedAt time.Time
+	// Model ID -> whether it supports structured outputs
+	structured map[string]bool
+}
+
+var (
+	modelsCacheMu sync.Mutex
+	modelsCache   = map[string]modelsCacheEntry{}
+)
+
+func (p *OpenAICompatible) SupportsSchema(model string) (bool, error) {
+	// Other OpenAI compatible servers, like Ollama, can't say which of
+	// their models support it
+	if p.ModelsURL == "" {
+		return false, nil
+	}
+
+	modelsCacheMu.Lock()
+	entry, ok := modelsCache[p.ModelsURL]
+	modelsCacheMu.Unlock()
+
+	if !ok || time.Since(entry.fetchedAt) > modelsCacheTTL {
+		structured, err := p.fetchStructuredModels()
+		if err != nil {
+			return false, err
+		}
+		entry = modelsCacheEntry{fetchedAt: time.Now(), structured: structured}
+
+		modelsCacheMu.Lock()
+		modelsCache[p.ModelsURL] = entry
+		modelsCacheMu.Unlock()
+	}
+
+	// Models the provider doesn't list are assumed not to support it
+	return entry.structured[model], nil
+}
+
+func (p *OpenAICompatible) fetchStructuredModels() (map[string]bool, error) {
+	req, err := http.NewRequest("GET", p.ModelsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range p.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, retry.Retryable(fmt.Errorf("failed to send request: %w", err), 0)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, retry.FromResponse(resp, body)
+	}
+
+	var result struct {
+		Data []struct {
+			ID                  string   `json:"id"`
+			SupportedParameters []string `json:"supported_parameters"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal models response: %w", err)
+	}
+
+	structured := make(map[string]bool, len(result.Data))
+	for _, model := range result.Data {
+		structured[model.ID] = false
+		for _, param := range model.SupportedParameters {
+			if param == "structured_outputs" {
+				structured[model.ID] = true
+				break
+			}
+		}
+	}
+	return structured, nil
+}
@@ -0,0 +1,308 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAICompatibleComplete(t *testing.T) {
+	testCases := []struct {
+		name            string
+		apiKey          string
+		serverResponse  interface{}
+		statusCode      int
+		expectedError   string
+		expectedContent string
+		expectedUsage   Usage
+	}{
+		{
+			name:   "Successful completion",
+			apiKey: "test_api_key",
+			serverResponse: map[string]interface{}{
+				"choices": []map[string]interface{}{
+					{
+						"message": map[string]string{
+							"content": "This is a summary of the text.",
+						},
+					},
+				},
+				"usage": map[string]int{
+					"prompt_tokens":     120,
+					"completion_tokens": 30,
+				},
+			},
+			statusCode:      http.StatusOK,
+			expectedContent: "This is a summary of the text.",
+			expectedUsage:   Usage{PromptTokens: 120, CompletionTokens: 30},
+		},
+		{
+			name: "No API key for a self-hosted server",
+			serverResponse: map[string]interface{}{
+				"choices": []map[string]interface{}{
+					{
+						"message": map[string]string{
+							"content": "Hello from Ollama.",
+						},
+					},
+				},
+			},
+			statusCode:      http.StatusOK,
+			expectedContent: "Hello from Ollama.",
+		},
+		{
+			name:           "API error",
+			apiKey:         "invalid_api_key",
+			serverResponse: map[string]string{"error": "Invalid API key"},
+			statusCode:     http.StatusUnauthorized,
+			expectedError:  "API request failed with status 401",
+		},
+		{
+			name:           "No choices in response",
+			apiKey:         "test_api_key",
+			serverResponse: map[string]interface{}{"choices": []interface{}{}},
+			statusCode:     http.StatusOK,
+			expectedError:  "no choices in response",
+		},
+		{
+			name:   "Invalid choice format",
+			apiKey: "test_api_key",
+			serverResponse: map[string]interface{}{
+				"choices": []interface{}{"invalid"},
+			},
+			statusCode:    http.StatusOK,
+			expectedError: "invalid choice format",
+		},
+		{
+			name:   "Invalid message format",
+			apiKey: "test_api_key",
+			serverResponse: map[string]interface{}{
+				"choices": []map[string]interface{}{
+					{
+						"message": "invalid",
+					},
+				},
+			},
+			statusCode:    http.StatusOK,
+			expectedError: "invalid message format",
+		},
+		{
+			name:   "Invalid content format",
+			apiKey: "test_api_key",
+			serverResponse: map[string]interface{}{
+				"choices": []map[string]interface{}{
+					{
+						"message": map[string]interface{}{
+							"content": 123,
+						},
+					},
+				},
+			},
+			statusCode:    http.StatusOK,
+			expectedError: "invalid content format",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				if tc.apiKey != "" {
+					assert.Equal(t, "Bearer "+tc.apiKey, r.Header.Get("Authorization"))
+				} else {
+					assert.Empty(t, r.Header.Get("Authorization"))
+				}
+
+				var requestBody map[string]interface{}
+				err := json.NewDecoder(r.Body).Decode(&requestBody)
+				require.NoError(t, err)
+
+				assert.Equal(t, "llama3.2", requestBody["model"])
+				assert.Equal(t, float64(500), requestBody["max_tokens"])
+				assert.NotContains(t, requestBody, "response_format")
+
+				messages, ok := requestBody["messages"].([]interface{})
+				require.True(t, ok)
+				require.Len(t, messages, 2)
+
+				systemMessage, ok := messages[0].(map[string]interface{})
+				require.True(t, ok)
+				assert.Equal(t, "system", systemMessage["role"])
+				assert.Equal(t, "Be helpful.", systemMessage["content"])
+
+				userMessage, ok := messages[1].(map[string]interface{})
+				require.True(t, ok)
+				assert.Equal(t, "user", userMessage["role"])
+				assert.Equal(t, "Some text", userMessage["content"])
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.statusCode)
+				json.NewEncoder(w).Encode(tc.serverResponse)
+			}))
+			defer server.Close()
+
+			provider := NewOpenAI(server.URL+"/v1/", tc.apiKey)
+			resp, err := provider.Complete(Request{
+				Model:     "llama3.2",
+				System:    "Be helpful.",
+				Messages:  []Message{{Role: "user", Content: "Some text"}},
+				MaxTokens: 500,
+			})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedContent, resp.Content)
+				assert.Equal(t, tc.expectedUsage, resp.Usage)
+			}
+		})
+	}
+}
+
+func TestOpenRouterRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Lynx", r.Header.Get("X-Title"))
+		assert.Equal(t, "https://github.com/brendanv/lynx", r.Header.Get("HTTP-Referer"))
+		assert.Equal(t, "Bearer test_api_key", r.Header.Get("Authorization"))
+
+		var requestBody map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+
+		// No system prompt or token limit were requested
+		messages := requestBody["messages"].([]interface{})
+		assert.Len(t, messages, 1)
+		assert.NotContains(t, requestBody, "max_tokens")
+
+		responseFormat := requestBody["response_format"].(map[string]interface{})
+		assert.Equal(t, "json_schema", responseFormat["type"])
+		jsonSchema := responseFormat["json_schema"].(map[string]interface{})
+		assert.Equal(t, "tag_suggestions", jsonSchema["name"])
+		assert.Equal(t, true, jsonSchema["strict"])
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": `{"suggested_tags":[]}`}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenRouter("test_api_key")
+	assert.Equal(t, DefaultOpenRouterAPIURL, provider.APIURL)
+	provider.APIURL = server.URL
+
+	resp, err := provider.Complete(Request{
+		Model:    "openai/gpt-4o-mini",
+		Messages: []Message{{Role: "user", Content: "Some text"}},
+		Schema: &Schema{
+			Name:   "tag_suggestions",
+			Schema: map[string]interface{}{"type": "object"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"suggested_tags":[]}`, resp.Content)
+}
+
+func TestNewOpenAIDefaultURL(t *testing.T) {
+	assert.Equal(t, "https://api.openai.com/v1/chat/completions", NewOpenAI("", "key").APIURL)
+	assert.Equal(t, "http://localhost:11434/v1/chat/completions", NewOpenAI("http://localhost:11434/v1", "").APIURL)
+	assert.Equal(t, "http://localhost:11434/v1/embeddings", NewOpenAI("http://localhost:11434/v1/", "").EmbeddingsURL)
+}
+
+func TestOpenAICompatibleSupportsSchema(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, http.MethodGet, r.Method)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"id": "openai/gpt-4o-mini", "supported_parameters": []string{"max_tokens", "response_format", "structured_outputs"}},
+				{"id": "meta-llama/llama-2-13b-chat", "supported_parameters": []string{"max_tokens"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenRouter("test_api_key")
+	provider.ModelsURL = server.URL
+
+	supported, err := provider.SupportsSchema("openai/gpt-4o-mini")
+	require.NoError(t, err)
+	assert.True(t, supported)
+
+	supported, err = provider.SupportsSchema("meta-llama/llama-2-13b-chat")
+	require.NoError(t, err)
+	assert.False(t, supported)
+
+	supported, err = provider.SupportsSchema("not/a-model")
+	require.NoError(t, err)
+	assert.False(t, supported)
+
+	// The model list is cached between calls
+	assert.Equal(t, 1, requests)
+
+	// Without a models endpoint no model is assumed to support it
+	supported, err = NewOpenAI("http://localhost:11434/v1", "").SupportsSchema("llama3.2")
+	require.NoError(t, err)
+	assert.False(t, supported)
+}
+
+func TestOpenAICompatibleEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test_api_key", r.Header.Get("Authorization"))
+
+		var requestBody map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+		assert.Equal(t, "openai/text-embedding-3-small", requestBody["model"])
+		assert.Equal(t, []interface{}{"first", "second"}, requestBody["input"])
+
+		// Results aren't guaranteed to be in input order
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"index": 1, "embedding": []float32{0, 1}},
+				{"index": 0, "embedding": []float32{1, 0}},
+			},
+			"usage": map[string]int{"prompt_tokens": 4, "total_tokens": 4},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenRouter("test_api_key")
+	assert.Equal(t, DefaultOpenRouterEmbeddingsURL, provider.EmbeddingsURL)
+	provider.EmbeddingsURL = server.URL
+
+	resp, err := provider.Embed(EmbeddingRequest{
+		Model: "openai/text-embedding-3-small",
+		Input: []string{"first", "second"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, resp.Vectors)
+	assert.Equal(t, Usage{PromptTokens: 4}, resp.Usage)
+}
+
+func TestOpenAICompatibleEmbedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"index": 0, "embedding": []float32{1, 0}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := &OpenAICompatible{EmbeddingsURL: server.URL}
+	_, err := provider.Embed(EmbeddingRequest{Model: "m", Input: []string{"a", "b"}})
+	assert.EqualError(t, err, "expected 2 embeddings in response, got 1")
+
+	provider.EmbeddingsURL = ""
+	_, err = provider.Embed(EmbeddingRequest{Model: "m", Input: []string{"a"}})
+	assert.ErrorIs(t, err, ErrEmbeddingsNotSupported)
+}
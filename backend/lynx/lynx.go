@@ -1,17 +1,35 @@
 package lynx
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/routine"
 	"github.com/pocketbase/pocketbase/tools/security"
 
+	"main/lynx/email"
+	"main/lynx/embeddings"
 	"main/lynx/feeds"
+	"main/lynx/fulltext"
+	"main/lynx/jobs"
+	"main/lynx/linkmerge"
+	"main/lynx/linkstatus"
+	"main/lynx/llm"
+	"main/lynx/questions"
+	"main/lynx/readingqueue"
+	"main/lynx/related"
+	"main/lynx/savedsearches"
+	"main/lynx/searchquery"
 	"main/lynx/singlefile"
 	"main/lynx/summarizer"
 	"main/lynx/tagger"
@@ -19,53 +37,137 @@ import (
 )
 
 var parseUrlHandlerFunc = url_parser.HandleParseURLRequest
+var parseUploadHandlerFunc = url_parser.HandleParseUploadRequest
 var parseFeedHandlerFunc = feeds.SaveNewFeed
 var convertFeedItemToLinkFunc = feeds.MaybeConvertFeedItemToLink
 
+// Largest file that can be uploaded to /lynx/parse_html, the same as
+// the largest document a link can have
+const maxUploadSize = 52428800
+
+// Number of background workers processing link enrichment jobs
+const jobWorkers = 2
+
 // Interfaces for dependency injection for summarization tests
 type Summarizer interface {
-	MaybeSummarizeLink(app core.App, linkID string)
+	MaybeSummarizeLink(app core.App, linkID string) error
+	SummarizeLink(app core.App, linkID string, model string) (string, error)
 }
 
 var CurrentSummarizer Summarizer = &DefaultSummarizer{}
 
 type DefaultSummarizer struct{}
 
-func (s *DefaultSummarizer) MaybeSummarizeLink(app core.App, linkID string) {
-	summarizer.MaybeSummarizeLink(app, linkID)
+func (s *DefaultSummarizer) MaybeSummarizeLink(app core.App, linkID string) error {
+	return summarizer.MaybeSummarizeLink(app, linkID)
+}
+
+func (s *DefaultSummarizer) SummarizeLink(app core.App, linkID string, model string) (string, error) {
+	return summarizer.SummarizeLink(app, linkID, model)
 }
 
 func InitializePocketbase(app core.App) {
 
 	apiKeyAuth := ApiKeyAuthMiddleware(app)
 
+	jobQueue := jobs.NewQueue(app, jobWorkers)
+	jobQueue.Register(jobs.TypeSummarize, func(app core.App, linkID string) error {
+		return CurrentSummarizer.MaybeSummarizeLink(app, linkID)
+	})
+	jobQueue.Register(jobs.TypeArchive, singlefile.MaybeArchiveLink)
+	jobQueue.Register(jobs.TypeSuggestTags, tagger.MaybeSuggestTagsForLink)
+	jobQueue.Register(jobs.TypeEmbed, embeddings.MaybeEmbedLink)
+	jobQueue.Register(jobs.TypeEmbedHighlight, embeddings.MaybeEmbedHighlight)
+
+	emailListener := email.NewListener(app)
+
 	app.Cron().MustAdd("FetchFeeds", "0 */6 * * *", func() {
 		feeds.FetchAllFeeds((app))
 	})
 
+	app.Cron().MustAdd("RetryJobs", "*/5 * * * *", func() {
+		jobQueue.RetryDue()
+	})
+
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		// Resume any jobs left over from a previous run
+		jobQueue.Start()
+
+		if err := emailListener.Start(); err != nil {
+			app.Logger().Error("Failed to start email listener", "error", err)
+		}
+
 		se.Router.POST("/lynx/parse_link", func(e *core.RequestEvent) error {
 			record, err := parseUrlHandlerFunc(app, e)
-			if err != nil {
-				return err
-			}
-			return e.JSON(http.StatusOK, map[string]interface{}{
-				"id": record.Id,
-			})
+			return savedLinkResponse(e, record, err)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.POST("/lynx/parse_html", func(e *core.RequestEvent) error {
+			record, err := parseUploadHandlerFunc(app, e)
+			return savedLinkResponse(e, record, err)
+		}).Bind(apiKeyAuth, apis.RequireAuth(), apis.BodyLimit(maxUploadSize))
+
+		se.Router.POST("/lynx/note", func(e *core.RequestEvent) error {
+			record, err := url_parser.HandleCreateNoteRequest(app, e)
+			return savedLinkResponse(e, record, err)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.POST("/lynx/links/merge", func(e *core.RequestEvent) error {
+			return handleMergeLinks(app, e)
 		}).Bind(apiKeyAuth, apis.RequireAuth())
 
 		se.Router.POST("/lynx/generate_api_key", func(e *core.RequestEvent) error {
 			return handleGenerateAPIKey(app, e)
 		}).Bind(apis.RequireAuth())
 
+		se.Router.POST("/lynx/generate_email_address", func(e *core.RequestEvent) error {
+			return handleGenerateEmailAddress(app, e)
+		}).Bind(apis.RequireAuth())
+
 		se.Router.POST("/lynx/parse_feed", func(e *core.RequestEvent) error {
 			return parseFeedHandlerFunc(app, e)
 		}).Bind(apiKeyAuth, apis.RequireAuth())
 
 		se.Router.POST("/lynx/link/{id}/create_archive", func(e *core.RequestEvent) error {
-			return handleArchiveLink(app, e)
+			return handleArchiveLink(app, e, jobQueue)
 		}).Bind(apis.RequireAuth())
 
+		se.Router.POST("/lynx/link/{id}/summarize", func(e *core.RequestEvent) error {
+			return handleSummarizeLink(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.GET("/lynx/link/{id}/status", func(e *core.RequestEvent) error {
+			return handleLinkStatus(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.POST("/lynx/link/{id}/accept_new_tag", func(e *core.RequestEvent) error {
+			return handleAcceptNewTag(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.POST("/lynx/link/{id}/ask", func(e *core.RequestEvent) error {
+			return handleAskQuestion(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.GET("/lynx/link/{id}/related", func(e *core.RequestEvent) error {
+			return handleRelatedLinks(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.GET("/lynx/search", func(e *core.RequestEvent) error {
+			return handleSearch(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.GET("/lynx/search/semantic", func(e *core.RequestEvent) error {
+			return handleSemanticSearch(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.GET("/lynx/saved_search/{id}/links", func(e *core.RequestEvent) error {
+			return handleSavedSearchLinks(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
+		se.Router.GET("/lynx/queue", func(e *core.RequestEvent) error {
+			return handleReadingQueue(app, e)
+		}).Bind(apiKeyAuth, apis.RequireAuth())
+
 		se.Router.GET(
 			"/{path...}",
 			apis.Static(os.DirFS("./pb_public"), true),
@@ -74,6 +176,20 @@ func InitializePocketbase(app core.App) {
 		return se.Next()
 	})
 
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		jobQueue.Stop()
+		emailListener.Stop()
+		return e.Next()
+	})
+
+	// Addresses depend on the configured domain, so they're worked out
+	// as records are returned rather than stored
+	app.OnRecordEnrich("email_addresses").BindFunc(func(e *core.RecordEnrichEvent) error {
+		e.Record.WithCustomData(true)
+		e.Record.Set("address", email.Address(e.Record))
+		return e.Next()
+	})
+
 	// Automatically update last_viewed_at when links are loaded
 	// individually. However, let the client control this behavior
 	// with a header.
@@ -94,16 +210,112 @@ func InitializePocketbase(app core.App) {
 		return e.Next()
 	})
 
+	// Keep the key used to find duplicate links up to date, however the
+	// link was saved
+	app.OnRecordCreate("links").BindFunc(func(e *core.RecordEvent) error {
+		url_parser.SetNormalizedURL(e.Record)
+		return e.Next()
+	})
+
+	app.OnRecordUpdate("links").BindFunc(func(e *core.RecordEvent) error {
+		url_parser.SetNormalizedURL(e.Record)
+		return e.Next()
+	})
+
 	app.OnRecordAfterCreateSuccess("links").BindFunc(func(e *core.RecordEvent) error {
-		routine.FireAndForget(func() {
-			CurrentSummarizer.MaybeSummarizeLink(app, e.Record.Id)
-		})
-		routine.FireAndForget(func() {
-			singlefile.MaybeArchiveLink(app, e.Record.Id)
-		})
-		routine.FireAndForget(func() {
-			tagger.MaybeSuggestTagsForLink(app, e.Record.Id)
-		})
+		for _, jobType := range []string{jobs.TypeSummarize, jobs.TypeArchive, jobs.TypeSuggestTags, jobs.TypeEmbed} {
+			if _, err := jobQueue.Enqueue(e.Record, jobType); err != nil {
+				app.Logger().Error("Failed to enqueue job", "type", jobType, "linkID", e.Record.Id, "error", err)
+			}
+		}
+		return e.Next()
+	})
+
+	// Keep embeddings in sync when the text they were computed from
+	// changes. Embedding goes through the job queue so that failures
+	// are retried and recorded.
+	app.OnRecordAfterUpdateSuccess("links").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		if original.GetString("raw_text_content") != e.Record.GetString("raw_text_content") ||
+			original.GetString("title") != e.Record.GetString("title") {
+			if _, err := jobQueue.Enqueue(e.Record, jobs.TypeEmbed); err != nil {
+				app.Logger().Error("Failed to enqueue job", "type", jobs.TypeEmbed, "linkID", e.Record.Id, "error", err)
+			}
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAfterCreateSuccess("highlights").BindFunc(func(e *core.RecordEvent) error {
+		if _, err := jobQueue.EnqueueHighlight(e.Record, jobs.TypeEmbedHighlight); err != nil {
+			app.Logger().Error("Failed to enqueue job", "type", jobs.TypeEmbedHighlight, "highlightID", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAfterUpdateSuccess("highlights").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.Original().GetString("highlighted_text") != e.Record.GetString("highlighted_text") {
+			if _, err := jobQueue.EnqueueHighlight(e.Record, jobs.TypeEmbedHighlight); err != nil {
+				app.Logger().Error("Failed to enqueue job", "type", jobs.TypeEmbedHighlight, "highlightID", e.Record.Id, "error", err)
+			}
+		}
+		return e.Next()
+	})
+
+	// Keep the full-text search index in sync with links and highlights
+	app.OnRecordAfterCreateSuccess("links", "highlights").BindFunc(func(e *core.RecordEvent) error {
+		indexForSearch(e)
+		return e.Next()
+	})
+
+	app.OnRecordAfterUpdateSuccess("links", "highlights").BindFunc(func(e *core.RecordEvent) error {
+		indexForSearch(e)
+		return e.Next()
+	})
+
+	app.OnRecordAfterDeleteSuccess("links", "highlights").BindFunc(func(e *core.RecordEvent) error {
+		if err := fulltext.Remove(e.App, e.Record.Id); err != nil {
+			e.App.Logger().Error("Failed to remove record from search index", "recordID", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	})
+
+	// Reject URL rewrite rules that can't be parsed, rather than
+	// silently ignoring them whenever a link is saved
+	app.OnRecordCreateRequest("user_settings").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := validateRewriteRules(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	app.OnRecordUpdateRequest("user_settings").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := validateRewriteRules(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	// Saved searches are validated when they're saved. Their counts
+	// aren't stored but added as they're returned, so they're never out
+	// of date; that costs one count query per saved search returned.
+	app.OnRecordCreateRequest("saved_searches").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := validateSavedSearch(e); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	app.OnRecordUpdateRequest("saved_searches").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := validateSavedSearch(e); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	app.OnRecordEnrich("saved_searches").BindFunc(func(e *core.RecordEnrichEvent) error {
+		if err := savedsearches.ApplyCounts(e.App, e.Record); err != nil {
+			e.App.Logger().Error("Failed to count saved search", "savedSearchID", e.Record.Id, "error", err)
+		}
 		return e.Next()
 	})
 
@@ -115,6 +327,41 @@ func InitializePocketbase(app core.App) {
 	})
 }
 
+func indexForSearch(e *core.RecordEvent) {
+	var err error
+	switch e.Record.Collection().Name {
+	case "links":
+		if !fulltext.LinkChanged(e.Record) {
+			return
+		}
+		err = fulltext.IndexLink(e.App, e.Record)
+	case "highlights":
+		err = fulltext.IndexHighlight(e.App, e.Record)
+	}
+	if err != nil {
+		e.App.Logger().Error("Failed to update search index", "recordID", e.Record.Id, "error", err)
+	}
+}
+
+func validateRewriteRules(settings *core.Record) error {
+	if _, err := url_parser.ParseRewriteRules(settings.GetString("url_rewrite_rules")); err != nil {
+		return apis.NewBadRequestError("Invalid URL rewrite rules: "+err.Error(), nil)
+	}
+	return nil
+}
+
+// validateSavedSearch rejects saved searches with invalid queries.
+func validateSavedSearch(e *core.RecordRequestEvent) error {
+	if _, err := searchquery.Parse(e.Record.GetString("query")); err != nil {
+		var parseErr *searchquery.ParseError
+		if errors.As(err, &parseErr) {
+			return apis.NewBadRequestError("Invalid query: "+parseErr.Error(), nil)
+		}
+		return apis.NewBadRequestError("Invalid query", err)
+	}
+	return nil
+}
+
 func handleGenerateAPIKey(app core.App, e *core.RequestEvent) error {
 	authRecord := e.Auth
 	if authRecord == nil {
@@ -154,7 +401,100 @@ func handleGenerateAPIKey(app core.App, e *core.RequestEvent) error {
 	})
 }
 
-func handleArchiveLink(app core.App, e *core.RequestEvent) error {
+// savedLinkResponse responds with the id of a newly saved link, or
+// with the id of the existing link if it was a duplicate.
+func savedLinkResponse(e *core.RequestEvent, record *core.Record, err error) error {
+	if existing, ok := url_parser.IsDuplicate(err); ok {
+		return e.JSON(http.StatusConflict, map[string]interface{}{
+			"status":  http.StatusConflict,
+			"message": "This link is already in your library.",
+			"id":      existing.Id,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"id": record.Id,
+	})
+}
+
+func handleGenerateEmailAddress(app core.App, e *core.RequestEvent) error {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	name := strings.TrimSpace(e.Request.FormValue("name"))
+	if name == "" {
+		return apis.NewBadRequestError("'name' parameter is required", nil)
+	}
+	saveLinks := e.Request.FormValue("save_links") == "true"
+
+	collection, err := app.FindCollectionByNameOrId("email_addresses")
+	if err != nil {
+		return apis.NewBadRequestError("Failed to find email_addresses collection", err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", authRecord.Id)
+	record.Set("name", name)
+	record.Set("token", email.NewToken())
+	record.Set("save_links", saveLinks)
+
+	if err := app.Save(record); err != nil {
+		return apis.NewBadRequestError("Failed to save email address", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"id":         record.Id,
+		"name":       name,
+		"address":    email.Address(record),
+		"save_links": saveLinks,
+	})
+}
+
+func handleMergeLinks(app core.App, e *core.RequestEvent) error {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	targetID := e.Request.FormValue("target")
+	if targetID == "" {
+		return apis.NewBadRequestError("Missing 'target' parameter", nil)
+	}
+
+	// Accept sources as repeated fields or a comma separated list
+	var sourceIDs []string
+	for _, value := range e.Request.Form["sources"] {
+		for _, sourceID := range strings.Split(value, ",") {
+			if sourceID = strings.TrimSpace(sourceID); sourceID != "" && !slices.Contains(sourceIDs, sourceID) {
+				sourceIDs = append(sourceIDs, sourceID)
+			}
+		}
+	}
+
+	link, err := linkmerge.Merge(app, authRecord.Id, targetID, sourceIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, linkmerge.ErrNoSources),
+			errors.Is(err, linkmerge.ErrSameLink):
+			return apis.NewBadRequestError(err.Error(), nil)
+		case errors.Is(err, linkmerge.ErrLinkNotFound):
+			return apis.NewNotFoundError("Link not found", nil)
+		default:
+			return apis.NewBadRequestError("Failed to merge links", err)
+		}
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"id":     link.Id,
+		"merged": sourceIDs,
+	})
+}
+
+func handleArchiveLink(app core.App, e *core.RequestEvent, jobQueue *jobs.Queue) error {
 	linkID := e.Request.PathValue("id")
 	if linkID == "" {
 		return apis.NewNotFoundError("Link ID is required", nil)
@@ -174,11 +514,400 @@ func handleArchiveLink(app core.App, e *core.RequestEvent) error {
 		return apis.NewForbiddenError("You don't have permission to archive this link", nil)
 	}
 
-	go func() {
-		singlefile.MaybeArchiveLink(app, linkID)
-	}()
+	job, err := jobQueue.Enqueue(link, jobs.TypeArchive)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to start archive process", err)
+	}
 
 	return e.JSON(http.StatusOK, map[string]interface{}{
 		"message": "Archive process started",
+		"job":     job.Id,
+	})
+}
+
+func handleSummarizeLink(app core.App, e *core.RequestEvent) error {
+	linkID := e.Request.PathValue("id")
+	if linkID == "" {
+		return apis.NewNotFoundError("Link ID is required", nil)
+	}
+
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return apis.NewNotFoundError("Link not found", err)
+	}
+
+	if link.GetString("user") != authRecord.Id {
+		return apis.NewForbiddenError("You don't have permission to summarize this link", nil)
+	}
+
+	previousSummary := link.GetString("summary")
+	summary, err := CurrentSummarizer.SummarizeLink(app, linkID, e.Request.FormValue("model"))
+	if err != nil {
+		switch {
+		case errors.Is(err, summarizer.ErrSettingsNotFound),
+			errors.Is(err, summarizer.ErrMissingModel),
+			errors.Is(err, summarizer.ErrMissingAPIKey):
+			return apis.NewBadRequestError(err.Error(), nil)
+		default:
+			return apis.NewApiError(http.StatusBadGateway, "Failed to summarize link", err)
+		}
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"id":               link.Id,
+		"summary":          summary,
+		"previous_summary": previousSummary,
+	})
+}
+
+// Maps each enrichment stage recorded on links to the job that runs it
+var linkStatusStages = []struct {
+	stage   string
+	jobType string
+}{
+	{linkstatus.StageSummary, jobs.TypeSummarize},
+	{linkstatus.StageArchive, jobs.TypeArchive},
+	{linkstatus.StageTags, jobs.TypeSuggestTags},
+}
+
+func handleLinkStatus(app core.App, e *core.RequestEvent) error {
+	linkID := e.Request.PathValue("id")
+	if linkID == "" {
+		return apis.NewNotFoundError("Link ID is required", nil)
+	}
+
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return apis.NewNotFoundError("Link not found", err)
+	}
+
+	if link.GetString("user") != authRecord.Id {
+		return apis.NewForbiddenError("You don't have permission to view this link", nil)
+	}
+
+	result := map[string]interface{}{
+		"id": link.Id,
+	}
+	for _, s := range linkStatusStages {
+		stageStatus := map[string]interface{}{
+			"status": link.GetString(s.stage + "_status"),
+			"error":  link.GetString(s.stage + "_error"),
+		}
+
+		// The link only records the outcome of the last attempt, so
+		// check the most recent job to see if work is still in flight.
+		jobRecords, err := app.FindRecordsByFilter(
+			"jobs",
+			"link = {:link} && type = {:type}",
+			"-created",
+			1,
+			0,
+			dbx.Params{"link": link.Id, "type": s.jobType},
+		)
+		if err == nil && len(jobRecords) > 0 {
+			job := jobRecords[0]
+			stageStatus["attempts"] = job.GetInt("attempts")
+			switch job.GetString("status") {
+			case jobs.StatusPending, jobs.StatusRunning:
+				stageStatus["status"] = job.GetString("status")
+				if nextAttempt := job.GetDateTime("next_attempt_at"); !nextAttempt.IsZero() {
+					stageStatus["next_attempt_at"] = nextAttempt
+				}
+			}
+		}
+
+		result[s.stage] = stageStatus
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func handleAcceptNewTag(app core.App, e *core.RequestEvent) error {
+	linkID := e.Request.PathValue("id")
+	if linkID == "" {
+		return apis.NewNotFoundError("Link ID is required", nil)
+	}
+
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	name := strings.TrimSpace(e.Request.FormValue("name"))
+	if name == "" {
+		return apis.NewBadRequestError("Tag name is required", nil)
+	}
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return apis.NewNotFoundError("Link not found", err)
+	}
+
+	if link.GetString("user") != authRecord.Id {
+		return apis.NewForbiddenError("You don't have permission to tag this link", nil)
+	}
+
+	tag, err := tagger.AcceptNewTag(app, linkID, name)
+	if err != nil {
+		if errors.Is(err, tagger.ErrTagNotSuggested) {
+			return apis.NewBadRequestError("Tag was not suggested for this link", nil)
+		}
+		return apis.NewBadRequestError("Failed to create tag", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"id":   tag.Id,
+		"name": tag.GetString("name"),
+		"slug": tag.GetString("slug"),
+	})
+}
+
+func handleAskQuestion(app core.App, e *core.RequestEvent) error {
+	linkID := e.Request.PathValue("id")
+	if linkID == "" {
+		return apis.NewNotFoundError("Link ID is required", nil)
+	}
+
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return apis.NewNotFoundError("Link not found", err)
+	}
+
+	if link.GetString("user") != authRecord.Id {
+		return apis.NewForbiddenError("You don't have permission to ask about this link", nil)
+	}
+
+	save := e.Request.FormValue("save") == "true"
+	answer, err := questions.Ask(app, linkID, e.Request.FormValue("question"), e.Request.FormValue("model"), save)
+	if err != nil {
+		switch {
+		case errors.Is(err, questions.ErrEmptyQuestion),
+			errors.Is(err, questions.ErrNoContent),
+			errors.Is(err, questions.ErrSettingsNotFound),
+			errors.Is(err, questions.ErrMissingModel),
+			errors.Is(err, llm.ErrMissingAPIKey),
+			errors.Is(err, llm.ErrUnknownProvider):
+			return apis.NewBadRequestError(err.Error(), nil)
+		default:
+			return apis.NewApiError(http.StatusBadGateway, "Failed to answer question", err)
+		}
+	}
+
+	return e.JSON(http.StatusOK, answer)
+}
+
+func handleRelatedLinks(app core.App, e *core.RequestEvent) error {
+	linkID := e.Request.PathValue("id")
+	if linkID == "" {
+		return apis.NewNotFoundError("Link ID is required", nil)
+	}
+
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	limit, err := parsePositiveInt(e, "limit")
+	if err != nil {
+		return err
+	}
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return apis.NewNotFoundError("Link not found", err)
+	}
+
+	if link.GetString("user") != authRecord.Id {
+		return apis.NewForbiddenError("You don't have permission to view this link", nil)
+	}
+
+	results, err := related.Find(app, linkID, limit)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to find related links", err)
+	}
+
+	return e.JSON(http.StatusOK, results)
+}
+
+// parsePositiveInt reads an optional numeric query parameter,
+// returning 0 if it isn't set.
+func parsePositiveInt(e *core.RequestEvent, name string) (int, error) {
+	value := e.Request.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, apis.NewBadRequestError(strings.ToUpper(name[:1])+name[1:]+" must be a positive number", nil)
+	}
+	return number, nil
+}
+
+// parseFloat reads an optional numeric query parameter, returning the
+// fallback if it isn't set.
+func parseFloat(e *core.RequestEvent, name string, fallback float64) (float64, error) {
+	value := e.Request.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, apis.NewBadRequestError(strings.ToUpper(name[:1])+name[1:]+" must be a number", nil)
+	}
+	return number, nil
+}
+
+func handleSearch(app core.App, e *core.RequestEvent) error {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	page, err := parsePositiveInt(e, "page")
+	if err != nil {
+		return err
+	}
+	perPage, err := parsePositiveInt(e, "perPage")
+	if err != nil {
+		return err
+	}
+
+	query := e.Request.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		return apis.NewBadRequestError("Query is required", nil)
+	}
+
+	results, err := searchquery.Search(app, authRecord.Id, query, page, perPage)
+	if err != nil {
+		var parseErr *searchquery.ParseError
+		if errors.As(err, &parseErr) {
+			return apis.NewBadRequestError("Invalid query: "+parseErr.Error(), nil)
+		}
+		return apis.NewBadRequestError("Failed to search", err)
+	}
+
+	return e.JSON(http.StatusOK, results)
+}
+
+func handleSavedSearchLinks(app core.App, e *core.RequestEvent) error {
+	savedSearchID := e.Request.PathValue("id")
+	if savedSearchID == "" {
+		return apis.NewNotFoundError("Saved search ID is required", nil)
+	}
+
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	page, err := parsePositiveInt(e, "page")
+	if err != nil {
+		return err
+	}
+	perPage, err := parsePositiveInt(e, "perPage")
+	if err != nil {
+		return err
+	}
+
+	savedSearch, err := app.FindRecordById("saved_searches", savedSearchID)
+	if err != nil {
+		return apis.NewNotFoundError("Saved search not found", err)
+	}
+
+	if savedSearch.GetString("user") != authRecord.Id {
+		return apis.NewForbiddenError("You don't have permission to view this saved search", nil)
+	}
+
+	results, err := savedsearches.Links(app, savedSearch, page, perPage)
+	if err != nil {
+		var parseErr *searchquery.ParseError
+		if errors.As(err, &parseErr) {
+			return apis.NewBadRequestError("Invalid query: "+parseErr.Error(), nil)
+		}
+		return apis.NewBadRequestError("Failed to search", err)
+	}
+
+	return e.JSON(http.StatusOK, results)
+}
+
+func handleReadingQueue(app core.App, e *core.RequestEvent) error {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	opts := readingqueue.Options{Weights: readingqueue.DefaultWeights}
+	var err error
+	if opts.MaxMinutes, err = parsePositiveInt(e, "max_minutes"); err != nil {
+		return err
+	}
+	if opts.Limit, err = parsePositiveInt(e, "limit"); err != nil {
+		return err
+	}
+	for name, weight := range map[string]*float64{
+		"length_weight":  &opts.Weights.Length,
+		"age_weight":     &opts.Weights.Age,
+		"starred_weight": &opts.Weights.Starred,
+		"feed_weight":    &opts.Weights.Feed,
+	} {
+		if *weight, err = parseFloat(e, name, *weight); err != nil {
+			return err
+		}
+	}
+	if opts.Weights.Tags, err = readingqueue.ParseTagWeights(e.Request.URL.Query().Get("tag_weights")); err != nil {
+		return apis.NewBadRequestError("Invalid tag_weights: "+err.Error(), nil)
+	}
+
+	queue, err := readingqueue.Build(app, authRecord.Id, opts, time.Now())
+	if err != nil {
+		return apis.NewBadRequestError("Failed to build reading queue", err)
+	}
+
+	return e.JSON(http.StatusOK, queue)
+}
+
+func handleSemanticSearch(app core.App, e *core.RequestEvent) error {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	limit, err := parsePositiveInt(e, "limit")
+	if err != nil {
+		return err
+	}
+
+	results, err := embeddings.Search(app, authRecord.Id, e.Request.URL.Query().Get("q"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, embeddings.ErrEmptyQuery),
+			errors.Is(err, embeddings.ErrSettingsNotFound),
+			errors.Is(err, embeddings.ErrMissingModel),
+			errors.Is(err, llm.ErrMissingAPIKey),
+			errors.Is(err, llm.ErrUnknownProvider),
+			errors.Is(err, llm.ErrEmbeddingsNotSupported):
+			return apis.NewBadRequestError(err.Error(), nil)
+		default:
+			return apis.NewApiError(http.StatusBadGateway, "Failed to search", err)
+		}
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"items": results,
 	})
 }
@@ -1,7 +1,10 @@
 package lynx
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
@@ -10,6 +13,10 @@ import (
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tests"
+
+	"main/lynx/fulltext"
+	"main/lynx/summarizer"
+	"main/lynx/url_parser"
 )
 
 const testDataDir = "../test_pb_data"
@@ -328,15 +335,17 @@ func TestSummarizationHook(t *testing.T) {
 			},
 			ExpectedStatus: 200,
 			ExpectedEvents: map[string]int{
-				"OnRecordCreate":             1,
-				"OnRecordAfterCreateSuccess": 1,
+				// The link itself plus one job per enrichment step
+				"OnRecordCreate":             5,
+				"OnRecordAfterCreateSuccess": 5,
 			},
 			ExpectedContent: []string{"example.com"},
 			TestAppFactory:  setupTestApp,
 			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
 				CurrentSummarizer = &MockSummarizer{
-					MaybeSummarizeLinkFunc: func(app core.App, linkID string) {
+					MaybeSummarizeLinkFunc: func(app core.App, linkID string) error {
 						summarizeCalled = true
+						return nil
 					},
 				}
 			},
@@ -357,13 +366,22 @@ func TestSummarizationHook(t *testing.T) {
 }
 
 type MockSummarizer struct {
-	MaybeSummarizeLinkFunc func(app core.App, linkID string)
+	MaybeSummarizeLinkFunc func(app core.App, linkID string) error
+	SummarizeLinkFunc      func(app core.App, linkID string, model string) (string, error)
 }
 
-func (m *MockSummarizer) MaybeSummarizeLink(app core.App, linkID string) {
+func (m *MockSummarizer) MaybeSummarizeLink(app core.App, linkID string) error {
 	if m.MaybeSummarizeLinkFunc != nil {
-		m.MaybeSummarizeLinkFunc(app, linkID)
+		return m.MaybeSummarizeLinkFunc(app, linkID)
+	}
+	return nil
+}
+
+func (m *MockSummarizer) SummarizeLink(app core.App, linkID string, model string) (string, error) {
+	if m.SummarizeLinkFunc != nil {
+		return m.SummarizeLinkFunc(app, linkID, model)
 	}
+	return "", nil
 }
 
 func generateValidLinkJSON(t testing.TB) string {
@@ -555,3 +573,1467 @@ func TestOnModelAfterCreateFeedItems(t *testing.T) {
 		t.Error("convertFeedItemToLinkFunc was not called after feed item creation")
 	}
 }
+
+func TestHandleLinkStatus(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodGet,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/status",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Link owned by another user",
+			Method: http.MethodGet,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/status",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"message":"You don't have permission to view this link."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Link not found",
+			Method: http.MethodGet,
+			URL:    "/lynx/link/doesnotexist123/status",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"message":"Link not found."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Stage statuses and in-flight jobs",
+			Method: http.MethodGet,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/status",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				link, err := app.FindRecordById("links", "8n3iq8dt6vwi4ph")
+				if err != nil {
+					t.Fatal(err)
+				}
+				link.Set("summary_status", "succeeded")
+				link.Set("archive_status", "failed")
+				link.Set("archive_error", "API request failed with status 502: bad gateway")
+				if err := app.Save(link); err != nil {
+					t.Fatal(err)
+				}
+
+				// A retry of the failed archive is still scheduled
+				collection, err := app.FindCollectionByNameOrId("jobs")
+				if err != nil {
+					t.Fatal(err)
+				}
+				job := core.NewRecord(collection)
+				job.Set("user", link.GetString("user"))
+				job.Set("link", link.Id)
+				job.Set("type", "archive")
+				job.Set("status", "pending")
+				job.Set("attempts", 2)
+				job.Set("next_attempt_at", time.Now().UTC().Add(time.Hour))
+				if err := app.Save(job); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"id":"8n3iq8dt6vwi4ph"`,
+				`"summary":{"error":"","status":"succeeded"}`,
+				`"archive":{"attempts":2,"error":"API request failed with status 502: bad gateway","next_attempt_at":`,
+				`"status":"pending"`,
+				`"tags":{"error":"","status":""}`,
+			},
+			TestAppFactory: setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleSummarizeLink(t *testing.T) {
+	originalSummarizer := CurrentSummarizer
+	t.Cleanup(func() {
+		CurrentSummarizer = originalSummarizer
+	})
+
+	var requestedModel string
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		requestedModel = ""
+		CurrentSummarizer = &MockSummarizer{
+			SummarizeLinkFunc: func(app core.App, linkID string, model string) (string, error) {
+				requestedModel = model
+				if linkID == "nosettingslink1" {
+					return "", fmt.Errorf("OpenRouter %w", summarizer.ErrMissingAPIKey)
+				}
+				return "A fresh summary", nil
+			},
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	createLink := func(t testing.TB, app *tests.TestApp, id string, userID string) {
+		collection, err := app.FindCollectionByNameOrId("links")
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := core.NewRecord(collection)
+		link.Set("id", id)
+		link.Set("user", userID)
+		link.Set("original_url", "https://example.com")
+		link.Set("cleaned_url", "https://example.com")
+		link.Set("added_to_library", time.Now().Format(time.RFC3339))
+		link.Set("title", "Test Link")
+		if err := app.Save(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/summarize",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Link owned by another user",
+			Method: http.MethodPost,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/summarize",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"message":"You don't have permission to summarize this link."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Summarize with model override",
+			Method: http.MethodPost,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/summarize",
+			Body:   strings.NewReader(url.Values{"model": {"openai/gpt-4o"}}.Encode()),
+			Headers: map[string]string{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"id":"8n3iq8dt6vwi4ph"`,
+				`"summary":"A fresh summary"`,
+				`"previous_summary":""`,
+			},
+			TestAppFactory: setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if requestedModel != "openai/gpt-4o" {
+					t.Fatalf("Expected model override to be passed through, got %q", requestedModel)
+				}
+			},
+		},
+		{
+			Name:   "Summarize with API key and missing settings",
+			Method: http.MethodPost,
+			URL:    "/lynx/link/nosettingslink1/summarize",
+			Headers: map[string]string{
+				"X-API-KEY": "this_is_a_test_api_key",
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				createLink(t, app, "nosettingslink1", "h4oofx0tx2eupnq")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"OpenRouter API key not set for user."`},
+			TestAppFactory:  setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if requestedModel != "" {
+					t.Fatalf("Expected no model override, got %q", requestedModel)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleAcceptNewTag(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		link, err := testApp.FindRecordById("links", "8n3iq8dt6vwi4ph")
+		if err != nil {
+			t.Fatal(err)
+		}
+		link.Set("suggested_new_tags", []string{"Machine Learning", "Databases"})
+		if err := testApp.Save(link); err != nil {
+			t.Fatal(err)
+		}
+
+		return testApp
+	}
+
+	formHeaders := func(email string) map[string]string {
+		return map[string]string{
+			"Authorization": generateRecordToken("users", email),
+			"Content-Type":  "application/x-www-form-urlencoded",
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/accept_new_tag",
+			Body:            strings.NewReader("name=Databases"),
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:            "Link owned by another user",
+			Method:          http.MethodPost,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/accept_new_tag",
+			Body:            strings.NewReader("name=Databases"),
+			Headers:         formHeaders("test@example.com"),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"message":"You don't have permission to tag this link."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:            "Tag that was not suggested",
+			Method:          http.MethodPost,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/accept_new_tag",
+			Body:            strings.NewReader("name=Cooking"),
+			Headers:         formHeaders("test2@example.com"),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Tag was not suggested for this link."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:           "Accept a suggested tag",
+			Method:         http.MethodPost,
+			URL:            "/lynx/link/8n3iq8dt6vwi4ph/accept_new_tag",
+			Body:           strings.NewReader("name=machine+learning"),
+			Headers:        formHeaders("test2@example.com"),
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"name":"Machine Learning"`,
+				`"slug":"machine-learning"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordCreate": 1,
+				"OnRecordUpdate": 1,
+			},
+			TestAppFactory: setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				link, err := app.FindRecordById("links", "8n3iq8dt6vwi4ph")
+				if err != nil {
+					t.Fatal(err)
+				}
+				var remaining []string
+				if err := link.UnmarshalJSONField("suggested_new_tags", &remaining); err != nil {
+					t.Fatal(err)
+				}
+				if len(remaining) != 1 || remaining[0] != "Databases" {
+					t.Errorf("Expected only Databases to remain suggested, got %v", remaining)
+				}
+				tagIDs := link.GetStringSlice("tags")
+				if len(tagIDs) != 1 {
+					t.Fatalf("Expected the link to have 1 tag, got %v", tagIDs)
+				}
+				tag, err := app.FindRecordById("tags", tagIDs[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tag.GetString("user") != "u3ozd82edmlybb1" {
+					t.Errorf("Expected tag to belong to the link's user, got %q", tag.GetString("user"))
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleAskQuestion(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/ask",
+			Body:            strings.NewReader("question=Why%3F"),
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Link owned by another user",
+			Method: http.MethodPost,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/ask",
+			Body:   strings.NewReader("question=Why%3F"),
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test@example.com"),
+				"Content-Type":  "application/x-www-form-urlencoded",
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"message":"You don't have permission to ask about this link."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Missing question",
+			Method: http.MethodPost,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/ask",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Question is required."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "User without settings",
+			Method: http.MethodPost,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/ask",
+			Body:   strings.NewReader("question=Why%3F"),
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+				"Content-Type":  "application/x-www-form-urlencoded",
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"User_settings not found for user."`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleSemanticSearch(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodGet,
+			URL:             "/lynx/search/semantic?q=databases",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Missing query",
+			Method: http.MethodGet,
+			URL:    "/lynx/search/semantic",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Query is required."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid limit",
+			Method: http.MethodGet,
+			URL:    "/lynx/search/semantic?q=databases&limit=none",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Limit must be a positive number."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "User without settings",
+			Method: http.MethodGet,
+			URL:    "/lynx/search/semantic?q=databases",
+			Headers: map[string]string{
+				"X-API-KEY": "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"User_settings not found for user."`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleRelatedLinks(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodGet,
+			URL:             "/lynx/link/8n3iq8dt6vwi4ph/related",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Link owned by another user",
+			Method: http.MethodGet,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/related",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"message":"You don't have permission to view this link."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "No other links",
+			Method: http.MethodGet,
+			URL:    "/lynx/link/8n3iq8dt6vwi4ph/related?limit=3",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"similarity":"tfidf"`, `"items":[]`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodGet,
+			URL:             "/lynx/search?q=example",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Missing query",
+			Method: http.MethodGet,
+			URL:    "/lynx/search?q=%20",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Query is required."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid page",
+			Method: http.MethodGet,
+			URL:    "/lynx/search?q=example&page=0",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Page must be a positive number."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid query",
+			Method: http.MethodGet,
+			URL:    "/lynx/search?q=is%3Aunread%20has%3Anothing",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Invalid query: unknown value \"nothing\" for has:, expected one of highlights, summary, archive, tags (at position 11)."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Operators and words",
+			Method: http.MethodGet,
+			URL:    "/lynx/search?q=site%3Aexample.com%20is%3Aunread%20article",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totalItems":1`,
+				`"id":"8n3iq8dt6vwi4ph"`,
+				`"snippet":"Example \u003cmark\u003eArticle\u003c/mark\u003e"`,
+			},
+			TestAppFactory: setupTestApp,
+		},
+		{
+			Name:   "Matching link",
+			Method: http.MethodGet,
+			URL:    "/lynx/search?q=exam*",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totalItems":1`,
+				`"id":"8n3iq8dt6vwi4ph"`,
+				`"title":"Example Article"`,
+			},
+			TestAppFactory: setupTestApp,
+		},
+		{
+			Name:   "Other users' links are not searched",
+			Method: http.MethodGet,
+			URL:    "/lynx/search?q=example",
+			Headers: map[string]string{
+				"X-API-KEY": "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"totalItems":0`, `"items":[]`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestSearchIndexHooks(t *testing.T) {
+	testApp, err := tests.NewTestApp(testDataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testApp.Cleanup()
+
+	InitializePocketbase(testApp)
+
+	link, err := testApp.FindRecordById("links", "8n3iq8dt6vwi4ph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link.Set("title", "Notes on beekeeping")
+	if err := testApp.Save(link); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := fulltext.SearchMatch(testApp, "u3ozd82edmlybb1", `"beekeeping"`, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 1 {
+		t.Errorf("Expected the updated link to be indexed, got %d results", results.TotalItems)
+	}
+
+	if err := testApp.Delete(link); err != nil {
+		t.Fatal(err)
+	}
+	results, err = fulltext.SearchMatch(testApp, "u3ozd82edmlybb1", `"beekeeping"`, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 0 {
+		t.Errorf("Expected the deleted link to be removed from the index, got %d results", results.TotalItems)
+	}
+}
+
+func TestHandleSavedSearchLinks(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		collection, err := testApp.FindCollectionByNameOrId("saved_searches")
+		if err != nil {
+			t.Fatal(err)
+		}
+		savedSearch := core.NewRecord(collection)
+		savedSearch.Id = "savedsearch0001"
+		savedSearch.Set("user", "u3ozd82edmlybb1")
+		savedSearch.Set("name", "Unread examples")
+		savedSearch.Set("query", "site:example.com is:unread")
+		if err := testApp.Save(savedSearch); err != nil {
+			t.Fatal(err)
+		}
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodGet,
+			URL:             "/lynx/saved_search/savedsearch0001/links",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Saved search not found",
+			Method: http.MethodGet,
+			URL:    "/lynx/saved_search/doesnotexist000/links",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"message":"Saved search not found."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Saved search owned by another user",
+			Method: http.MethodGet,
+			URL:    "/lynx/saved_search/savedsearch0001/links",
+			Headers: map[string]string{
+				"X-API-KEY": "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"message":"You don't have permission to view this saved search."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Matching links",
+			Method: http.MethodGet,
+			URL:    "/lynx/saved_search/savedsearch0001/links?perPage=5",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"perPage":5`,
+				`"totalItems":1`,
+				`"id":"8n3iq8dt6vwi4ph"`,
+			},
+			TestAppFactory: setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestSavedSearchRequests(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "Invalid query",
+			Method: http.MethodPost,
+			URL:    "/api/collections/saved_searches/records",
+			Body:   strings.NewReader(`{"user":"u3ozd82edmlybb1","name":"Broken","query":"is:nope"}`),
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Invalid query: unknown value \"nope\" for is:`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Counts are filled in",
+			Method: http.MethodPost,
+			URL:    "/api/collections/saved_searches/records",
+			Body:   strings.NewReader(`{"user":"u3ozd82edmlybb1","name":"Examples","query":"site:example.com","link_count":42}`),
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"name":"Examples"`,
+				`"link_count":1`,
+				`"unread_count":1`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordCreateRequest": 1,
+			},
+			TestAppFactory: setupTestApp,
+		},
+		{
+			Name:   "Counts are current when listed",
+			Method: http.MethodGet,
+			URL:    "/api/collections/saved_searches/records",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection, err := app.FindCollectionByNameOrId("saved_searches")
+				if err != nil {
+					t.Fatal(err)
+				}
+				savedSearch := core.NewRecord(collection)
+				savedSearch.Set("user", "u3ozd82edmlybb1")
+				savedSearch.Set("name", "Examples")
+				savedSearch.Set("query", "site:example.com")
+				if err := app.Save(savedSearch); err != nil {
+					t.Fatal(err)
+				}
+
+				link, err := app.FindRecordById("links", "8n3iq8dt6vwi4ph")
+				if err != nil {
+					t.Fatal(err)
+				}
+				link.Set("last_viewed_at", "2025-01-01 00:00:00.000Z")
+				if err := app.Save(link); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"name":"Examples"`,
+				`"link_count":1`,
+				`"unread_count":0`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordEnrich": 1,
+			},
+			TestAppFactory: setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleReadingQueue(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodGet,
+			URL:             "/lynx/queue",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid budget",
+			Method: http.MethodGet,
+			URL:    "/lynx/queue?max_minutes=-5",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Max_minutes must be a positive number."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid weight",
+			Method: http.MethodGet,
+			URL:    "/lynx/queue?starred_weight=lots",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Starred_weight must be a number."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid tag weights",
+			Method: http.MethodGet,
+			URL:    "/lynx/queue?tag_weights=golang",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Invalid tag_weights: invalid tag weight \"golang\", expected tag:weight."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Unread links",
+			Method: http.MethodGet,
+			URL:    "/lynx/queue?length_weight=2&tag_weights=golang:1",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"max_minutes":0`,
+				`"id":"8n3iq8dt6vwi4ph"`,
+				`"title":"Example Article"`,
+			},
+			TestAppFactory: setupTestApp,
+		},
+		{
+			Name:   "Nothing fits the budget",
+			Method: http.MethodGet,
+			URL:    "/lynx/queue?max_minutes=1",
+			Headers: map[string]string{
+				"X-API-KEY": "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"max_minutes":1`, `"total_seconds":0`, `"items":[]`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleParseURLDuplicate(t *testing.T) {
+	originalHandleParseURL := parseUrlHandlerFunc
+
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parseUrlHandlerFunc = func(app core.App, c *core.RequestEvent) (*core.Record, error) {
+			existing, err := app.FindRecordById("links", "8n3iq8dt6vwi4ph")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return nil, &url_parser.DuplicateLinkError{Link: existing}
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	t.Cleanup(func() {
+		parseUrlHandlerFunc = originalHandleParseURL
+	})
+
+	scenario := tests.ApiScenario{
+		Name:   "Link already saved",
+		Method: http.MethodPost,
+		URL:    "/lynx/parse_link",
+		Body:   strings.NewReader("url=https://example.com"),
+		Headers: map[string]string{
+			"Authorization": generateRecordToken("users", "test2@example.com"),
+		},
+		ExpectedStatus: 409,
+		ExpectedContent: []string{
+			`"id":"8n3iq8dt6vwi4ph"`,
+			`"message":"This link is already in your library."`,
+		},
+		TestAppFactory: setupTestApp,
+	}
+	scenario.Test(t)
+}
+
+func TestHandleMergeLinks(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		collection, err := testApp.FindCollectionByNameOrId("links")
+		if err != nil {
+			t.Fatal(err)
+		}
+		duplicate := core.NewRecord(collection)
+		duplicate.Id = "duplicatelink01"
+		duplicate.Set("user", "u3ozd82edmlybb1")
+		duplicate.Set("original_url", "https://example.com/?utm_source=rss")
+		duplicate.Set("cleaned_url", "https://example.com/")
+		duplicate.Set("added_to_library", "2025-01-01 00:00:00.000Z")
+		duplicate.Set("reading_progress", 0.5)
+		if err := testApp.Save(duplicate); err != nil {
+			t.Fatal(err)
+		}
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/links/merge",
+			Body:            strings.NewReader("target=8n3iq8dt6vwi4ph&sources=duplicatelink01"),
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Missing sources",
+			Method: http.MethodPost,
+			URL:    "/lynx/links/merge",
+			Body:   strings.NewReader("target=8n3iq8dt6vwi4ph"),
+			Headers: map[string]string{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"At least one link to merge is required."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Links owned by another user",
+			Method: http.MethodPost,
+			URL:    "/lynx/links/merge",
+			Body:   strings.NewReader("target=8n3iq8dt6vwi4ph&sources=duplicatelink01"),
+			Headers: map[string]string{
+				"Content-Type": "application/x-www-form-urlencoded",
+				"X-API-KEY":    "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"message":"Link not found."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Merge duplicate",
+			Method: http.MethodPost,
+			URL:    "/lynx/links/merge",
+			Body:   strings.NewReader("target=8n3iq8dt6vwi4ph&sources=duplicatelink01&sources=duplicatelink01"),
+			Headers: map[string]string{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"id":"8n3iq8dt6vwi4ph"`, `"merged":["duplicatelink01"]`},
+			TestAppFactory:  setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				link, err := app.FindRecordById("links", "8n3iq8dt6vwi4ph")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if link.GetFloat("reading_progress") != 0.5 {
+					t.Errorf("Expected reading progress to be merged, got %v", link.GetFloat("reading_progress"))
+				}
+				if _, err := app.FindRecordById("links", "duplicatelink01"); err == nil {
+					t.Error("Expected the duplicate link to be deleted")
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestNormalizedURLHook(t *testing.T) {
+	testApp, err := tests.NewTestApp(testDataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testApp.Cleanup()
+
+	InitializePocketbase(testApp)
+
+	link, err := testApp.FindRecordById("links", "8n3iq8dt6vwi4ph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link.Set("cleaned_url", "https://www.example.com/article/?utm_source=rss")
+	if err := testApp.Save(link); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err = testApp.FindRecordById("links", "8n3iq8dt6vwi4ph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.GetString("normalized_url") != "example.com/article" {
+		t.Errorf("Expected normalized URL to be updated, got %q", link.GetString("normalized_url"))
+	}
+}
+
+func TestUserSettingsRewriteRules(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "Invalid rules",
+			Method: http.MethodPost,
+			URL:    "/api/collections/user_settings/records",
+			Body:   strings.NewReader(`{"user":"u3ozd82edmlybb1","url_rewrite_rules":"twitter.com =>"}`),
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Invalid URL rewrite rules: line 1: missing hostname after =\u003e."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Valid rules",
+			Method: http.MethodPost,
+			URL:    "/api/collections/user_settings/records",
+			Body:   strings.NewReader(`{"user":"u3ozd82edmlybb1","url_rewrite_rules":"twitter.com => nitter.net -s"}`),
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"url_rewrite_rules":"twitter.com =\u003e nitter.net -s"`},
+			ExpectedEvents: map[string]int{
+				"OnRecordCreateRequest": 1,
+			},
+			TestAppFactory: setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleParseHTML(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	upload := func(fields map[string]string, filename string, content string) (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		if filename != "" {
+			part, err := writer.CreateFormFile("file", filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte(content))
+		}
+		writer.Close()
+		return body, writer.FormDataContentType()
+	}
+
+	article := `<html><head><title>Members Only</title></head><body><article><h1>Members Only</h1><p>A post only members can read, with enough words in it that readability keeps it around.</p></article></body></html>`
+
+	unauthenticatedBody, unauthenticatedContentType := upload(nil, "post.html", article)
+	htmlBody, htmlContentType := upload(map[string]string{"url": "https://example.com/members/post?utm_source=email"}, "post.html", article)
+	textBody, textContentType := upload(nil, "notes.txt", "Meeting Notes\n\nWe talked about things.")
+	missingBody, missingContentType := upload(map[string]string{"url": "https://example.com"}, "", "")
+	invalidURLBody, invalidURLContentType := upload(map[string]string{"url": "file:///etc/passwd"}, "post.html", article)
+	duplicateBody, duplicateContentType := upload(nil, "post.html", `<html><head><title>Already Saved</title><link rel="canonical" href="https://www.example.com/"></head><body><p>Text</p></body></html>`)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/parse_html",
+			Body:            unauthenticatedBody,
+			Headers:         map[string]string{"Content-Type": unauthenticatedContentType},
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Uploaded page with its URL",
+			Method: http.MethodPost,
+			URL:    "/lynx/parse_html",
+			Body:   htmlBody,
+			Headers: map[string]string{
+				"Content-Type":  htmlContentType,
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"id":`},
+			TestAppFactory:  setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				link, err := app.FindFirstRecordByData("links", "cleaned_url", "https://example.com/members/post")
+				if err != nil {
+					t.Fatal("Failed to find the uploaded link")
+				}
+				if link.GetString("title") != "Members Only" {
+					t.Errorf("Expected title Members Only, got %q", link.GetString("title"))
+				}
+				if link.GetString("user") != "u3ozd82edmlybb1" {
+					t.Errorf("Expected link to belong to the uploading user, got %q", link.GetString("user"))
+				}
+				if link.GetString("original_url") != "https://example.com/members/post?utm_source=email" {
+					t.Errorf("Unexpected original_url %q", link.GetString("original_url"))
+				}
+			},
+		},
+		{
+			Name:   "Uploaded text with API key",
+			Method: http.MethodPost,
+			URL:    "/lynx/parse_html",
+			Body:   textBody,
+			Headers: map[string]string{
+				"Content-Type": textContentType,
+				"X-API-KEY":    "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"id":`},
+			TestAppFactory:  setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				link, err := app.FindFirstRecordByData("links", "title", "Meeting Notes")
+				if err != nil {
+					t.Fatal("Failed to find the uploaded link")
+				}
+				if link.GetString("original_url") != "" {
+					t.Errorf("Expected no original_url, got %q", link.GetString("original_url"))
+				}
+			},
+		},
+		{
+			Name:   "Missing file",
+			Method: http.MethodPost,
+			URL:    "/lynx/parse_html",
+			Body:   missingBody,
+			Headers: map[string]string{
+				"Content-Type":  missingContentType,
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Missing 'file' upload."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Invalid URL",
+			Method: http.MethodPost,
+			URL:    "/lynx/parse_html",
+			Body:   invalidURLBody,
+			Headers: map[string]string{
+				"Content-Type":  invalidURLContentType,
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Invalid 'url' parameter, expected an http or https URL."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Duplicate page",
+			Method: http.MethodPost,
+			URL:    "/lynx/parse_html",
+			Body:   duplicateBody,
+			Headers: map[string]string{
+				"Content-Type":  duplicateContentType,
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  409,
+			ExpectedContent: []string{`"id":"8n3iq8dt6vwi4ph"`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleCreateNote(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/note",
+			Body:            strings.NewReader("title=Idea&body=Something"),
+			Headers:         map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Missing body",
+			Method: http.MethodPost,
+			URL:    "/lynx/note",
+			Body:   strings.NewReader("title=Idea&body=+"),
+			Headers: map[string]string{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Authorization": generateRecordToken("users", "test2@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Missing 'body' parameter."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Note with API key",
+			Method: http.MethodPost,
+			URL:    "/lynx/note",
+			Body:   strings.NewReader(url.Values{"title": {"Idea"}, "body": {"A *thought* worth keeping."}}.Encode()),
+			Headers: map[string]string{
+				"Content-Type": "application/x-www-form-urlencoded",
+				"X-API-KEY":    "this_is_a_test_api_key",
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"id":`},
+			TestAppFactory:  setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				link, err := app.FindFirstRecordByData("links", "title", "Idea")
+				if err != nil {
+					t.Fatal("Failed to find the note")
+				}
+				if link.GetString("user") != "h4oofx0tx2eupnq" {
+					t.Errorf("Expected note to belong to the API key's user, got %q", link.GetString("user"))
+				}
+				if link.GetString("kind") != "note" {
+					t.Errorf("Expected kind note, got %q", link.GetString("kind"))
+				}
+				if link.GetString("article_html") != "<p>A <em>thought</em> worth keeping.</p>\n" {
+					t.Errorf("Unexpected article_html %q", link.GetString("article_html"))
+				}
+				if link.GetString("raw_text_content") != "A thought worth keeping." {
+					t.Errorf("Unexpected raw_text_content %q", link.GetString("raw_text_content"))
+				}
+				if link.GetString("read_time_display") == "" {
+					t.Error("Expected read time to be set")
+				}
+				if link.GetString("original_url") != "" {
+					t.Errorf("Expected no original_url, got %q", link.GetString("original_url"))
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestHandleGenerateEmailAddress(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "Unauthenticated request",
+			Method:          http.MethodPost,
+			URL:             "/lynx/generate_email_address",
+			Body:            strings.NewReader(url.Values{"name": {"Newsletters"}}.Encode()),
+			Headers:         map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"message":"The request requires valid record authorization token."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Missing name",
+			Method: http.MethodPost,
+			URL:    "/lynx/generate_email_address",
+			Body:   strings.NewReader(url.Values{"name": {" "}}.Encode()),
+			Headers: map[string]string{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"'name' parameter is required."`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Generate email address",
+			Method: http.MethodPost,
+			URL:    "/lynx/generate_email_address",
+			Body:   strings.NewReader(url.Values{"name": {"Newsletters"}, "save_links": {"true"}}.Encode()),
+			Headers: map[string]string{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"name":"Newsletters"`, `"save_links":true`, `@lynx.local"`},
+			ExpectedEvents: map[string]int{
+				"OnRecordCreate":             1,
+				"OnRecordAfterCreateSuccess": 1,
+			},
+			TestAppFactory: setupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				var result map[string]interface{}
+				if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+					t.Fatal(err)
+				}
+
+				record, err := app.FindRecordById("email_addresses", result["id"].(string))
+				if err != nil {
+					t.Fatal("Failed to find the created email address in the database")
+				}
+				if record.GetString("user") != "h4oofx0tx2eupnq" {
+					t.Errorf("Expected email address to belong to the user, got %q", record.GetString("user"))
+				}
+				if result["address"] != record.GetString("token")+"@lynx.local" {
+					t.Errorf("Expected address to use the token, got %v", result["address"])
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestEmailAddressEnrich(t *testing.T) {
+	setupTestApp := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		collection, err := testApp.FindCollectionByNameOrId("email_addresses")
+		if err != nil {
+			t.Fatal(err)
+		}
+		record := core.NewRecord(collection)
+		record.Set("id", "emailaddress001")
+		record.Set("user", "h4oofx0tx2eupnq")
+		record.Set("name", "Newsletters")
+		record.Set("token", "abc123")
+		if err := testApp.Save(record); err != nil {
+			t.Fatal(err)
+		}
+
+		InitializePocketbase(testApp)
+
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "Address is returned with the record",
+			Method: http.MethodGet,
+			URL:    "/api/collections/email_addresses/records/emailaddress001",
+			Headers: map[string]string{
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"address":"abc123@lynx.local"`},
+			TestAppFactory:  setupTestApp,
+		},
+		{
+			Name:   "Tokens can't be changed",
+			Method: http.MethodPatch,
+			URL:    "/api/collections/email_addresses/records/emailaddress001",
+			Body:   strings.NewReader(`{"token":"chosen"}`),
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"Authorization": generateRecordToken("users", "test@example.com"),
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"status":404`},
+			TestAppFactory:  setupTestApp,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
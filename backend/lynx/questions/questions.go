@@ -0,0 +1,146 @@
+package questions
+
+// Answers questions about a saved article using the user's configured
+// LLM provider, optionally keeping the question and answer in the
+// `link_questions` collection.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/llm"
+)
+
+// Overridden in tests to point at a mock server
+var newProvider = llm.FromSettings
+
+// Questions include the whole article, so cap it at roughly 50k tokens
+// to stay within the context window of most models.
+const maxArticleChars = 200_000
+
+const answerMaxTokens = 1000
+
+const systemPrompt = `You are a helpful assistant answering questions about an article the user has saved.
+Answer using only the information in the article. If the article doesn't contain the answer, say so rather than guessing.`
+
+var (
+	ErrEmptyQuestion    = errors.New("question is required")
+	ErrNoContent        = errors.New("link has no text content to ask about")
+	ErrSettingsNotFound = errors.New("user_settings not found for user")
+	ErrMissingModel     = errors.New("model not set for user")
+)
+
+type Answer struct {
+	// ID of the saved link_questions record, empty if not saved
+	ID        string    `json:"id,omitempty"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Model     string    `json:"model"`
+	Truncated bool      `json:"truncated"`
+	Usage     llm.Usage `json:"usage"`
+}
+
+// Ask answers a question about the link's text. If model is empty the
+// user's summarize_model setting is used. With save set, the question
+// and answer are stored in link_questions.
+func Ask(app core.App, linkID string, question string, model string, save bool) (*Answer, error) {
+	logger := app.Logger().With("action", "askQuestion", "linkID", linkID)
+
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, ErrEmptyQuestion
+	}
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find link: %w", err)
+	}
+
+	text := link.GetString("raw_text_content")
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrNoContent
+	}
+
+	userSettings, err := app.FindFirstRecordByFilter("user_settings", "user = {:user}",
+		dbx.Params{
+			"user": link.GetString("user"),
+		})
+	if err != nil {
+		return nil, ErrSettingsNotFound
+	}
+
+	if model == "" {
+		model = userSettings.GetString("summarize_model")
+	}
+	if model == "" {
+		return nil, ErrMissingModel
+	}
+
+	provider, err := newProvider(userSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &Answer{Question: question, Model: model}
+	if utf8.RuneCountInString(text) > maxArticleChars {
+		text = string([]rune(text)[:maxArticleChars])
+		answer.Truncated = true
+	}
+
+	resp, err := provider.Complete(llm.Request{
+		Model:  model,
+		System: systemPrompt,
+		Messages: []llm.Message{
+			{
+				Role:    "user",
+				Content: formatPrompt(link.GetString("title"), text, question, answer.Truncated),
+			},
+		},
+		MaxTokens: answerMaxTokens,
+	})
+	if err != nil {
+		logger.Error("Failed to answer question", "error", err)
+		return nil, err
+	}
+	answer.Answer = resp.Content
+	answer.Usage = resp.Usage
+
+	if save {
+		collection, err := app.FindCollectionByNameOrId("link_questions")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find link_questions collection: %w", err)
+		}
+		record := core.NewRecord(collection)
+		record.Set("user", link.GetString("user"))
+		record.Set("link", link.Id)
+		record.Set("question", question)
+		record.Set("answer", answer.Answer)
+		record.Set("model", model)
+		if err := app.Save(record); err != nil {
+			return nil, fmt.Errorf("failed to save question: %w", err)
+		}
+		answer.ID = record.Id
+	}
+
+	logger.Info("Answered question about link", "model", model, "saved", save)
+	return answer, nil
+}
+
+func formatPrompt(title string, text string, question string, truncated bool) string {
+	var prompt strings.Builder
+	if title != "" {
+		fmt.Fprintf(&prompt, "Title: %s\n\n", title)
+	}
+	prompt.WriteString("Article:\n")
+	prompt.WriteString(text)
+	if truncated {
+		prompt.WriteString("\n\n(The article was too long and has been cut off here.)")
+	}
+	fmt.Fprintf(&prompt, "\n\nQuestion: %s", question)
+	return prompt.String()
+}
@@ -0,0 +1,147 @@
+package questions
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/llm"
+)
+
+const testLinkID = "8n3iq8dt6vwi4ph"
+
+type fakeProvider struct {
+	requests []llm.Request
+	err      error
+}
+
+func (p *fakeProvider) Complete(req llm.Request) (*llm.Response, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &llm.Response{
+		Content: "The author recommends SQLite.",
+		Usage:   llm.Usage{PromptTokens: 300, CompletionTokens: 12},
+	}, nil
+}
+
+func (p *fakeProvider) SupportsSchema(model string) (bool, error) {
+	return true, nil
+}
+
+func setupTestApp(t *testing.T, provider llm.Provider) (*tests.TestApp, *core.Record) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+
+	originalNewProvider := newProvider
+	newProvider = func(settings *core.Record) (llm.Provider, error) {
+		return provider, nil
+	}
+	t.Cleanup(func() {
+		newProvider = originalNewProvider
+	})
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("title", "Choosing a Database")
+	link.Set("raw_text_content", "For most small projects, SQLite is all you need.")
+	require.NoError(t, testApp.Save(link))
+
+	return testApp, link
+}
+
+func createUserSettings(t *testing.T, app core.App, userID string) {
+	collection, err := app.FindCollectionByNameOrId("user_settings")
+	require.NoError(t, err)
+
+	settings := core.NewRecord(collection)
+	settings.Set("user", userID)
+	settings.Set("summarize_model", "openai/gpt-4o-mini")
+	require.NoError(t, app.Save(settings))
+}
+
+func TestAsk(t *testing.T) {
+	provider := &fakeProvider{}
+	testApp, link := setupTestApp(t, provider)
+	createUserSettings(t, testApp, link.GetString("user"))
+
+	answer, err := Ask(testApp, testLinkID, "  Which database should I use? ", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, &Answer{
+		Question: "Which database should I use?",
+		Answer:   "The author recommends SQLite.",
+		Model:    "openai/gpt-4o-mini",
+		Usage:    llm.Usage{PromptTokens: 300, CompletionTokens: 12},
+	}, answer)
+
+	require.Len(t, provider.requests, 1)
+	req := provider.requests[0]
+	assert.Equal(t, systemPrompt, req.System)
+	assert.Equal(t, "Title: Choosing a Database\n\nArticle:\nFor most small projects, SQLite is all you need.\n\nQuestion: Which database should I use?", req.Messages[0].Content)
+
+	count, err := testApp.CountRecords("link_questions")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestAskAndSave(t *testing.T) {
+	provider := &fakeProvider{}
+	testApp, link := setupTestApp(t, provider)
+	createUserSettings(t, testApp, link.GetString("user"))
+
+	answer, err := Ask(testApp, testLinkID, "Which database should I use?", "anthropic/claude-3-haiku", true)
+	require.NoError(t, err)
+	require.NotEmpty(t, answer.ID)
+	assert.Equal(t, "anthropic/claude-3-haiku", provider.requests[0].Model)
+
+	record, err := testApp.FindRecordById("link_questions", answer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, link.GetString("user"), record.GetString("user"))
+	assert.Equal(t, testLinkID, record.GetString("link"))
+	assert.Equal(t, "Which database should I use?", record.GetString("question"))
+	assert.Equal(t, "The author recommends SQLite.", record.GetString("answer"))
+	assert.Equal(t, "anthropic/claude-3-haiku", record.GetString("model"))
+}
+
+func TestAskTruncatesLongArticles(t *testing.T) {
+	provider := &fakeProvider{}
+	testApp, link := setupTestApp(t, provider)
+	createUserSettings(t, testApp, link.GetString("user"))
+
+	link.Set("raw_text_content", strings.Repeat("a", maxArticleChars+100))
+	require.NoError(t, testApp.Save(link))
+
+	answer, err := Ask(testApp, testLinkID, "What is this?", "", false)
+	require.NoError(t, err)
+	assert.True(t, answer.Truncated)
+	assert.Contains(t, provider.requests[0].Messages[0].Content, "has been cut off")
+	assert.NotContains(t, provider.requests[0].Messages[0].Content, strings.Repeat("a", maxArticleChars+1))
+}
+
+func TestAskErrors(t *testing.T) {
+	provider := &fakeProvider{}
+	testApp, link := setupTestApp(t, provider)
+
+	_, err := Ask(testApp, testLinkID, "   ", "", false)
+	assert.ErrorIs(t, err, ErrEmptyQuestion)
+
+	_, err = Ask(testApp, testLinkID, "Why?", "", false)
+	assert.ErrorIs(t, err, ErrSettingsNotFound)
+
+	createUserSettings(t, testApp, link.GetString("user"))
+	provider.err = errors.New("API request failed with status 500: oops")
+	_, err = Ask(testApp, testLinkID, "Why?", "", true)
+	assert.EqualError(t, err, "API request failed with status 500: oops")
+
+	link.Set("raw_text_content", "")
+	require.NoError(t, testApp.Save(link))
+	_, err = Ask(testApp, testLinkID, "Why?", "", false)
+	assert.ErrorIs(t, err, ErrNoContent)
+}
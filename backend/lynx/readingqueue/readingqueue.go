@@ -0,0 +1,211 @@
+package readingqueue
+
+// Suggests what to read next from a user's unread links. Each link is
+// scored on how quick it is to read, how recently it was saved,
+// whether it's starred or came from a feed, and the weights given to
+// its tags. Given a time budget, the best scoring links that fit in
+// it are returned.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/searchquery"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 50
+)
+
+// Reads longer than this get no credit for being quick
+const longRead = 60 * time.Minute
+
+// How long it takes for the credit a link gets for being new to halve
+const ageHalfLife = 14 * 24 * time.Hour
+
+type Weights struct {
+	// Favours shorter reads
+	Length float64
+	// Favours recently saved links
+	Age     float64
+	Starred float64
+	// Applied to links that were created from a feed rather than
+	// saved by hand
+	Feed float64
+	// Weight of each tag by name or slug. A link gets the sum of the
+	// weights of its tags.
+	Tags map[string]float64
+}
+
+var DefaultWeights = Weights{
+	Length:  1,
+	Age:     1,
+	Starred: 2,
+	Feed:    -0.5,
+}
+
+type Options struct {
+	// Only return links that fit in this many minutes in total. Zero
+	// means there is no budget.
+	MaxMinutes int
+	Limit      int
+	Weights    Weights
+}
+
+// Scores shows how much each signal contributed to a link's score,
+// after weighting.
+type Scores struct {
+	Length  float64 `json:"length"`
+	Age     float64 `json:"age"`
+	Starred float64 `json:"starred"`
+	Feed    float64 `json:"feed"`
+	Tags    float64 `json:"tags"`
+}
+
+type Item struct {
+	ID              string  `json:"id"`
+	Title           string  `json:"title"`
+	URL             string  `json:"url"`
+	Hostname        string  `json:"hostname"`
+	ReadTimeSeconds int     `json:"read_time_seconds"`
+	Score           float64 `json:"score"`
+	Scores          Scores  `json:"scores"`
+}
+
+type Queue struct {
+	MaxMinutes   int    `json:"max_minutes"`
+	TotalSeconds int    `json:"total_seconds"`
+	Items        []Item `json:"items"`
+}
+
+// Build ranks the user's unread links and returns the best of them,
+// highest score first. With a time budget, links are taken in order
+// of score and any that would go over the budget are skipped, as are
+// links without a known reading time.
+func Build(app core.App, userID string, opts Options, now time.Time) (*Queue, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+	opts.Limit = min(opts.Limit, MaxLimit)
+
+	tagWeights, err := resolveTagWeights(searchquery.UserTags(app, userID), opts.Weights.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := app.FindAllRecords(
+		"links",
+		dbx.HashExp{"user": userID},
+		dbx.HashExp{"last_viewed_at": ""},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load links: %w", err)
+	}
+
+	items := make([]Item, len(links))
+	for i, link := range links {
+		scores := score(link, opts.Weights, tagWeights, now)
+		items[i] = Item{
+			ID:              link.Id,
+			Title:           link.GetString("title"),
+			URL:             link.GetString("cleaned_url"),
+			Hostname:        link.GetString("hostname"),
+			ReadTimeSeconds: link.GetInt("read_time_seconds"),
+			Score:           scores.Length + scores.Age + scores.Starred + scores.Feed + scores.Tags,
+			Scores:          scores,
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+
+	queue := &Queue{MaxMinutes: opts.MaxMinutes, Items: []Item{}}
+	budget := opts.MaxMinutes * 60
+	for _, item := range items {
+		if len(queue.Items) >= opts.Limit {
+			break
+		}
+		if budget > 0 {
+			if item.ReadTimeSeconds <= 0 || queue.TotalSeconds+item.ReadTimeSeconds > budget {
+				continue
+			}
+		}
+		queue.Items = append(queue.Items, item)
+		queue.TotalSeconds += item.ReadTimeSeconds
+	}
+	return queue, nil
+}
+
+func score(link *core.Record, weights Weights, tagWeights map[string]float64, now time.Time) Scores {
+	var scores Scores
+
+	if seconds := link.GetInt("read_time_seconds"); seconds > 0 {
+		length := time.Duration(seconds) * time.Second
+		scores.Length = weights.Length * max(1-float64(length)/float64(longRead), 0)
+	}
+
+	if added := link.GetDateTime("added_to_library"); !added.IsZero() {
+		age := max(now.Sub(added.Time()), 0)
+		scores.Age = weights.Age * math.Pow(0.5, float64(age)/float64(ageHalfLife))
+	}
+
+	if !link.GetDateTime("starred_at").IsZero() {
+		scores.Starred = weights.Starred
+	}
+
+	if link.GetString("created_from_feed") != "" {
+		scores.Feed = weights.Feed
+	}
+
+	for _, tagID := range link.GetStringSlice("tags") {
+		scores.Tags += tagWeights[tagID]
+	}
+
+	return scores
+}
+
+// resolveTagWeights turns weights keyed by tag name or slug into
+// weights keyed by tag ID.
+func resolveTagWeights(resolveTags searchquery.TagResolver, weights map[string]float64) (map[string]float64, error) {
+	byID := make(map[string]float64)
+	for name, weight := range weights {
+		tagIDs, err := resolveTags(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, tagID := range tagIDs {
+			byID[tagID] = weight
+		}
+	}
+	return byID, nil
+}
+
+// ParseTagWeights parses tag weights written as a comma separated
+// list of tag:weight pairs, e.g. "golang:2,politics:-1".
+func ParseTagWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		separator := strings.LastIndex(pair, ":")
+		if separator <= 0 {
+			return nil, fmt.Errorf("invalid tag weight %q, expected tag:weight", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(pair[separator+1:]), 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("invalid weight in %q, expected a number", pair)
+		}
+		weights[strings.TrimSpace(pair[:separator])] = weight
+	}
+	return weights, nil
+}
@@ -0,0 +1,171 @@
+package readingqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// setupTestApp adds a few unread links alongside the existing
+// example.com link, which is two minutes long and was saved long ago.
+func setupTestApp(t *testing.T) (*tests.TestApp, map[string]string) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+
+	tagsCollection, err := testApp.FindCollectionByNameOrId("tags")
+	require.NoError(t, err)
+	tag := core.NewRecord(tagsCollection)
+	tag.Set("user", testUserID)
+	tag.Set("name", "Go Lang")
+	tag.Set("slug", "go-lang")
+	require.NoError(t, testApp.Save(tag))
+
+	example, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	example.Set("read_time_seconds", 120)
+	example.Set("added_to_library", "2024-01-01 00:00:00.000Z")
+	require.NoError(t, testApp.Save(example))
+
+	linksCollection, err := testApp.FindCollectionByNameOrId("links")
+	require.NoError(t, err)
+	ids := map[string]string{"example": testLinkID}
+	for name, values := range map[string]map[string]any{
+		"quick": {
+			"read_time_seconds": 180,
+			"added_to_library":  "2025-02-28 12:00:00.000Z",
+		},
+		"long": {
+			"read_time_seconds": 1800,
+			"added_to_library":  "2025-02-28 12:00:00.000Z",
+			"starred_at":        "2025-02-28 12:00:00.000Z",
+		},
+		"tagged": {
+			"read_time_seconds": 600,
+			"added_to_library":  "2025-01-01 00:00:00.000Z",
+			"tags":              []string{tag.Id},
+		},
+		"read": {
+			"read_time_seconds": 60,
+			"added_to_library":  "2025-02-28 12:00:00.000Z",
+			"last_viewed_at":    "2025-03-01 00:00:00.000Z",
+		},
+	} {
+		link := core.NewRecord(linksCollection)
+		link.Set("user", testUserID)
+		link.Set("title", name)
+		link.Set("original_url", "https://example.org/"+name)
+		link.Set("cleaned_url", "https://example.org/"+name)
+		for key, value := range values {
+			link.Set(key, value)
+		}
+		require.NoError(t, testApp.Save(link))
+		ids[name] = link.Id
+	}
+
+	return testApp, ids
+}
+
+func itemNames(ids map[string]string, queue *Queue) []string {
+	names := map[string]string{}
+	for name, id := range ids {
+		names[id] = name
+	}
+	result := []string{}
+	for _, item := range queue.Items {
+		result = append(result, names[item.ID])
+	}
+	return result
+}
+
+func TestBuild(t *testing.T) {
+	testApp, ids := setupTestApp(t)
+
+	queue, err := Build(testApp, testUserID, Options{Weights: DefaultWeights}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"long", "quick", "example", "tagged"}, itemNames(ids, queue))
+	assert.Equal(t, 180+1800+120+600, queue.TotalSeconds)
+
+	long := queue.Items[0]
+	assert.Equal(t, 1800, long.ReadTimeSeconds)
+	assert.InDelta(t, 0.5, long.Scores.Length, 0.001)
+	assert.Equal(t, 2.0, long.Scores.Starred)
+	assert.InDelta(t, long.Scores.Length+long.Scores.Age+long.Scores.Starred, long.Score, 0.001)
+
+	queue, err = Build(testApp, testUserID, Options{Weights: DefaultWeights, Limit: 2}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"long", "quick"}, itemNames(ids, queue))
+}
+
+func TestBuildWithBudget(t *testing.T) {
+	testApp, ids := setupTestApp(t)
+
+	// The starred link is too long, so the best links that fit are
+	// picked instead
+	queue, err := Build(testApp, testUserID, Options{Weights: DefaultWeights, MaxMinutes: 15}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"quick", "example", "tagged"}, itemNames(ids, queue))
+	assert.Equal(t, 15, queue.MaxMinutes)
+	assert.Equal(t, 180+120+600, queue.TotalSeconds)
+
+	queue, err = Build(testApp, testUserID, Options{Weights: DefaultWeights, MaxMinutes: 1}, now)
+	require.NoError(t, err)
+	assert.Empty(t, queue.Items)
+}
+
+func TestBuildWithWeights(t *testing.T) {
+	testApp, ids := setupTestApp(t)
+
+	weights := DefaultWeights
+	weights.Starred = 0
+	weights.Tags = map[string]float64{"go lang": 5}
+	queue, err := Build(testApp, testUserID, Options{Weights: weights}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tagged", "quick", "long", "example"}, itemNames(ids, queue))
+	assert.Equal(t, 5.0, queue.Items[0].Scores.Tags)
+
+	// Links from feeds are penalised by default
+	feedsCollection, err := testApp.FindCollectionByNameOrId("feeds")
+	require.NoError(t, err)
+	feed := core.NewRecord(feedsCollection)
+	feed.Set("user", testUserID)
+	feed.Set("name", "Example feed")
+	feed.Set("feed_url", "https://example.org/feed.xml")
+	require.NoError(t, testApp.Save(feed))
+
+	quick, err := testApp.FindRecordById("links", ids["quick"])
+	require.NoError(t, err)
+	quick.Set("created_from_feed", feed.Id)
+	require.NoError(t, testApp.Save(quick))
+
+	queue, err = Build(testApp, testUserID, Options{Weights: weights}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tagged", "long", "quick", "example"}, itemNames(ids, queue))
+	assert.Equal(t, -0.5, queue.Items[2].Scores.Feed)
+}
+
+func TestParseTagWeights(t *testing.T) {
+	weights, err := ParseTagWeights("golang:2, machine learning:-1.5,,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"golang": 2, "machine learning": -1.5}, weights)
+
+	weights, err = ParseTagWeights("")
+	require.NoError(t, err)
+	assert.Empty(t, weights)
+
+	_, err = ParseTagWeights("golang")
+	assert.EqualError(t, err, `invalid tag weight "golang", expected tag:weight`)
+	_, err = ParseTagWeights("golang:lots")
+	assert.EqualError(t, err, `invalid weight in "golang:lots", expected a number`)
+}
@@ -0,0 +1,175 @@
+package related
+
+// Finds other links in a user's library on the same topic as a given
+// link, for showing "more like this" in the reader view. Links are
+// scored on shared tags, a shared site or author, and how similar
+// their text is. Text similarity uses the links' embeddings when the
+// link has one, and falls back to TF-IDF over the user's library.
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/embeddings"
+)
+
+const (
+	DefaultLimit = 5
+	MaxLimit     = 20
+)
+
+// How much each signal contributes to a link's score, which is
+// between 0 and 1.
+const (
+	textWeight     = 0.5
+	tagsWeight     = 0.3
+	hostnameWeight = 0.1
+	authorWeight   = 0.1
+)
+
+const (
+	SimilarityEmbeddings = "embeddings"
+	SimilarityTFIDF      = "tfidf"
+)
+
+type Reasons struct {
+	SharedTags     []string `json:"shared_tags"`
+	SameHostname   bool     `json:"same_hostname"`
+	SameAuthor     bool     `json:"same_author"`
+	TextSimilarity float64  `json:"text_similarity"`
+}
+
+type Result struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	URL      string  `json:"url"`
+	Hostname string  `json:"hostname"`
+	Author   string  `json:"author"`
+	Score    float64 `json:"score"`
+	Reasons  Reasons `json:"reasons"`
+}
+
+type Results struct {
+	// How text similarity was measured, "embeddings" or "tfidf"
+	Similarity string   `json:"similarity"`
+	Items      []Result `json:"items"`
+}
+
+// Find returns up to limit of the user's other links that are most
+// similar to the link, best match first. Links with nothing in common
+// are never returned.
+func Find(app core.App, linkID string, limit int) (*Results, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find link: %w", err)
+	}
+
+	candidates, err := app.FindRecordsByFilter(
+		"links",
+		"user = {:user} && id != {:id}",
+		"",
+		0,
+		0,
+		dbx.Params{"user": link.GetString("user"), "id": link.Id},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load links: %w", err)
+	}
+
+	results := &Results{Items: []Result{}}
+	textSimilarities, err := embeddings.LinkSimilarities(app, link.Id)
+	switch {
+	case err == nil:
+		results.Similarity = SimilarityEmbeddings
+	case errors.Is(err, embeddings.ErrNoEmbedding):
+		results.Similarity = SimilarityTFIDF
+		textSimilarities = tfidfSimilarities(link, candidates)
+	default:
+		return nil, err
+	}
+
+	tags := link.GetStringSlice("tags")
+	hostname := strings.ToLower(link.GetString("hostname"))
+	author := strings.ToLower(strings.TrimSpace(link.GetString("author")))
+
+	for _, candidate := range candidates {
+		reasons := Reasons{
+			SharedTags:     sharedTags(tags, candidate.GetStringSlice("tags")),
+			SameHostname:   hostname != "" && hostname == strings.ToLower(candidate.GetString("hostname")),
+			SameAuthor:     author != "" && author == strings.ToLower(strings.TrimSpace(candidate.GetString("author"))),
+			TextSimilarity: max(textSimilarities[candidate.Id], 0),
+		}
+
+		score := textWeight * reasons.TextSimilarity
+		if union := len(tags) + len(candidate.GetStringSlice("tags")) - len(reasons.SharedTags); union > 0 {
+			score += tagsWeight * float64(len(reasons.SharedTags)) / float64(union)
+		}
+		if reasons.SameHostname {
+			score += hostnameWeight
+		}
+		if reasons.SameAuthor {
+			score += authorWeight
+		}
+		if score <= 0 {
+			continue
+		}
+
+		results.Items = append(results.Items, Result{
+			ID:       candidate.Id,
+			Title:    candidate.GetString("title"),
+			URL:      candidate.GetString("cleaned_url"),
+			Hostname: candidate.GetString("hostname"),
+			Author:   candidate.GetString("author"),
+			Score:    score,
+			Reasons:  reasons,
+		})
+	}
+
+	sort.SliceStable(results.Items, func(i, j int) bool {
+		return results.Items[i].Score > results.Items[j].Score
+	})
+	if len(results.Items) > limit {
+		results.Items = results.Items[:limit]
+	}
+	return results, nil
+}
+
+func tfidfSimilarities(link *core.Record, candidates []*core.Record) map[string]float64 {
+	documents := make(map[string][]string, len(candidates)+1)
+	documents[link.Id] = tokenize(link.GetString("title") + "\n" + link.GetString("raw_text_content"))
+	for _, candidate := range candidates {
+		documents[candidate.Id] = tokenize(candidate.GetString("title") + "\n" + candidate.GetString("raw_text_content"))
+	}
+
+	vectors := tfidfVectors(documents)
+	similarities := make(map[string]float64, len(candidates))
+	for _, candidate := range candidates {
+		similarities[candidate.Id] = vectors[link.Id].cosine(vectors[candidate.Id])
+	}
+	return similarities
+}
+
+func sharedTags(a []string, b []string) []string {
+	inA := make(map[string]bool, len(a))
+	for _, tag := range a {
+		inA[tag] = true
+	}
+	shared := []string{}
+	for _, tag := range b {
+		if inA[tag] {
+			shared = append(shared, tag)
+			delete(inA, tag)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,135 @@
+package related
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+func setupTestApp(t *testing.T) *tests.TestApp {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+	return testApp
+}
+
+func createTag(t *testing.T, app core.App, name string) string {
+	collection, err := app.FindCollectionByNameOrId("tags")
+	require.NoError(t, err)
+	tag := core.NewRecord(collection)
+	tag.Set("user", testUserID)
+	tag.Set("name", name)
+	tag.Set("slug", name)
+	require.NoError(t, app.Save(tag))
+	return tag.Id
+}
+
+func createLink(t *testing.T, app core.App, userID string, values map[string]any) *core.Record {
+	collection, err := app.FindCollectionByNameOrId("links")
+	require.NoError(t, err)
+	link := core.NewRecord(collection)
+	link.Set("user", userID)
+	link.Set("original_url", "https://example.com/article")
+	link.Set("cleaned_url", "https://example.com/article")
+	link.Set("added_to_library", "2024-08-15 00:00:00.000Z")
+	for key, value := range values {
+		link.Set(key, value)
+	}
+	require.NoError(t, app.Save(link))
+	return link
+}
+
+func saveEmbedding(t *testing.T, app core.App, linkID string, vector []float32) {
+	collection, err := app.FindCollectionByNameOrId("embeddings")
+	require.NoError(t, err)
+	record := core.NewRecord(collection)
+	record.Set("user", testUserID)
+	record.Set("link", linkID)
+	record.Set("model", "openai/text-embedding-3-small")
+	record.Set("vector", vector)
+	require.NoError(t, app.Save(record))
+}
+
+func TestFind(t *testing.T) {
+	testApp := setupTestApp(t)
+	golang := createTag(t, testApp, "golang")
+	databases := createTag(t, testApp, "databases")
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("title", "Using SQLite from Go")
+	link.Set("raw_text_content", "SQLite works well from Go programs. The database driver supports transactions.")
+	link.Set("hostname", "go.dev")
+	link.Set("author", "Gopher")
+	link.Set("tags", []string{golang, databases})
+	require.NoError(t, testApp.Save(link))
+
+	sameTopic := createLink(t, testApp, testUserID, map[string]any{
+		"title":            "SQLite transactions explained",
+		"raw_text_content": "Transactions in SQLite keep the database consistent.",
+		"tags":             []string{databases},
+	})
+	sameSite := createLink(t, testApp, testUserID, map[string]any{
+		"title":            "Release notes",
+		"raw_text_content": "Improvements to generics.",
+		"hostname":         "GO.dev",
+		"author":           " gopher ",
+	})
+	createLink(t, testApp, testUserID, map[string]any{
+		"title":            "Sourdough bread",
+		"raw_text_content": "Flour, water and salt.",
+		"hostname":         "bread.example",
+	})
+	createLink(t, testApp, "h4oofx0tx2eupnq", map[string]any{
+		"title":            "Another user's SQLite article",
+		"raw_text_content": "SQLite database transactions from Go programs.",
+		"hostname":         "go.dev",
+	})
+
+	results, err := Find(testApp, testLinkID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, SimilarityTFIDF, results.Similarity)
+	require.Len(t, results.Items, 2)
+
+	assert.Equal(t, sameTopic.Id, results.Items[0].ID)
+	assert.Equal(t, []string{databases}, results.Items[0].Reasons.SharedTags)
+	assert.False(t, results.Items[0].Reasons.SameHostname)
+	assert.Greater(t, results.Items[0].Reasons.TextSimilarity, 0.0)
+
+	assert.Equal(t, sameSite.Id, results.Items[1].ID)
+	assert.Empty(t, results.Items[1].Reasons.SharedTags)
+	assert.True(t, results.Items[1].Reasons.SameHostname)
+	assert.True(t, results.Items[1].Reasons.SameAuthor)
+	assert.Zero(t, results.Items[1].Reasons.TextSimilarity)
+	assert.InDelta(t, hostnameWeight+authorWeight, results.Items[1].Score, 1e-9)
+
+	results, err = Find(testApp, testLinkID, 1)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, sameTopic.Id, results.Items[0].ID)
+}
+
+func TestFindUsesEmbeddings(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	nearby := createLink(t, testApp, testUserID, map[string]any{"title": "Close"})
+	far := createLink(t, testApp, testUserID, map[string]any{"title": "Far"})
+	saveEmbedding(t, testApp, testLinkID, []float32{1, 0})
+	saveEmbedding(t, testApp, nearby.Id, []float32{1, 0.1})
+	saveEmbedding(t, testApp, far.Id, []float32{-1, 0})
+
+	results, err := Find(testApp, testLinkID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, SimilarityEmbeddings, results.Similarity)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, nearby.Id, results.Items[0].ID)
+	assert.InDelta(t, 0.995, results.Items[0].Reasons.TextSimilarity, 0.001)
+}
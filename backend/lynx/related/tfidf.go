@@ -0,0 +1,103 @@
+package related
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Only the start of each article is compared, which is plenty to tell
+// what it's about and keeps the work per request bounded.
+const maxTextChars = 20_000
+
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		about after all also and any are because been before being but can could did does
+		for from had has have her here his how into its just like more most not now off
+		one only other our out over said she should some such than that the their them
+		then there these they this those through too under very was way were what when
+		where which while who why will with would you your`) {
+		stopWords[word] = true
+	}
+}
+
+// tokenize splits text into lowercase words, dropping short words and
+// common English stop words.
+func tokenize(text string) []string {
+	if utf8.RuneCountInString(text) > maxTextChars {
+		text = string([]rune(text)[:maxTextChars])
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < 3 || stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+type vector map[string]float64
+
+// tfidfVectors returns a TF-IDF weighted vector for each document,
+// using the documents themselves as the corpus.
+func tfidfVectors(documents map[string][]string) map[string]vector {
+	documentFrequency := map[string]int{}
+	termCounts := make(map[string]map[string]int, len(documents))
+	for id, tokens := range documents {
+		counts := map[string]int{}
+		for _, token := range tokens {
+			counts[token]++
+		}
+		for term := range counts {
+			documentFrequency[term]++
+		}
+		termCounts[id] = counts
+	}
+
+	total := float64(len(documents))
+	vectors := make(map[string]vector, len(documents))
+	for id, counts := range termCounts {
+		v := make(vector, len(counts))
+		for term, count := range counts {
+			// Smoothed so that terms shared by every document still
+			// count for something in small libraries
+			idf := math.Log((1+total)/(1+float64(documentFrequency[term]))) + 1
+			v[term] = (1 + math.Log(float64(count))) * idf
+		}
+		vectors[id] = v
+	}
+	return vectors
+}
+
+func (a vector) cosine(b vector) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (a.norm() * b.norm())
+}
+
+func (a vector) norm() float64 {
+	var sum float64
+	for _, weight := range a {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
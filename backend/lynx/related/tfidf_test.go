@@ -0,0 +1,31 @@
+package related
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t,
+		[]string{"sqlite", "database", "small", "projects", "2025"},
+		tokenize("SQLite is the database for small projects, in 2025!"),
+	)
+	assert.Empty(t, tokenize(""))
+	assert.Len(t, tokenize(strings.Repeat("word ", maxTextChars)), maxTextChars/5)
+}
+
+func TestTFIDFCosine(t *testing.T) {
+	vectors := tfidfVectors(map[string][]string{
+		"a": tokenize("SQLite database performance tuning"),
+		"b": tokenize("Tuning SQLite database performance for writes"),
+		"c": tokenize("Sourdough bread baking recipe"),
+		"d": {},
+	})
+
+	assert.InDelta(t, 1, vectors["a"].cosine(vectors["a"]), 1e-9)
+	assert.Greater(t, vectors["a"].cosine(vectors["b"]), 0.5)
+	assert.Zero(t, vectors["a"].cosine(vectors["c"]))
+	assert.Zero(t, vectors["a"].cosine(vectors["d"]))
+}
@@ -0,0 +1,102 @@
+package retry
+
+// Helpers for classifying failures from remote services (OpenRouter,
+// SingleFile) as transient, and for computing when to try again.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxAttempts = 5
+	BaseDelay   = time.Minute
+	MaxDelay    = 6 * time.Hour
+)
+
+// RetryableError marks a failure that is likely to succeed if the same
+// work is attempted again later. RetryAfter is the delay requested by
+// the remote service, if any.
+type RetryableError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func Retryable(err error, retryAfter time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err, RetryAfter: retryAfter}
+}
+
+// IsRetryable reports whether err (or any error it wraps) is retryable,
+// along with the delay requested by the remote service.
+func IsRetryable(err error) (bool, time.Duration) {
+	var retryableErr *RetryableError
+	if errors.As(err, &retryableErr) {
+		return true, retryableErr.RetryAfter
+	}
+	return false, 0
+}
+
+// FromResponse builds an error for a non-successful HTTP response. Rate
+// limiting and server errors are retryable and honour the Retry-After
+// header, anything else is returned as a permanent failure.
+func FromResponse(resp *http.Response, body []byte) error {
+	err := fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return Retryable(err, ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	}
+	return err
+}
+
+// ParseRetryAfter parses a Retry-After header value, which may either
+// be a number of seconds or an HTTP date. Returns 0 if the value is
+// missing or invalid.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// Backoff returns how long to wait before the next attempt, given the
+// number of attempts made so far. The delay doubles with each attempt
+// up to MaxDelay, but is never shorter than what the service asked for.
+func Backoff(attempts int, retryAfter time.Duration) time.Duration {
+	delay := BaseDelay
+	for i := 1; i < attempts && delay < MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
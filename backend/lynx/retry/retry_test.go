@@ -0,0 +1,89 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "Empty", value: "", expected: 0},
+		{name: "Seconds", value: "120", expected: 2 * time.Minute},
+		{name: "Negative seconds", value: "-5", expected: 0},
+		{name: "HTTP date", value: now.Add(90 * time.Second).Format(http.TimeFormat), expected: 90 * time.Second},
+		{name: "HTTP date in the past", value: now.Add(-time.Hour).Format(http.TimeFormat), expected: 0},
+		{name: "Garbage", value: "soon", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseRetryAfter(tc.value, now))
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, Backoff(1, 0))
+	assert.Equal(t, 2*time.Minute, Backoff(2, 0))
+	assert.Equal(t, 8*time.Minute, Backoff(4, 0))
+	assert.Equal(t, MaxDelay, Backoff(50, 0))
+
+	// Retry-After wins when it asks for a longer delay
+	assert.Equal(t, 10*time.Minute, Backoff(1, 10*time.Minute))
+	assert.Equal(t, 2*time.Minute, Backoff(2, 30*time.Second))
+}
+
+func TestFromResponse(t *testing.T) {
+	testCases := []struct {
+		name               string
+		statusCode         int
+		retryAfter         string
+		expectedRetryable  bool
+		expectedRetryAfter time.Duration
+	}{
+		{name: "Rate limited", statusCode: http.StatusTooManyRequests, retryAfter: "30", expectedRetryable: true, expectedRetryAfter: 30 * time.Second},
+		{name: "Service unavailable", statusCode: http.StatusServiceUnavailable, retryAfter: "5", expectedRetryable: true, expectedRetryAfter: 5 * time.Second},
+		{name: "Bad gateway", statusCode: http.StatusBadGateway, expectedRetryable: true},
+		{name: "Unauthorized", statusCode: http.StatusUnauthorized, expectedRetryable: false},
+		{name: "Bad request", statusCode: http.StatusBadRequest, retryAfter: "30", expectedRetryable: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tc.statusCode, Header: http.Header{}}
+			if tc.retryAfter != "" {
+				resp.Header.Set("Retry-After", tc.retryAfter)
+			}
+
+			err := FromResponse(resp, []byte("oops"))
+			assert.EqualError(t, err, fmt.Sprintf("API request failed with status %d: oops", tc.statusCode))
+
+			retryable, retryAfter := IsRetryable(err)
+			assert.Equal(t, tc.expectedRetryable, retryable)
+			assert.Equal(t, tc.expectedRetryAfter, retryAfter)
+		})
+	}
+}
+
+func TestIsRetryableWrapped(t *testing.T) {
+	err := fmt.Errorf("summarizing: %w", Retryable(errors.New("timeout"), time.Second))
+	retryable, retryAfter := IsRetryable(err)
+	assert.True(t, retryable)
+	assert.Equal(t, time.Second, retryAfter)
+
+	retryable, _ = IsRetryable(errors.New("permanent"))
+	assert.False(t, retryable)
+
+	assert.Nil(t, Retryable(nil, time.Second))
+}
@@ -0,0 +1,39 @@
+package savedsearches
+
+// Saved searches are named queries in the search query language (see
+// the searchquery package) that act as dynamic reading lists. The
+// queries can't be expressed as a view like tags_metadata, so the
+// number of matching links is counted whenever a saved search is
+// returned rather than stored.
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/fulltext"
+	"main/lynx/searchquery"
+)
+
+// Links returns a page of the links matching a saved search.
+func Links(app core.App, savedSearch *core.Record, page int, perPage int) (*fulltext.Results, error) {
+	return searchquery.SearchLinks(app, savedSearch.GetString("user"), savedSearch.GetString("query"), page, perPage)
+}
+
+// Count returns how many of the user's links match a query, and how
+// many of those are unread.
+func Count(app core.App, userID string, query string) (int, int, error) {
+	return searchquery.CountLinks(app, userID, query)
+}
+
+// ApplyCounts sets link_count and unread_count on the saved search as
+// custom data. The counts aren't stored, so they're never out of date,
+// at the cost of one query per saved search whenever they're returned.
+func ApplyCounts(app core.App, savedSearch *core.Record) error {
+	links, unread, err := Count(app, savedSearch.GetString("user"), savedSearch.GetString("query"))
+	if err != nil {
+		return err
+	}
+	savedSearch.WithCustomData(true)
+	savedSearch.Set("link_count", links)
+	savedSearch.Set("unread_count", unread)
+	return nil
+}
@@ -0,0 +1,91 @@
+package savedsearches
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+func setupTestApp(t *testing.T) *tests.TestApp {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+	return testApp
+}
+
+func newSavedSearch(t *testing.T, app core.App, name string, query string) *core.Record {
+	collection, err := app.FindCollectionByNameOrId("saved_searches")
+	require.NoError(t, err)
+	savedSearch := core.NewRecord(collection)
+	savedSearch.Set("user", testUserID)
+	savedSearch.Set("name", name)
+	savedSearch.Set("query", query)
+	require.NoError(t, app.Save(savedSearch))
+	return savedSearch
+}
+
+func TestCount(t *testing.T) {
+	testApp := setupTestApp(t)
+
+	links, unread, err := Count(testApp, testUserID, "site:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, links)
+	assert.Equal(t, 1, unread)
+
+	links, unread, err = Count(testApp, testUserID, "site:example.com is:read")
+	require.NoError(t, err)
+	assert.Equal(t, 0, links)
+	assert.Equal(t, 0, unread)
+
+	_, _, err = Count(testApp, testUserID, "is:nope")
+	assert.Error(t, err)
+}
+
+func TestLinks(t *testing.T) {
+	testApp := setupTestApp(t)
+	savedSearch := newSavedSearch(t, testApp, "Examples", "site:example.com")
+
+	results, err := Links(testApp, savedSearch, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, testLinkID, results.Items[0].ID)
+
+	// Words alone still only match links
+	savedSearch.Set("query", "example")
+	results, err = Links(testApp, savedSearch, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, testLinkID, results.Items[0].ID)
+}
+
+func TestApplyCounts(t *testing.T) {
+	testApp := setupTestApp(t)
+	unread := newSavedSearch(t, testApp, "Unread", "is:unread")
+	read := newSavedSearch(t, testApp, "Read", "is:read")
+
+	require.NoError(t, ApplyCounts(testApp, unread))
+	assert.Equal(t, 1, unread.GetInt("link_count"))
+	assert.Equal(t, 1, unread.GetInt("unread_count"))
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("last_viewed_at", "2025-01-01 00:00:00.000Z")
+	require.NoError(t, testApp.Save(link))
+
+	require.NoError(t, ApplyCounts(testApp, unread))
+	assert.Equal(t, 0, unread.GetInt("link_count"))
+	require.NoError(t, ApplyCounts(testApp, read))
+	assert.Equal(t, 1, read.GetInt("link_count"))
+	assert.Equal(t, 0, read.GetInt("unread_count"))
+
+	read.Set("query", "is:nope")
+	assert.Error(t, ApplyCounts(testApp, read))
+}
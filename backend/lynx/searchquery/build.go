@@ -0,0 +1,181 @@
+package searchquery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/fulltext"
+	"main/lynx/tagger"
+)
+
+// How dates are stored by PocketBase, so they compare as strings
+const dateFormat = "2006-01-02 15:04:05.000Z"
+
+// TagResolver returns the IDs of the user's tags matching a tag: value.
+type TagResolver func(value string) ([]string, error)
+
+// Filter translates the operator terms into a PocketBase filter over
+// the links collection, using the same expressions as the web client.
+// Returns an empty filter if there are no operators.
+func (q *Query) Filter(resolveTags TagResolver) (string, dbx.Params, error) {
+	var exprs []string
+	params := dbx.Params{}
+	param := func(value any) string {
+		name := fmt.Sprintf("p%d", len(params))
+		params[name] = value
+		return "{:" + name + "}"
+	}
+
+	for _, term := range q.Filters() {
+		var expr string
+		switch term.Operator {
+		case OperatorTag:
+			// Tags are matched by ID rather than through the relation,
+			// since PocketBase shares one join between all conditions
+			// on tags.name and two tag: terms could never both match.
+			tagIDs, err := resolveTags(term.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			if len(tagIDs) == 0 {
+				if term.Negated {
+					continue
+				}
+				expr = "id = ''"
+				break
+			}
+			conditions := make([]string, len(tagIDs))
+			for i, tagID := range tagIDs {
+				conditions[i] = "tags " + negate("~", term.Negated) + " " + param(tagID)
+			}
+			if term.Negated {
+				expr = "(" + strings.Join(conditions, " && ") + ")"
+			} else {
+				expr = "(" + strings.Join(conditions, " || ") + ")"
+			}
+		case OperatorSite:
+			// Subdomains count as the same site
+			host, subdomains := param(term.Value), param("%."+term.Value)
+			if term.Negated {
+				expr = fmt.Sprintf("(hostname != %s && hostname !~ %s)", host, subdomains)
+			} else {
+				expr = fmt.Sprintf("(hostname = %s || hostname ~ %s)", host, subdomains)
+			}
+		case OperatorFeed:
+			expr = "created_from_feed.name " + negate("~", term.Negated) + " " + param(term.Value)
+		case OperatorIs:
+			switch term.Value {
+			case "unread":
+				expr = "last_viewed_at " + negate("=", term.Negated) + " null"
+			case "read":
+				expr = "last_viewed_at " + negate("!=", term.Negated) + " null"
+			case "starred":
+				expr = "starred_at " + negate("!=", term.Negated) + " null"
+			case "feed":
+				expr = "created_from_feed " + negate("!=", term.Negated) + " null"
+			}
+		case OperatorHas:
+			switch term.Value {
+			case "highlights":
+				expr = "highlights_via_link.id " + negate("!=", term.Negated) + " null"
+			case "summary":
+				expr = "summary " + negate("!=", term.Negated) + " null"
+			case "archive":
+				expr = "archive " + negate("!=", term.Negated) + " null"
+			case "tags":
+				if term.Negated {
+					expr = "tags:length = 0"
+				} else {
+					expr = "tags:length > 0"
+				}
+			}
+		case OperatorBefore:
+			start, _, _ := parseDateRange(term.Value)
+			expr = fmt.Sprintf("(article_date != null && article_date < %s)", param(start.Format(dateFormat)))
+		case OperatorAfter:
+			_, end, _ := parseDateRange(term.Value)
+			expr = "article_date >= " + param(end.Format(dateFormat))
+		case OperatorMinutes:
+			// Links without any text have no reading time, so they
+			// shouldn't count as quick reads
+			comparison, minutes, _ := parseMinutes(term.Value)
+			expr = fmt.Sprintf("(read_time_seconds > 0 && read_time_seconds %s %s)", comparison, param(minutes*60))
+		}
+		exprs = append(exprs, expr)
+	}
+
+	return strings.Join(exprs, " && "), params, nil
+}
+
+// UserTags returns a TagResolver that finds the user's tags by name or
+// slug.
+func UserTags(app core.App, userID string) TagResolver {
+	return func(value string) ([]string, error) {
+		tags, err := app.FindAllRecords("tags", dbx.HashExp{"user": userID}, dbx.Or(
+			dbx.NewExp("name = {:name} COLLATE NOCASE", dbx.Params{"name": value}),
+			dbx.HashExp{"slug": tagger.Slugify(value)},
+		))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tags: %w", err)
+		}
+		ids := make([]string, len(tags))
+		for i, tag := range tags {
+			ids[i] = tag.Id
+		}
+		return ids, nil
+	}
+}
+
+func negate(operator string, negated bool) string {
+	if !negated {
+		return operator
+	}
+	switch operator {
+	case "=":
+		return "!="
+	case "!=":
+		return "="
+	case "~":
+		return "!~"
+	}
+	return operator
+}
+
+// Match translates the full-text terms into an FTS5 query. Returns an
+// empty string if there are no full-text terms.
+func (q *Query) Match() (string, error) {
+	var include, exclude []string
+	var firstExcluded *Term
+	for _, term := range q.Text() {
+		expr := fulltext.Quote(term.Value)
+		if term.Prefix {
+			expr += "*"
+		}
+		if term.Negated {
+			exclude = append(exclude, expr)
+			if firstExcluded == nil {
+				firstExcluded = &term
+			}
+		} else {
+			include = append(include, expr)
+		}
+	}
+
+	if len(include) == 0 {
+		if firstExcluded != nil {
+			// FTS5 can only exclude words from the results of another
+			// match
+			return "", &ParseError{Position: firstExcluded.Position, Message: "excluded words need at least one word to search for"}
+		}
+		return "", nil
+	}
+
+	match := strings.Join(include, " ")
+	if len(exclude) > 0 {
+		match = "(" + match + ") NOT " + strings.Join(exclude, " NOT ")
+	}
+	return match, nil
+}
@@ -0,0 +1,118 @@
+package searchquery
+
+import (
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	resolveTags := func(value string) ([]string, error) {
+		return map[string][]string{
+			"golang": {"tag_golang"},
+			"ml":     {"tag_ml", "tag_ml2"},
+		}[value], nil
+	}
+
+	testCases := []struct {
+		query          string
+		expectedFilter string
+		expectedParams dbx.Params
+	}{
+		{
+			query:          "tag:golang tag:ml",
+			expectedFilter: "(tags ~ {:p0}) && (tags ~ {:p1} || tags ~ {:p2})",
+			expectedParams: dbx.Params{"p0": "tag_golang", "p1": "tag_ml", "p2": "tag_ml2"},
+		},
+		{
+			query:          "-tag:ml",
+			expectedFilter: "(tags !~ {:p0} && tags !~ {:p1})",
+			expectedParams: dbx.Params{"p0": "tag_ml", "p1": "tag_ml2"},
+		},
+		{
+			query:          "tag:missing is:unread",
+			expectedFilter: "id = '' && last_viewed_at = null",
+			expectedParams: dbx.Params{},
+		},
+		{
+			query:          "-tag:missing is:unread",
+			expectedFilter: "last_viewed_at = null",
+			expectedParams: dbx.Params{},
+		},
+		{
+			query:          "site:go.dev -site:blog.go.dev",
+			expectedFilter: "(hostname = {:p0} || hostname ~ {:p1}) && (hostname != {:p2} && hostname !~ {:p3})",
+			expectedParams: dbx.Params{"p0": "go.dev", "p1": "%.go.dev", "p2": "blog.go.dev", "p3": "%.blog.go.dev"},
+		},
+		{
+			query:          "is:unread -is:starred is:feed -is:read",
+			expectedFilter: "last_viewed_at = null && starred_at = null && created_from_feed != null && last_viewed_at = null",
+			expectedParams: dbx.Params{},
+		},
+		{
+			query:          "has:highlights -has:summary has:archive -has:tags",
+			expectedFilter: "highlights_via_link.id != null && summary = null && archive != null && tags:length = 0",
+			expectedParams: dbx.Params{},
+		},
+		{
+			query:          "feed:hacker -feed:lobsters",
+			expectedFilter: "created_from_feed.name ~ {:p0} && created_from_feed.name !~ {:p1}",
+			expectedParams: dbx.Params{"p0": "hacker", "p1": "lobsters"},
+		},
+		{
+			query:          "after:2024 before:2025-02-10",
+			expectedFilter: "article_date >= {:p0} && (article_date != null && article_date < {:p1})",
+			expectedParams: dbx.Params{"p0": "2025-01-01 00:00:00.000Z", "p1": "2025-02-10 00:00:00.000Z"},
+		},
+		{
+			query:          "minutes:<10 minutes:>=2",
+			expectedFilter: "(read_time_seconds > 0 && read_time_seconds < {:p0}) && (read_time_seconds > 0 && read_time_seconds >= {:p1})",
+			expectedParams: dbx.Params{"p0": 600, "p1": 120},
+		},
+		{
+			query:          "just some words",
+			expectedFilter: "",
+			expectedParams: dbx.Params{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			query, err := Parse(tc.query)
+			require.NoError(t, err)
+			filter, params, err := query.Filter(resolveTags)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFilter, filter)
+			assert.Equal(t, tc.expectedParams, params)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "tag:golang", expected: ""},
+		{query: "sqlite tuning", expected: `"sqlite" "tuning"`},
+		{query: `"write ahead" data* -postgres -"my sql"`, expected: `("write ahead" "data"*) NOT "postgres" NOT "my sql"`},
+		{query: `NEAR(a`, expected: `"NEAR(a"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			query, err := Parse(tc.query)
+			require.NoError(t, err)
+			match, err := query.Match()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, match)
+		})
+	}
+
+	query, err := Parse("tag:golang -spam")
+	require.NoError(t, err)
+	_, err = query.Match()
+	assert.EqualError(t, err, "excluded words need at least one word to search for (at position 12)")
+}
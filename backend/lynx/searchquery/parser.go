@@ -0,0 +1,268 @@
+package searchquery
+
+// Parses the search box query language, e.g.
+//
+//	tag:golang site:go.dev is:unread has:highlights before:2025-01 minutes:<10 "exact phrase"
+//
+// Operators narrow down which links match and are translated into a
+// PocketBase filter (see Query.Filter), while the remaining words and
+// phrases are matched against the full-text search index. Any
+// operator or word can be excluded by prefixing it with a minus sign.
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	OperatorTag    = "tag"
+	OperatorSite   = "site"
+	OperatorFeed   = "feed"
+	OperatorIs     = "is"
+	OperatorHas    = "has"
+	OperatorBefore = "before"
+	OperatorAfter  = "after"
+	// Reading time, e.g. minutes:<10 or minutes:>=30
+	OperatorMinutes = "minutes"
+)
+
+var operators = map[string]bool{
+	OperatorTag:     true,
+	OperatorSite:    true,
+	OperatorFeed:    true,
+	OperatorIs:      true,
+	OperatorHas:     true,
+	OperatorBefore:  true,
+	OperatorAfter:   true,
+	OperatorMinutes: true,
+}
+
+// Allowed values for is: and has:
+var (
+	isValues  = []string{"unread", "read", "starred", "feed"}
+	hasValues = []string{"highlights", "summary", "archive", "tags"}
+)
+
+// ParseError describes why a query couldn't be parsed. Position is
+// the zero based offset in runes of the offending term.
+type ParseError struct {
+	Position int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (at position %d)", e.Message, e.Position+1)
+}
+
+// Term is a single operator, word or phrase in a query.
+type Term struct {
+	// Operator name, or empty for full-text words and phrases
+	Operator string
+	Value    string
+	Negated  bool
+	// The value was quoted
+	Phrase bool
+	// A full-text word ending in *, matching any word with that prefix
+	Prefix   bool
+	Position int
+}
+
+type Query struct {
+	Terms []Term
+}
+
+// Filters returns the operator terms.
+func (q *Query) Filters() []Term {
+	var terms []Term
+	for _, term := range q.Terms {
+		if term.Operator != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Text returns the full-text words and phrases.
+func (q *Query) Text() []Term {
+	var terms []Term
+	for _, term := range q.Terms {
+		if term.Operator == "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func Parse(input string) (*Query, error) {
+	query := &Query{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		term := Term{Position: i}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			term.Negated = true
+			i++
+		}
+
+		if runes[i] == '"' {
+			value, next, err := readQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+			term.Value = value
+			term.Phrase = true
+			if term.Value == "" {
+				continue
+			}
+			query.Terms = append(query.Terms, term)
+			continue
+		}
+
+		word, next := readWord(runes, i)
+		if key, value, ok := strings.Cut(word, ":"); ok && operators[strings.ToLower(key)] {
+			term.Operator = strings.ToLower(key)
+			valueStart := i + len([]rune(key)) + 1
+			if value == "" && valueStart < len(runes) && runes[valueStart] == '"' {
+				quoted, afterQuote, err := readQuoted(runes, valueStart)
+				if err != nil {
+					return nil, err
+				}
+				value = quoted
+				term.Phrase = true
+				next = afterQuote
+			}
+			term.Value = strings.TrimSpace(value)
+			if term.Value == "" {
+				return nil, &ParseError{Position: term.Position, Message: fmt.Sprintf("missing value for %s:", term.Operator)}
+			}
+			if err := validate(&term); err != nil {
+				return nil, err
+			}
+		} else {
+			term.Prefix = strings.HasSuffix(word, "*")
+			term.Value = strings.TrimRight(word, "*")
+			if !strings.ContainsFunc(term.Value, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) {
+				// Punctuation on its own can't match anything
+				i = next
+				continue
+			}
+		}
+
+		i = next
+		query.Terms = append(query.Terms, term)
+	}
+
+	if len(query.Terms) == 0 {
+		return nil, &ParseError{Position: 0, Message: "query is empty"}
+	}
+	return query, nil
+}
+
+// readWord reads up to the next whitespace or quote.
+func readWord(runes []rune, start int) (string, int) {
+	end := start
+	for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+		end++
+	}
+	return string(runes[start:end]), end
+}
+
+// readQuoted reads a quoted string starting at the opening quote and
+// returns its contents and the position after the closing quote.
+func readQuoted(runes []rune, start int) (string, int, error) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end >= len(runes) {
+		return "", 0, &ParseError{Position: start, Message: "missing closing quote"}
+	}
+	return strings.TrimSpace(string(runes[start+1 : end])), end + 1, nil
+}
+
+func validate(term *Term) error {
+	switch term.Operator {
+	case OperatorIs:
+		term.Value = strings.ToLower(term.Value)
+		if !slices.Contains(isValues, term.Value) {
+			return &ParseError{Position: term.Position, Message: fmt.Sprintf("unknown value %q for is:, expected one of %s", term.Value, strings.Join(isValues, ", "))}
+		}
+	case OperatorHas:
+		term.Value = strings.ToLower(term.Value)
+		if !slices.Contains(hasValues, term.Value) {
+			return &ParseError{Position: term.Position, Message: fmt.Sprintf("unknown value %q for has:, expected one of %s", term.Value, strings.Join(hasValues, ", "))}
+		}
+	case OperatorBefore, OperatorAfter:
+		if term.Negated {
+			opposite := OperatorAfter
+			if term.Operator == OperatorAfter {
+				opposite = OperatorBefore
+			}
+			return &ParseError{Position: term.Position, Message: fmt.Sprintf("%s: can't be excluded, use %s: instead", term.Operator, opposite)}
+		}
+		if _, _, err := parseDateRange(term.Value); err != nil {
+			return &ParseError{Position: term.Position, Message: fmt.Sprintf("invalid date %q for %s:, expected YYYY, YYYY-MM or YYYY-MM-DD", term.Value, term.Operator)}
+		}
+	case OperatorMinutes:
+		if term.Negated {
+			return &ParseError{Position: term.Position, Message: "minutes: can't be excluded, compare with < or > instead"}
+		}
+		comparison, minutes, err := parseMinutes(term.Value)
+		if err != nil {
+			return &ParseError{Position: term.Position, Message: fmt.Sprintf("invalid reading time %q for minutes:, expected a number of minutes like <10 or >=30", term.Value)}
+		}
+		term.Value = fmt.Sprintf("%s%d", comparison, minutes)
+	case OperatorSite:
+		term.Value = strings.ToLower(term.Value)
+	}
+	return nil
+}
+
+// parseMinutes splits a reading time like "<10" into the comparison
+// and number of minutes. A bare number means at most that long.
+func parseMinutes(value string) (string, int, error) {
+	comparison := "<="
+	for _, prefix := range []string{"<=", ">=", "<", ">", "="} {
+		if strings.HasPrefix(value, prefix) {
+			comparison = prefix
+			value = value[len(prefix):]
+			break
+		}
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		return "", 0, fmt.Errorf("invalid number of minutes %q", value)
+	}
+	return comparison, minutes, nil
+}
+
+// parseDateRange returns the start and end of the period covered by a
+// year, month or day.
+func parseDateRange(value string) (time.Time, time.Time, error) {
+	layouts := []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006", 1, 0, 0},
+		{"2006-01", 0, 1, 0},
+		{time.DateOnly, 0, 0, 1},
+	}
+	for _, l := range layouts {
+		if start, err := time.Parse(l.layout, value); err == nil {
+			return start, start.AddDate(l.years, l.months, l.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", value)
+}
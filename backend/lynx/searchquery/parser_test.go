@@ -0,0 +1,63 @@
+package searchquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`tag:golang -site:Go.dev is:UNREAD has:highlights before:2025-01 "exact phrase" data* -spam tag:"machine learning" http://example.com`)
+	require.NoError(t, err)
+	assert.Equal(t, []Term{
+		{Operator: OperatorTag, Value: "golang", Position: 0},
+		{Operator: OperatorSite, Value: "go.dev", Negated: true, Position: 11},
+		{Operator: OperatorIs, Value: "unread", Position: 24},
+		{Operator: OperatorHas, Value: "highlights", Position: 34},
+		{Operator: OperatorBefore, Value: "2025-01", Position: 49},
+		{Value: "exact phrase", Phrase: true, Position: 64},
+		{Value: "data", Prefix: true, Position: 79},
+		{Value: "spam", Negated: true, Position: 85},
+		{Operator: OperatorTag, Value: "machine learning", Phrase: true, Position: 91},
+		{Value: "http://example.com", Position: 114},
+	}, query.Terms)
+
+	query, err = Parse("minutes:<10 minutes:>=5 minutes:20")
+	require.NoError(t, err)
+	assert.Equal(t, []Term{
+		{Operator: OperatorMinutes, Value: "<10", Position: 0},
+		{Operator: OperatorMinutes, Value: ">=5", Position: 12},
+		{Operator: OperatorMinutes, Value: "<=20", Position: 24},
+	}, query.Terms)
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "", expected: "query is empty (at position 1)"},
+		{query: `  "" * `, expected: "query is empty (at position 1)"},
+		{query: `golang "exact phrase`, expected: "missing closing quote (at position 8)"},
+		{query: `tag:"machine learning`, expected: "missing closing quote (at position 5)"},
+		{query: "golang tag:", expected: "missing value for tag: (at position 8)"},
+		{query: "is:archived", expected: `unknown value "archived" for is:, expected one of unread, read, starred, feed (at position 1)`},
+		{query: "has:notes", expected: `unknown value "notes" for has:, expected one of highlights, summary, archive, tags (at position 1)`},
+		{query: "before:last-week", expected: `invalid date "last-week" for before:, expected YYYY, YYYY-MM or YYYY-MM-DD (at position 1)`},
+		{query: "after:2025-13", expected: `invalid date "2025-13" for after:, expected YYYY, YYYY-MM or YYYY-MM-DD (at position 1)`},
+		{query: "-before:2025", expected: "before: can't be excluded, use after: instead (at position 1)"},
+		{query: "minutes:short", expected: `invalid reading time "short" for minutes:, expected a number of minutes like <10 or >=30 (at position 1)`},
+		{query: "minutes:<-5", expected: `invalid reading time "<-5" for minutes:, expected a number of minutes like <10 or >=30 (at position 1)`},
+		{query: "-minutes:10", expected: "minutes: can't be excluded, compare with < or > instead (at position 1)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := Parse(tc.query)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tc.expected, parseErr.Error())
+		})
+	}
+}
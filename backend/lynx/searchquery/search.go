@@ -0,0 +1,176 @@
+package searchquery
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/search"
+
+	"main/lynx/fulltext"
+)
+
+// Search runs a query for the user. Queries made up only of words and
+// phrases search both links and highlights; once an operator is used
+// only links are returned, since operators describe links. Without
+// any words, matching links are returned newest first.
+func Search(app core.App, userID string, input string, page int, perPage int) (*fulltext.Results, error) {
+	query, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := query.Match()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(query.Filters()) == 0 {
+		return fulltext.SearchMatch(app, userID, match, page, perPage)
+	}
+	return searchLinks(app, userID, query, match, page, perPage)
+}
+
+// SearchLinks runs a query for the user like Search, but only ever
+// returns links, even for queries made up only of words.
+func SearchLinks(app core.App, userID string, input string, page int, perPage int) (*fulltext.Results, error) {
+	query, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := query.Match()
+	if err != nil {
+		return nil, err
+	}
+
+	return searchLinks(app, userID, query, match, page, perPage)
+}
+
+// CountLinks returns how many of the user's links match a query, and
+// how many of those are unread, in a single query.
+func CountLinks(app core.App, userID string, input string) (int, int, error) {
+	query, err := Parse(input)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	match, err := query.Match()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	linksQuery, resolver, err := buildLinksQuery(app, userID, query, match)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := resolver.UpdateQuery(linksQuery); err != nil {
+		return 0, 0, fmt.Errorf("failed to build filter: %w", err)
+	}
+
+	// Filters on relations can join a link more than once
+	var counts struct {
+		Total  int `db:"total"`
+		Unread int `db:"unread"`
+	}
+	err = linksQuery.
+		Select(
+			"COUNT(DISTINCT links.id) AS total",
+			"COUNT(DISTINCT CASE WHEN links.last_viewed_at IS NULL OR links.last_viewed_at = '' THEN links.id END) AS unread",
+		).
+		One(&counts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count links: %w", err)
+	}
+	return counts.Total, counts.Unread, nil
+}
+
+// buildLinksQuery returns a query for the user's links matching the
+// filters and full-text match, along with the resolver any joins the
+// filters need are recorded on.
+func buildLinksQuery(app core.App, userID string, query *Query, match string) (*dbx.SelectQuery, *core.RecordFieldResolver, error) {
+	collection, err := app.FindCollectionByNameOrId("links")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find links collection: %w", err)
+	}
+
+	resolver := core.NewRecordFieldResolver(app, collection, nil, true)
+	filter, params, err := query.Filter(UserTags(app, userID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	linksQuery := app.RecordQuery(collection).
+		AndWhere(dbx.HashExp{"links.user": userID})
+	if filter != "" {
+		filterExpr, err := search.FilterData(filter).BuildExpr(resolver, params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build filter: %w", err)
+		}
+		linksQuery.AndWhere(filterExpr)
+	}
+	if match != "" {
+		linksQuery.
+			InnerJoin("search_index", dbx.NewExp("search_index.record_id = links.id")).
+			AndWhere(dbx.NewExp("search_index MATCH {:match}", dbx.Params{"match": match}))
+	}
+	return linksQuery, resolver, nil
+}
+
+func searchLinks(app core.App, userID string, query *Query, match string, page int, perPage int) (*fulltext.Results, error) {
+	linksQuery, resolver, err := buildLinksQuery(app, userID, query, match)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := search.NewProvider(resolver).Page(page)
+	if perPage > 0 {
+		provider.PerPage(min(perPage, fulltext.MaxPerPage))
+	} else {
+		provider.PerPage(fulltext.DefaultPerPage)
+	}
+	if match != "" {
+		linksQuery.OrderBy(fulltext.RankExpression)
+	} else {
+		provider.Sort([]search.SortField{{Name: "added_to_library", Direction: search.SortDesc}})
+	}
+
+	var links []*core.Record
+	result, err := provider.Query(linksQuery).Exec(&links)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search links: %w", err)
+	}
+
+	var matches map[string]fulltext.Result
+	if match != "" {
+		ids := make([]string, len(links))
+		for i, link := range links {
+			ids[i] = link.Id
+		}
+		matches, err = fulltext.Matches(app, match, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := &fulltext.Results{
+		Page:       result.Page,
+		PerPage:    result.PerPage,
+		TotalItems: result.TotalItems,
+		TotalPages: result.TotalPages,
+		Items:      make([]fulltext.Result, len(links)),
+	}
+	for i, link := range links {
+		results.Items[i] = fulltext.Result{
+			Type:     fulltext.TypeLink,
+			ID:       link.Id,
+			LinkID:   link.Id,
+			Title:    link.GetString("title"),
+			URL:      link.GetString("cleaned_url"),
+			Hostname: link.GetString("hostname"),
+			Snippet:  matches[link.Id].Snippet,
+			Score:    matches[link.Id].Score,
+		}
+	}
+	return results, nil
+}
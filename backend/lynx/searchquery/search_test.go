@@ -0,0 +1,227 @@
+package searchquery
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/fulltext"
+	"main/lynx/tagger"
+)
+
+const (
+	testLinkID = "8n3iq8dt6vwi4ph"
+	testUserID = "u3ozd82edmlybb1"
+)
+
+type testLibrary struct {
+	app *tests.TestApp
+	// Link IDs by a short name
+	links map[string]string
+}
+
+// setupTestLibrary adds a few links with different tags, sites and
+// states alongside the existing example.com link.
+func setupTestLibrary(t *testing.T) *testLibrary {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+
+	library := &testLibrary{app: testApp, links: map[string]string{"example": testLinkID}}
+
+	tagsCollection, err := testApp.FindCollectionByNameOrId("tags")
+	require.NoError(t, err)
+	tagIDs := map[string]string{}
+	for _, name := range []string{"Go Lang", "Databases"} {
+		tag := core.NewRecord(tagsCollection)
+		tag.Set("user", testUserID)
+		tag.Set("name", name)
+		tag.Set("slug", tagger.Slugify(name))
+		require.NoError(t, testApp.Save(tag))
+		tagIDs[name] = tag.Id
+	}
+
+	linksCollection, err := testApp.FindCollectionByNameOrId("links")
+	require.NoError(t, err)
+	for name, values := range map[string]map[string]any{
+		"goblog": {
+			"title":             "Go 1.24 is released",
+			"hostname":          "go.dev",
+			"tags":              []string{tagIDs["Go Lang"]},
+			"raw_text_content":  "Generic type aliases are now fully supported.",
+			"article_date":      "2025-02-11 00:00:00.000Z",
+			"added_to_library":  "2025-02-12 00:00:00.000Z",
+			"read_time_seconds": 300,
+		},
+		"sqlite": {
+			"title":             "SQLite in Go",
+			"hostname":          "blog.example.org",
+			"tags":              []string{tagIDs["Go Lang"], tagIDs["Databases"]},
+			"raw_text_content":  "Use database/sql with a pure Go SQLite driver.",
+			"article_date":      "2024-12-01 00:00:00.000Z",
+			"added_to_library":  "2025-01-05 00:00:00.000Z",
+			"last_viewed_at":    "2025-01-06 00:00:00.000Z",
+			"starred_at":        "2025-01-06 00:00:00.000Z",
+			"summary":           "How to use SQLite from Go.",
+			"read_time_seconds": 900,
+		},
+		"pkgsite": {
+			"title":            "Package documentation",
+			"hostname":         "pkg.go.dev",
+			"raw_text_content": "Documentation for Go packages.",
+			"added_to_library": "2025-03-01 00:00:00.000Z",
+		},
+	} {
+		link := core.NewRecord(linksCollection)
+		link.Set("user", testUserID)
+		link.Set("original_url", "https://"+values["hostname"].(string)+"/"+name)
+		link.Set("cleaned_url", "https://"+values["hostname"].(string)+"/"+name)
+		for key, value := range values {
+			link.Set(key, value)
+		}
+		require.NoError(t, testApp.Save(link))
+		require.NoError(t, fulltext.IndexLink(testApp, link))
+		library.links[name] = link.Id
+	}
+
+	highlightsCollection, err := testApp.FindCollectionByNameOrId("highlights")
+	require.NoError(t, err)
+	highlight := core.NewRecord(highlightsCollection)
+	highlight.Set("user", testUserID)
+	highlight.Set("link", library.links["sqlite"])
+	highlight.Set("highlighted_text", "pure Go SQLite driver")
+	highlight.Set("serialized_range", "0:0")
+	require.NoError(t, testApp.Save(highlight))
+	require.NoError(t, fulltext.IndexHighlight(testApp, highlight))
+
+	return library
+}
+
+func (l *testLibrary) search(t *testing.T, query string) []string {
+	t.Helper()
+	results, err := Search(l.app, testUserID, query, 0, 0)
+	require.NoError(t, err)
+
+	names := map[string]string{}
+	for name, id := range l.links {
+		names[id] = name
+	}
+	ids := []string{}
+	for _, item := range results.Items {
+		if item.Type == fulltext.TypeHighlight {
+			ids = append(ids, "highlight:"+names[item.LinkID])
+		} else {
+			ids = append(ids, names[item.ID])
+		}
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	library := setupTestLibrary(t)
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		// Operators only, newest first
+		{query: "tag:go-lang", expected: []string{"goblog", "sqlite"}},
+		{query: `tag:"Go Lang" tag:databases`, expected: []string{"sqlite"}},
+		{query: "-tag:databases", expected: []string{"pkgsite", "goblog", "example"}},
+		{query: "site:go.dev", expected: []string{"pkgsite", "goblog"}},
+		{query: "-site:go.dev", expected: []string{"sqlite", "example"}},
+		{query: "is:unread", expected: []string{"pkgsite", "goblog", "example"}},
+		{query: "is:read", expected: []string{"sqlite"}},
+		{query: "is:starred", expected: []string{"sqlite"}},
+		{query: "has:highlights", expected: []string{"sqlite"}},
+		{query: "-has:highlights", expected: []string{"pkgsite", "goblog", "example"}},
+		{query: "has:summary", expected: []string{"sqlite"}},
+		{query: "has:tags", expected: []string{"goblog", "sqlite"}},
+		{query: "-has:tags", expected: []string{"pkgsite", "example"}},
+		{query: "before:2025", expected: []string{"sqlite", "example"}},
+		{query: "after:2024-12", expected: []string{"goblog"}},
+		{query: "after:2024-11 before:2025-02-11", expected: []string{"sqlite"}},
+		{query: "minutes:<10", expected: []string{"goblog", "example"}},
+		{query: "minutes:>=10", expected: []string{"sqlite"}},
+		// Words alone search highlights too
+		{query: "sqlite", expected: []string{"highlight:sqlite", "sqlite"}},
+		// Words combined with operators only return links
+		{query: "go is:unread", expected: []string{"goblog", "pkgsite"}},
+		{query: `"pure go" tag:databases`, expected: []string{"sqlite"}},
+		{query: "go -documentation site:go.dev", expected: []string{"goblog"}},
+		{query: "tag:nonexistent", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.expected, library.search(t, tc.query))
+		})
+	}
+}
+
+func TestSearchResults(t *testing.T) {
+	library := setupTestLibrary(t)
+
+	results, err := Search(library.app, testUserID, "aliases site:go.dev", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Items, 1)
+	item := results.Items[0]
+	assert.Equal(t, fulltext.TypeLink, item.Type)
+	assert.Equal(t, library.links["goblog"], item.LinkID)
+	assert.Equal(t, "Go 1.24 is released", item.Title)
+	assert.Equal(t, "https://go.dev/goblog", item.URL)
+	assert.Equal(t, "go.dev", item.Hostname)
+	assert.Equal(t, "Generic type <mark>aliases</mark> are now fully supported.", item.Snippet)
+	assert.Greater(t, item.Score, 0.0)
+
+	results, err = Search(library.app, testUserID, "site:go.dev", 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.Page)
+	assert.Equal(t, 1, results.PerPage)
+	assert.Equal(t, 2, results.TotalItems)
+	assert.Equal(t, 2, results.TotalPages)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, library.links["goblog"], results.Items[0].ID)
+	assert.Empty(t, results.Items[0].Snippet)
+
+	// Other users' links never match
+	results, err = Search(library.app, "h4oofx0tx2eupnq", "site:go.dev", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, results.Items)
+
+	_, err = Search(library.app, testUserID, "is:nope", 0, 0)
+	var parseErr *ParseError
+	assert.ErrorAs(t, err, &parseErr)
+}
+
+func TestCountLinks(t *testing.T) {
+	library := setupTestLibrary(t)
+
+	testCases := []struct {
+		query  string
+		total  int
+		unread int
+	}{
+		{query: "tag:go-lang", total: 2, unread: 1},
+		{query: "has:tags", total: 2, unread: 1},
+		{query: "go site:go.dev", total: 2, unread: 2},
+		{query: "is:read", total: 1, unread: 0},
+		{query: "tag:nonexistent", total: 0, unread: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			total, unread, err := CountLinks(library.app, testUserID, tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.total, total)
+			assert.Equal(t, tc.unread, unread)
+		})
+	}
+
+	_, _, err := CountLinks(library.app, testUserID, "is:nope")
+	var parseErr *ParseError
+	assert.ErrorAs(t, err, &parseErr)
+}
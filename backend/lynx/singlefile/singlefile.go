@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"net/url"
@@ -18,6 +19,9 @@ import (
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/filesystem"
+
+	"main/lynx/linkstatus"
+	"main/lynx/retry"
 )
 
 const (
@@ -35,30 +39,49 @@ func generateFilenameSuffix() string {
 	return string(b)
 }
 
-func MaybeArchiveLink(app core.App, linkID string) {
+func MaybeArchiveLink(app core.App, linkID string) error {
 	logger := app.Logger().With("action", "createArchive", "linkID", linkID)
 
 	link, err := app.FindRecordById("links", linkID)
 	if err != nil {
 		logger.Error("Failed to find link", "error", err)
-		return
+		return fmt.Errorf("failed to find link: %w", err)
 	}
 
 	if link.GetString("archive") != "" {
 		logger.Info("Link already archived, skipping")
-		return
+		return nil
+	}
+
+	// Content that was uploaded rather than saved from the web has
+	// nothing to archive
+	if link.GetString("original_url") == "" {
+		logger.Info("Link has no original_url, skipping archive creation")
+		linkstatus.Record(app, linkID, linkstatus.StageArchive, linkstatus.Skipped, nil)
+		return nil
 	}
 
 	singlefileURL := os.Getenv("SINGLEFILE_URL")
 	if singlefileURL == "" {
 		logger.Info("SINGLEFILE_URL not set, skipping archive creation")
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageArchive, linkstatus.Skipped, nil)
+		return nil
 	}
 
+	if err := createArchive(app, logger, link, singlefileURL); err != nil {
+		linkstatus.Record(app, linkID, linkstatus.StageArchive, linkstatus.Failed, err)
+		return err
+	}
+
+	logger.Info("Successfully created archive for link")
+	return nil
+}
+
+func createArchive(app core.App, logger *slog.Logger, link *core.Record, singlefileURL string) error {
 	originalURL := link.GetString("original_url")
 	if originalURL == "" {
 		logger.Error("Link has no original_url")
-		return
+		return fmt.Errorf("link has no original_url")
 	}
 
 	// Create a file using Pocketbase's filesystem
@@ -70,14 +93,14 @@ func MaybeArchiveLink(app core.App, linkID string) {
 	fs, err := app.NewFilesystem()
 	if err != nil {
 		logger.Error("Failed to create filesystem", "error", err)
-		return
+		return fmt.Errorf("failed to create filesystem: %w", err)
 	}
 	defer fs.Close()
 
 	exists, err := fs.Exists(fileKey)
 	if exists || err != nil {
 		logger.Info("Skipping archive creation, file already exists")
-		return
+		return nil
 	}
 
 	userID := link.GetString("user")
@@ -98,38 +121,39 @@ func MaybeArchiveLink(app core.App, linkID string) {
 	resp, err := client.PostForm(singlefileURL, formData)
 	if err != nil {
 		logger.Error("Failed to send request to singlefile service", "error", err)
-		return
+		return retry.Retryable(fmt.Errorf("failed to send request to singlefile service: %w", err), 0)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logger.Error("Singlefile service returned non-OK status", "statusCode", resp.StatusCode)
-		return
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logger.Error("Failed to read response body", "error", err)
-		return
+		return retry.Retryable(fmt.Errorf("failed to read response body: %w", err), 0)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("Singlefile service returned non-OK status", "statusCode", resp.StatusCode)
+		return retry.FromResponse(resp, body)
 	}
 
 	if len(body) == 0 {
 		logger.Error("Received empty response from singlefile service")
-		return
+		return retry.Retryable(fmt.Errorf("received empty response from singlefile service"), 0)
 	}
 
 	fsFile, err := filesystem.NewFileFromBytes(body, fileKey)
 	if err != nil {
 		logger.Error("Failed to create archive file", "error", err)
-		return
+		return fmt.Errorf("failed to create archive file: %w", err)
 	}
 
 	err = app.RunInTransaction(func(txApp core.App) error {
-		updatedLink, err := txApp.FindRecordById("links", linkID)
+		updatedLink, err := txApp.FindRecordById("links", link.Id)
 		if err != nil {
 			return err
 		}
 		updatedLink.Set("archive", fsFile)
+		linkstatus.Apply(updatedLink, linkstatus.StageArchive, linkstatus.Succeeded, nil)
 		if err := txApp.Save(updatedLink); err != nil {
 			return err
 		}
@@ -139,10 +163,10 @@ func MaybeArchiveLink(app core.App, linkID string) {
 
 	if err != nil {
 		logger.Error("Failed to update link with archive information", "error", err)
-		return
+		return fmt.Errorf("failed to update link: %w", err)
 	}
 
-	logger.Info("Successfully created archive for link")
+	return nil
 }
 
 func getUserCookiesJSON(app core.App, userID string, urlStr string) (string, error) {
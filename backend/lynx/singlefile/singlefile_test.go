@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/pocketbase/pocketbase/core"
@@ -57,6 +58,9 @@ func TestMaybeArchiveLink(t *testing.T) {
 				if link.GetString("archive") != "" {
 					t.Error("Expected archive field to be empty, but it was set")
 				}
+				if link.GetString("archive_status") != "skipped" {
+					t.Errorf("Expected archive_status to be skipped, got %q", link.GetString("archive_status"))
+				}
 				if hit {
 					t.Error("Expected server not to be hit, but it was")
 				}
@@ -94,6 +98,9 @@ func TestMaybeArchiveLink(t *testing.T) {
 				if !exists {
 					t.Error("Expected archive file to exist, but it doesn't")
 				}
+				if link.GetString("archive_status") != "succeeded" {
+					t.Errorf("Expected archive_status to be succeeded, got %q", link.GetString("archive_status"))
+				}
 				if !hit {
 					t.Error("Expected server to be hit, but it wasn't")
 				}
@@ -116,6 +123,12 @@ func TestMaybeArchiveLink(t *testing.T) {
 				if link.GetString("archive") != "" {
 					t.Error("Expected archive field to be empty, but it was set")
 				}
+				if link.GetString("archive_status") != "failed" {
+					t.Errorf("Expected archive_status to be failed, got %q", link.GetString("archive_status"))
+				}
+				if !strings.Contains(link.GetString("archive_error"), "status 500") {
+					t.Errorf("Expected archive_error to mention the status code, got %q", link.GetString("archive_error"))
+				}
 				if !hit {
 					t.Error("Expected server to be hit, but it wasn't")
 				}
@@ -0,0 +1,98 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	StyleConcise      = "concise"
+	StyleTLDR         = "tldr"
+	StyleBullets      = "bullets"
+	StyleKeyTakeaways = "key_takeaways"
+	StyleCustom       = "custom"
+)
+
+// Placeholders available in custom prompt templates
+const (
+	titlePlaceholder = "{{title}}"
+	textPlaceholder  = "{{text}}"
+)
+
+type stylePrompt struct {
+	system      string
+	instruction string
+	// Used instead of system and instruction when summarizing the
+	// section summaries of a long article, if set
+	sectionsSystem      string
+	sectionsInstruction string
+}
+
+var stylePrompts = map[string]stylePrompt{
+	StyleConcise: {
+		system:      systemPrompt,
+		instruction: "Please summarize the following text:",
+	},
+	StyleTLDR: {
+		system:      "You are a helpful assistant that summarizes articles. Respond with a single sentence TL;DR that captures the core point of the article, and nothing else.",
+		instruction: "Please write a one sentence TL;DR of the following text:",
+	},
+	StyleBullets: {
+		system:      "You are a helpful assistant that summarizes articles. Respond with a Markdown bullet list of the 3 to 7 most important points, one short sentence each, and nothing else.",
+		instruction: "Please summarize the following text as a bullet list:",
+	},
+	StyleKeyTakeaways: {
+		system:      "You are a helpful assistant that summarizes articles. Respond with a Markdown bullet list of the key takeaways. Follow each takeaway with a short supporting quote copied word for word from the text, formatted as a nested > blockquote. Never invent or paraphrase quotes.",
+		instruction: "Please list the key takeaways of the following text, with supporting quotes:",
+		// Section summaries don't have the article's wording, so any
+		// quotes taken from them would be made up
+		sectionsSystem:      "You are a helpful assistant that summarizes articles. Respond with a Markdown bullet list of the key takeaways, and nothing else. Don't include quotes.",
+		sectionsInstruction: "Please list the key takeaways of the article:",
+	},
+}
+
+// normalizeStyle returns the style that will actually be used, falling
+// back to a concise summary for unknown styles or an empty template.
+func normalizeStyle(style string, template string) string {
+	if style == StyleCustom && strings.TrimSpace(template) != "" {
+		return StyleCustom
+	}
+	if _, ok := stylePrompts[style]; ok {
+		return style
+	}
+	return StyleConcise
+}
+
+// renderPrompt builds the system prompt and user message for the final
+// summary. fromSections is set when text is the combined summaries of
+// a long article's sections rather than the article itself.
+func (s *Summarizer) renderPrompt(title string, text string, fromSections bool) (string, string) {
+	if fromSections {
+		text = "(The article was too long to include in full, so these are summaries of its consecutive sections.)\n\n" + text
+	}
+
+	if normalizeStyle(s.Style, s.PromptTemplate) == StyleCustom {
+		// Replaced in one pass so placeholders in the title or text are
+		// left alone
+		template := s.PromptTemplate
+		if !strings.Contains(template, textPlaceholder) {
+			template += "\n\n" + textPlaceholder
+		}
+		prompt := strings.NewReplacer(titlePlaceholder, title, textPlaceholder, text).Replace(template)
+		return "You are a helpful assistant that summarizes articles.", prompt
+	}
+
+	style := stylePrompts[normalizeStyle(s.Style, s.PromptTemplate)]
+	system, instruction := style.system, style.instruction
+	if fromSections {
+		if style.sectionsSystem != "" {
+			system, instruction = style.sectionsSystem, style.sectionsInstruction
+		} else {
+			instruction = strings.Replace(instruction, "the following text", "the article", 1)
+		}
+	}
+	if title != "" {
+		text = fmt.Sprintf("Title: %s\n\n%s", title, text)
+	}
+	return system, fmt.Sprintf("%s\n\n%s", instruction, text)
+}
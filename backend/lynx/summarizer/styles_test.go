@@ -0,0 +1,118 @@
+package summarizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeTextStyles(t *testing.T) {
+	testCases := []struct {
+		name             string
+		style            string
+		template         string
+		expectedStyle    string
+		expectedSystem   string
+		expectedContains []string
+	}{
+		{
+			name:             "Default",
+			expectedStyle:    StyleConcise,
+			expectedSystem:   systemPrompt,
+			expectedContains: []string{"Please summarize the following text:\n\nTitle: On Gardens\n\nSome text"},
+		},
+		{
+			name:             "TL;DR",
+			style:            StyleTLDR,
+			expectedStyle:    StyleTLDR,
+			expectedSystem:   stylePrompts[StyleTLDR].system,
+			expectedContains: []string{"one sentence TL;DR", "Some text"},
+		},
+		{
+			name:             "Bullets",
+			style:            StyleBullets,
+			expectedStyle:    StyleBullets,
+			expectedSystem:   stylePrompts[StyleBullets].system,
+			expectedContains: []string{"as a bullet list", "Some text"},
+		},
+		{
+			name:             "Key takeaways",
+			style:            StyleKeyTakeaways,
+			expectedStyle:    StyleKeyTakeaways,
+			expectedSystem:   stylePrompts[StyleKeyTakeaways].system,
+			expectedContains: []string{"key takeaways", "with supporting quotes", "Some text"},
+		},
+		{
+			name:             "Custom template",
+			style:            StyleCustom,
+			template:         "Explain \"{{title}}\" to a child:\n{{text}}\nKeep it short.",
+			expectedStyle:    StyleCustom,
+			expectedContains: []string{"Explain \"On Gardens\" to a child:\nSome text\nKeep it short."},
+		},
+		{
+			name:             "Custom template without a text placeholder",
+			style:            StyleCustom,
+			template:         "Summarize {{title}} in French.",
+			expectedStyle:    StyleCustom,
+			expectedContains: []string{"Summarize On Gardens in French.\n\nSome text"},
+		},
+		{
+			name:             "Custom style without a template",
+			style:            StyleCustom,
+			expectedStyle:    StyleConcise,
+			expectedSystem:   systemPrompt,
+			expectedContains: []string{"Please summarize the following text:"},
+		},
+		{
+			name:           "Unknown style",
+			style:          "haiku",
+			expectedStyle:  StyleConcise,
+			expectedSystem: systemPrompt,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &fakeProvider{content: "Summary."}
+			summarizer := &Summarizer{Provider: provider, Style: tc.style, PromptTemplate: tc.template}
+
+			result, err := summarizer.SummarizeText("On Gardens", "Some text", "openai/gpt-4o")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStyle, result.Style)
+
+			require.Len(t, provider.requests, 1)
+			req := provider.requests[0]
+			if tc.expectedSystem != "" {
+				assert.Equal(t, tc.expectedSystem, req.System)
+			}
+			for _, expected := range tc.expectedContains {
+				assert.Contains(t, req.Messages[0].Content, expected)
+			}
+		})
+	}
+}
+
+func TestKeyTakeawaysFromSections(t *testing.T) {
+	summarizer := &Summarizer{Style: StyleKeyTakeaways}
+
+	system, prompt := summarizer.renderPrompt("On Gardens", "Some text", false)
+	assert.Contains(t, system, "word for word")
+	assert.Contains(t, prompt, "with supporting quotes")
+
+	// Section summaries don't have the article's wording to quote
+	system, prompt = summarizer.renderPrompt("On Gardens", "Section summaries", true)
+	assert.NotContains(t, system, "quote copied")
+	assert.Contains(t, system, "Don't include quotes.")
+	assert.Contains(t, prompt, "Please list the key takeaways of the article:")
+	assert.NotContains(t, prompt, "supporting quotes")
+}
+
+func TestCustomTemplatePlaceholdersInTitle(t *testing.T) {
+	summarizer := &Summarizer{Style: StyleCustom, PromptTemplate: "Summarize {{title}}:\n{{text}}"}
+
+	// The article body isn't pasted into a title that happens to
+	// contain a placeholder
+	_, prompt := summarizer.renderPrompt("Using {{text}} in templates", "Some text", false)
+	assert.Equal(t, "Summarize Using {{text}} in templates:\nSome text", prompt)
+}
@@ -1,22 +1,38 @@
 package summarizer
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+
+	"main/lynx/linkstatus"
+	"main/lynx/llm"
+)
+
+// Overridden in tests to point at a mock server
+var newProvider = llm.FromSettings
+
+var (
+	ErrSettingsNotFound = errors.New("user_settings not found for user")
+	ErrMissingModel     = errors.New("summarization model not set for user")
+	ErrMissingAPIKey    = llm.ErrMissingAPIKey
 )
 
-func MaybeSummarizeLink(app core.App, linkID string) {
+func MaybeSummarizeLink(app core.App, linkID string) error {
 	logger := app.Logger().With("action", "summarizeLink", "linkID", linkID)
 
 	link, err := app.FindRecordById("links", linkID)
 	if err != nil {
 		logger.Error("Summarization failed, failed to find link", "error", err)
-		return
+		return fmt.Errorf("failed to find link: %w", err)
 	}
 
 	if link.GetString("summary") != "" {
 		logger.Info("Summarization skipped, link already has a summary")
-		return
+		return nil
 	}
 
 	userID := link.GetString("user")
@@ -26,41 +42,102 @@ func MaybeSummarizeLink(app core.App, linkID string) {
 		})
 	if err != nil {
 		logger.Info("Summarization skipped, user_settings not found", "userID", userID)
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageSummary, linkstatus.Skipped, nil)
+		return nil
 	}
 
 	if !userSettings.GetBool("automatically_summarize_new_links") {
 		logger.Info("Summarization skipped, Automatic summarization is disabled for user", "userID", userID)
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageSummary, linkstatus.Skipped, nil)
+		return nil
 	}
 
 	summarizationModel := userSettings.GetString("summarize_model")
 	if summarizationModel == "" {
 		logger.Info("Summarization skipped, summarization model not set for user", "userID", userID)
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageSummary, linkstatus.Skipped, nil)
+		return nil
+	}
+
+	provider, err := newProvider(userSettings)
+	if err != nil {
+		logger.Error("Summarization failed, LLM provider not configured for user", "userID", userID, "error", err)
+		linkstatus.Record(app, linkID, linkstatus.StageSummary, linkstatus.Failed, err)
+		return err
+	}
+
+	if _, err := summarizeAndSave(app, logger, link, newSummarizerForSettings(provider, userSettings), summarizationModel); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SummarizeLink generates a new summary for the link even if it
+// already has one or automatic summarization is disabled. The old
+// summary is kept in previous_summary so the two can be compared.
+// If model is empty, the user's summarize_model setting is used.
+func SummarizeLink(app core.App, linkID string, model string) (string, error) {
+	logger := app.Logger().With("action", "resummarizeLink", "linkID", linkID)
+
+	link, err := app.FindRecordById("links", linkID)
+	if err != nil {
+		return "", fmt.Errorf("failed to find link: %w", err)
+	}
+
+	userSettings, err := app.FindFirstRecordByFilter("user_settings", "user = {:user}",
+		dbx.Params{
+			"user": link.GetString("user"),
+		})
+	if err != nil {
+		return "", ErrSettingsNotFound
+	}
+
+	if model == "" {
+		model = userSettings.GetString("summarize_model")
+	}
+	if model == "" {
+		return "", ErrMissingModel
 	}
 
-	apiKey := userSettings.GetString("openrouter_api_key")
-	if apiKey == "" {
-		logger.Error("Summarization failed, OpenRouter API key not set for user", "userID", userID)
-		return
+	provider, err := newProvider(userSettings)
+	if err != nil {
+		return "", err
 	}
 
-	summarizer := NewOpenRouterSummarizer()
-	summary, err := summarizer.SummarizeText(link.GetString("raw_text_content"), apiKey, summarizationModel)
+	return summarizeAndSave(app, logger, link, newSummarizerForSettings(provider, userSettings), model)
+}
+
+func newSummarizerForSettings(provider llm.Provider, userSettings *core.Record) *Summarizer {
+	return &Summarizer{
+		Provider:       provider,
+		MaxInputChars:  userSettings.GetInt("summarize_max_input_chars"),
+		Style:          userSettings.GetString("summary_style"),
+		PromptTemplate: userSettings.GetString("summary_prompt_template"),
+	}
+}
+
+func summarizeAndSave(app core.App, logger *slog.Logger, link *core.Record, summarizer *Summarizer, model string) (string, error) {
+	result, err := summarizer.SummarizeText(link.GetString("title"), link.GetString("raw_text_content"), model)
 
 	if err != nil {
 		logger.Error("Summarization failed", "error", err)
-		return
+		linkstatus.Record(app, link.Id, linkstatus.StageSummary, linkstatus.Failed, err)
+		return "", err
 	}
 
 	err = app.RunInTransaction(func(txApp core.App) error {
-		updatedLink, err := txApp.FindRecordById("links", linkID)
+		updatedLink, err := txApp.FindRecordById("links", link.Id)
 		if err != nil {
 			return err
 		}
 
-		updatedLink.Set("summary", summary)
+		if previousSummary := updatedLink.GetString("summary"); previousSummary != "" {
+			updatedLink.Set("previous_summary", previousSummary)
+		}
+		updatedLink.Set("summary", result.Summary)
+		updatedLink.Set("summary_style", result.Style)
+		updatedLink.Set("summary_metadata", result)
+		linkstatus.Apply(updatedLink, linkstatus.StageSummary, linkstatus.Succeeded, nil)
 		if err := txApp.Save(updatedLink); err != nil {
 			return err
 		}
@@ -69,8 +146,9 @@ func MaybeSummarizeLink(app core.App, linkID string) {
 	})
 	if err != nil {
 		logger.Error("Summarization failed, failed to update link", "error", err)
-		return
+		return "", fmt.Errorf("failed to update link: %w", err)
 	}
 
-	logger.Info("Successfully summarized link", "model", summarizationModel)
+	logger.Info("Successfully summarized link", "model", model, "chunks", result.Chunks, "promptTokens", result.Usage.PromptTokens, "completionTokens", result.Usage.CompletionTokens)
+	return result.Summary, nil
 }
@@ -0,0 +1,116 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/llm"
+)
+
+const testLinkID = "8n3iq8dt6vwi4ph"
+
+func createUserSettings(t *testing.T, app core.App, userID string, values map[string]any) {
+	collection, err := app.FindCollectionByNameOrId("user_settings")
+	require.NoError(t, err)
+
+	settings := core.NewRecord(collection)
+	settings.Set("user", userID)
+	for key, value := range values {
+		settings.Set(key, value)
+	}
+	require.NoError(t, app.Save(settings))
+}
+
+func TestSummarizeLink(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	var requestedModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestBody map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+		requestedModel, _ = requestBody["model"].(string)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": "A brand new summary."}},
+			},
+			"usage": map[string]int{"prompt_tokens": 250, "completion_tokens": 40},
+		})
+	}))
+	defer server.Close()
+
+	originalNewProvider := newProvider
+	newProvider = func(settings *core.Record) (llm.Provider, error) {
+		provider, err := llm.FromSettings(settings)
+		if err != nil {
+			return nil, err
+		}
+		provider.(*llm.OpenAICompatible).APIURL = server.URL
+		return provider, nil
+	}
+	t.Cleanup(func() {
+		newProvider = originalNewProvider
+	})
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("summary", "An old summary.")
+	link.Set("raw_text_content", "The full text of the article.")
+	require.NoError(t, testApp.Save(link))
+
+	// No settings at all
+	_, err = SummarizeLink(testApp, testLinkID, "")
+	assert.True(t, errors.Is(err, ErrSettingsNotFound))
+
+	// Automatic summarization is disabled, but forcing a summary
+	// should still work.
+	createUserSettings(t, testApp, link.GetString("user"), map[string]any{
+		"summarize_model":                   "anthropic/claude-3-haiku",
+		"automatically_summarize_new_links": false,
+	})
+	_, err = SummarizeLink(testApp, testLinkID, "")
+	assert.True(t, errors.Is(err, ErrMissingAPIKey))
+
+	settings, err := testApp.FindFirstRecordByData("user_settings", "user", link.GetString("user"))
+	require.NoError(t, err)
+	settings.Set("openrouter_api_key", "test_api_key")
+	require.NoError(t, testApp.Save(settings))
+
+	summary, err := SummarizeLink(testApp, testLinkID, "openai/gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "A brand new summary.", summary)
+	assert.Equal(t, "openai/gpt-4o", requestedModel)
+
+	link, err = testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	assert.Equal(t, "A brand new summary.", link.GetString("summary"))
+	assert.Equal(t, "An old summary.", link.GetString("previous_summary"))
+	assert.Equal(t, "succeeded", link.GetString("summary_status"))
+	assert.Equal(t, StyleConcise, link.GetString("summary_style"))
+
+	var metadata Result
+	require.NoError(t, link.UnmarshalJSONField("summary_metadata", &metadata))
+	assert.Equal(t, Result{
+		Model:      "openai/gpt-4o",
+		InputChars: 29,
+		Chunks:     1,
+		Requests:   1,
+		Usage:      llm.Usage{PromptTokens: 250, CompletionTokens: 40},
+	}, metadata)
+
+	// Without an override the user's model is used
+	_, err = SummarizeLink(testApp, testLinkID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic/claude-3-haiku", requestedModel)
+}
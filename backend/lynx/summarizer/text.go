@@ -0,0 +1,226 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"main/lynx/llm"
+)
+
+const systemPrompt = "You are a helpful assistant that summarizes articles. Provide a concise summary that captures the main points and key insights."
+
+const chunkSystemPrompt = "You are a helpful assistant that summarizes long articles one section at a time. Summarize the section you are given, keeping the important facts, arguments and names so the section summaries can later be combined."
+
+const (
+	// Summaries of whole articles and of individual chunks
+	summaryMaxTokens = 500
+	// Rough characters per token. Good enough for deciding where to
+	// split text without depending on each model's tokenizer.
+	charsPerToken = 4
+	// Most models handle far more than this, but smaller requests are
+	// cheaper, more reliable and fit self-hosted models too.
+	DefaultChunkTokens = 8000
+	// Longer input is truncated before summarizing. Roughly a 400 page
+	// book, which is already a lot of requests.
+	DefaultMaxInputChars = 1_000_000
+)
+
+type Summarizer struct {
+	Provider llm.Provider
+	// Zero means DefaultChunkTokens
+	ChunkTokens int
+	// Zero means DefaultMaxInputChars
+	MaxInputChars int
+	// One of the Style* constants, defaulting to StyleConcise
+	Style string
+	// Used with StyleCustom. {{title}} and {{text}} are replaced with
+	// the article's title and text.
+	PromptTemplate string
+}
+
+// Result is a summary along with details of how it was generated,
+// which are stored on the link as summary_metadata.
+type Result struct {
+	Summary    string    `json:"-"`
+	Style      string    `json:"-"`
+	Model      string    `json:"model"`
+	InputChars int       `json:"input_chars"`
+	Truncated  bool      `json:"truncated"`
+	Chunks     int       `json:"chunks"`
+	Requests   int       `json:"requests"`
+	Usage      llm.Usage `json:"usage"`
+}
+
+// SummarizeText summarizes the text in a single request if it fits in
+// one chunk. Longer text is split into chunks that are summarized
+// individually (map) and then combined into one summary (reduce).
+func (s *Summarizer) SummarizeText(title string, text string, model string) (*Result, error) {
+	if text == "" {
+		return nil, fmt.Errorf("empty input text")
+	}
+
+	maxInputChars := s.MaxInputChars
+	if maxInputChars <= 0 {
+		maxInputChars = DefaultMaxInputChars
+	}
+	chunkTokens := s.ChunkTokens
+	if chunkTokens <= 0 {
+		chunkTokens = DefaultChunkTokens
+	}
+	chunkChars := chunkTokens * charsPerToken
+
+	result := &Result{Model: model, Style: normalizeStyle(s.Style, s.PromptTemplate)}
+	text, result.Truncated = truncate(text, maxInputChars)
+	result.InputChars = utf8.RuneCountInString(text)
+
+	chunks := splitIntoChunks(text, chunkChars)
+	result.Chunks = len(chunks)
+
+	if len(chunks) == 1 {
+		system, prompt := s.renderPrompt(title, text, false)
+		summary, err := s.complete(result, system, prompt)
+		if err != nil {
+			return nil, err
+		}
+		result.Summary = summary
+		return result, nil
+	}
+
+	// Map: summarize every chunk on its own
+	summaries := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		summary, err := s.complete(result, chunkSystemPrompt, fmt.Sprintf("This is part %d of %d of an article. Please summarize it:\n\n%s", i+1, len(chunks), chunk))
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize part %d of %d: %w", i+1, len(chunks), err)
+		}
+		summaries[i] = summary
+	}
+
+	// Reduce: combine the section summaries, in several rounds if they
+	// still don't fit in a single request.
+	for {
+		combined := strings.Join(summaries, "\n\n")
+		if len(combined) <= chunkChars {
+			system, prompt := s.renderPrompt(title, combined, true)
+			summary, err := s.complete(result, system, prompt)
+			if err != nil {
+				return nil, err
+			}
+			result.Summary = summary
+			return result, nil
+		}
+
+		groups := splitIntoChunks(combined, chunkChars)
+		if len(groups) >= len(summaries) {
+			// Summaries aren't getting any shorter, so stop here
+			// rather than looping forever.
+			return nil, fmt.Errorf("section summaries are too long to combine")
+		}
+		summaries = make([]string, len(groups))
+		for i, group := range groups {
+			summary, err := s.complete(result, chunkSystemPrompt, fmt.Sprintf("The following are summaries of consecutive sections of an article. Please condense them into one summary:\n\n%s", group))
+			if err != nil {
+				return nil, err
+			}
+			summaries[i] = summary
+		}
+	}
+}
+
+func (s *Summarizer) complete(result *Result, system string, prompt string) (string, error) {
+	resp, err := s.Provider.Complete(llm.Request{
+		Model:  result.Model,
+		System: system,
+		Messages: []llm.Message{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		MaxTokens: summaryMaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	result.Requests++
+	result.Usage.PromptTokens += resp.Usage.PromptTokens
+	result.Usage.CompletionTokens += resp.Usage.CompletionTokens
+	return resp.Content, nil
+}
+
+// truncate shortens text to at most maxChars characters, preferring
+// to cut at a paragraph break.
+func truncate(text string, maxChars int) (string, bool) {
+	if utf8.RuneCountInString(text) <= maxChars {
+		return text, false
+	}
+	runes := []rune(text)
+	truncated := string(runes[:maxChars])
+	if i := strings.LastIndex(truncated, "\n\n"); i > len(truncated)/2 {
+		truncated = truncated[:i]
+	}
+	return truncated, true
+}
+
+// splitIntoChunks splits text into pieces of at most maxChars bytes,
+// breaking between paragraphs where possible, then between lines or
+// words, and only mid-word as a last resort.
+func splitIntoChunks(text string, maxChars int) []string {
+	if len(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, piece := range splitPieces(text, maxChars, []string{"\n\n", "\n", " "}) {
+		// Trailing whitespace is trimmed when the chunk is flushed, so
+		// it doesn't count towards the limit.
+		if current.Len() > 0 && current.Len()+len(strings.TrimRightFunc(piece, unicode.IsSpace)) > maxChars {
+			flush()
+		}
+		current.WriteString(piece)
+	}
+	flush()
+	return chunks
+}
+
+// splitPieces breaks text into pieces no longer than maxChars, each
+// keeping its trailing separator so they can be joined back together.
+func splitPieces(text string, maxChars int, separators []string) []string {
+	for i, sep := range separators {
+		if !strings.Contains(text, sep) {
+			continue
+		}
+		var pieces []string
+		for _, part := range strings.SplitAfter(text, sep) {
+			if len(part) <= maxChars {
+				pieces = append(pieces, part)
+			} else {
+				pieces = append(pieces, splitPieces(part, maxChars, separators[i+1:])...)
+			}
+		}
+		return pieces
+	}
+
+	// No separators left, so cut on character boundaries
+	var pieces []string
+	for len(text) > maxChars {
+		cut := maxChars
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		pieces = append(pieces, text[:cut])
+		text = text[cut:]
+	}
+	return append(pieces, text)
+}
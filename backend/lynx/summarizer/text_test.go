@@ -0,0 +1,137 @@
+package summarizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/llm"
+)
+
+type fakeProvider struct {
+	requests []llm.Request
+	content  string
+	usage    llm.Usage
+	err      error
+}
+
+func (p *fakeProvider) Complete(req llm.Request) (*llm.Response, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &llm.Response{Content: p.content, Usage: p.usage}, nil
+}
+
+func (p *fakeProvider) SupportsSchema(model string) (bool, error) {
+	return true, nil
+}
+
+func TestSummarizeText(t *testing.T) {
+	provider := &fakeProvider{
+		content: "This is a summary of the text.",
+		usage:   llm.Usage{PromptTokens: 40, CompletionTokens: 10},
+	}
+	summarizer := &Summarizer{Provider: provider}
+
+	result, err := summarizer.SummarizeText("", "This is a long text that needs to be summarized.", "anthropic/claude-3-sonnet")
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Summary:    "This is a summary of the text.",
+		Style:      StyleConcise,
+		Model:      "anthropic/claude-3-sonnet",
+		InputChars: 48,
+		Chunks:     1,
+		Requests:   1,
+		Usage:      llm.Usage{PromptTokens: 40, CompletionTokens: 10},
+	}, result)
+
+	require.Len(t, provider.requests, 1)
+	req := provider.requests[0]
+	assert.Equal(t, "anthropic/claude-3-sonnet", req.Model)
+	assert.Equal(t, 500, req.MaxTokens)
+	assert.Equal(t, systemPrompt, req.System)
+	require.Len(t, req.Messages, 1)
+	assert.Equal(t, "user", req.Messages[0].Role)
+	assert.Contains(t, req.Messages[0].Content, "This is a long text that needs to be summarized.")
+}
+
+func TestSummarizeTextErrors(t *testing.T) {
+	provider := &fakeProvider{}
+	summarizer := &Summarizer{Provider: provider}
+
+	_, err := summarizer.SummarizeText("", "", "openai/gpt-4o")
+	assert.EqualError(t, err, "empty input text")
+	assert.Empty(t, provider.requests)
+
+	provider.err = errors.New("API request failed with status 401: Invalid API key")
+	_, err = summarizer.SummarizeText("", "Some text", "openai/gpt-4o")
+	assert.ErrorContains(t, err, "API request failed with status 401")
+}
+
+func TestSummarizeTextInChunks(t *testing.T) {
+	provider := &fakeProvider{
+		content: "Section summary.",
+		usage:   llm.Usage{PromptTokens: 100, CompletionTokens: 20},
+	}
+	// 10 tokens is about 40 characters per chunk
+	summarizer := &Summarizer{Provider: provider, ChunkTokens: 10}
+
+	text := "First paragraph of the article.\n\nSecond paragraph of the article.\n\nThird paragraph of the article."
+	result, err := summarizer.SummarizeText("", text, "openai/gpt-4o")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Section summary.", result.Summary)
+	assert.Equal(t, 3, result.Chunks)
+	// Three chunks, then the three summaries are too long for one
+	// request so they are condensed in pairs before the final summary.
+	assert.Equal(t, 6, result.Requests)
+	assert.Equal(t, llm.Usage{PromptTokens: 600, CompletionTokens: 120}, result.Usage)
+
+	require.Len(t, provider.requests, 6)
+	assert.Equal(t, chunkSystemPrompt, provider.requests[0].System)
+	assert.Contains(t, provider.requests[0].Messages[0].Content, "part 1 of 3")
+	assert.Contains(t, provider.requests[0].Messages[0].Content, "First paragraph of the article.")
+	assert.Contains(t, provider.requests[2].Messages[0].Content, "Third paragraph of the article.")
+	last := provider.requests[5]
+	assert.Equal(t, systemPrompt, last.System)
+	assert.Contains(t, last.Messages[0].Content, "Please summarize the article:")
+	assert.Contains(t, last.Messages[0].Content, "summaries of its consecutive sections")
+}
+
+func TestSummarizeTextTruncatesInput(t *testing.T) {
+	provider := &fakeProvider{content: "Summary."}
+	summarizer := &Summarizer{Provider: provider, MaxInputChars: 20}
+
+	result, err := summarizer.SummarizeText("", strings.Repeat("é", 50), "openai/gpt-4o")
+	require.NoError(t, err)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, 20, result.InputChars)
+	assert.Contains(t, provider.requests[0].Messages[0].Content, strings.Repeat("é", 20))
+	assert.NotContains(t, provider.requests[0].Messages[0].Content, strings.Repeat("é", 21))
+}
+
+func TestSplitIntoChunks(t *testing.T) {
+	assert.Equal(t, []string{"short"}, splitIntoChunks("short", 10))
+
+	// Paragraphs are kept together when they fit
+	assert.Equal(t,
+		[]string{"one two\n\nthree", "four five six"},
+		splitIntoChunks("one two\n\nthree\n\nfour five six", 15),
+	)
+
+	// Long paragraphs fall back to words, and long words to characters
+	assert.Equal(t,
+		[]string{"alpha beta", "gamma", "abcdefghij", "klm"},
+		splitIntoChunks("alpha beta gamma abcdefghijklm", 10),
+	)
+
+	for _, chunk := range splitIntoChunks(strings.Repeat("日本語 ", 100), 16) {
+		assert.LessOrEqual(t, len(chunk), 16)
+		assert.True(t, utf8.ValidString(chunk))
+	}
+}
@@ -0,0 +1,90 @@
+package tagger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var ErrTagNotSuggested = errors.New("tag was not suggested for this link")
+
+var (
+	slugInvalidChars = regexp.MustCompile(`[^\w\s-]`)
+	slugWhitespace   = regexp.MustCompile(`\s+`)
+)
+
+// Slugify generates a tag slug the same way the web client does when
+// a user creates a tag by hand.
+func Slugify(name string) string {
+	slug := strings.ToLower(strings.TrimSpace(name))
+	slug = slugInvalidChars.ReplaceAllString(slug, "")
+	return slugWhitespace.ReplaceAllString(slug, "-")
+}
+
+// AcceptNewTag turns one of the link's suggested_new_tags into a real
+// tag and adds it to the link. If the user already has a tag with the
+// same name or slug (e.g. it was created from another link), that tag
+// is reused instead.
+func AcceptNewTag(app core.App, linkID string, name string) (*core.Record, error) {
+	var tag *core.Record
+	err := app.RunInTransaction(func(txApp core.App) error {
+		link, err := txApp.FindRecordById("links", linkID)
+		if err != nil {
+			return fmt.Errorf("failed to find link: %w", err)
+		}
+
+		var suggested []string
+		if err := link.UnmarshalJSONField("suggested_new_tags", &suggested); err != nil {
+			suggested = nil
+		}
+
+		var remaining []string
+		accepted := ""
+		for _, suggestion := range suggested {
+			if accepted == "" && strings.EqualFold(suggestion, strings.TrimSpace(name)) {
+				accepted = suggestion
+				continue
+			}
+			remaining = append(remaining, suggestion)
+		}
+		if accepted == "" {
+			return ErrTagNotSuggested
+		}
+
+		userID := link.GetString("user")
+		slug := Slugify(accepted)
+		tag, err = txApp.FindFirstRecordByFilter(
+			"tags",
+			"user = {:user} && (name = {:name} || slug = {:slug})",
+			dbx.Params{"user": userID, "name": accepted, "slug": slug},
+		)
+		if err != nil {
+			collection, err := txApp.FindCollectionByNameOrId("tags")
+			if err != nil {
+				return err
+			}
+			tag = core.NewRecord(collection)
+			tag.Set("user", userID)
+			tag.Set("name", accepted)
+			tag.Set("slug", slug)
+			if err := txApp.Save(tag); err != nil {
+				return fmt.Errorf("failed to create tag: %w", err)
+			}
+		}
+
+		link.Set("tags+", tag.Id)
+		if remaining == nil {
+			remaining = []string{}
+		}
+		link.Set("suggested_new_tags", remaining)
+		return txApp.Save(link)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
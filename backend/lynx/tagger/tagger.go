@@ -1,24 +1,36 @@
 package tagger
 
 import (
+	"fmt"
+
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/types"
+
+	"main/lynx/linkstatus"
+	"main/lynx/llm"
 )
 
-func MaybeSuggestTagsForLink(app core.App, linkID string) {
+// Used with OpenRouter when the user hasn't picked a tagging model.
+// Other providers fall back to the user's summarization model.
+const defaultOpenRouterTaggingModel = "openai/gpt-4o-mini"
+
+// Overridden in tests to point at a mock server
+var newProvider = llm.FromSettings
+
+func MaybeSuggestTagsForLink(app core.App, linkID string) error {
 	logger := app.Logger().With("action", "suggestTags", "linkID", linkID)
 
 	link, err := app.FindRecordById("links", linkID)
 	if err != nil {
 		logger.Error("Tag suggestion failed, failed to find link", "error", err)
-		return
+		return fmt.Errorf("failed to find link: %w", err)
 	}
 
 	// Check if tags_suggested_at is already set
 	if !link.GetDateTime("tags_suggested_at").IsZero() {
 		logger.Info("Tag suggestion skipped, suggestions have already been generated")
-		return
+		return nil
 	}
 
 	userID := link.GetString("user")
@@ -28,19 +40,29 @@ func MaybeSuggestTagsForLink(app core.App, linkID string) {
 		})
 	if err != nil {
 		logger.Info("Tag suggestion skipped, user_settings not found", "userID", userID)
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Skipped, nil)
+		return nil
 	}
 
 	if !userSettings.GetBool("automatically_suggest_tags_for_new_links") {
 		logger.Info("Tag suggestion skipped, automatic tag suggestion is disabled for user", "userID", userID)
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Skipped, nil)
+		return nil
+	}
+
+	provider, err := newProvider(userSettings)
+	if err != nil {
+		logger.Error("Tag suggestion failed, LLM provider not configured for user", "userID", userID, "error", err)
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Failed, err)
+		return err
 	}
 
-	taggingModel := "openai/gpt-4o-mini"
-	apiKey := userSettings.GetString("openrouter_api_key")
-	if apiKey == "" {
-		logger.Error("Tag suggestion failed, OpenRouter API key not set for user", "userID", userID)
-		return
+	taggingModel := taggingModelForSettings(userSettings)
+	if taggingModel == "" {
+		logger.Error("Tag suggestion failed, no model set for user", "userID", userID)
+		err := fmt.Errorf("tagging model not set for user")
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Failed, err)
+		return err
 	}
 
 	// Fetch existing tags for the user
@@ -50,7 +72,9 @@ func MaybeSuggestTagsForLink(app core.App, linkID string) {
 		})
 	if err != nil {
 		logger.Error("Tag suggestion failed, failed to fetch existing tags", "error", err)
-		return
+		err = fmt.Errorf("failed to fetch existing tags: %w", err)
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Failed, err)
+		return err
 	}
 
 	var existingTags []string
@@ -61,7 +85,8 @@ func MaybeSuggestTagsForLink(app core.App, linkID string) {
 		tagNameToID[tagName] = tagRecord.Id
 	}
 
-	if len(existingTags) == 0 {
+	allowNewTags := userSettings.GetBool("allow_new_tag_suggestions")
+	if len(existingTags) == 0 && !allowNewTags {
 		logger.Info("Tag suggestion skipped, user has no existing tags")
 		// Even if no tags are suggested because the user has none, we should mark that we've processed it.
 		err = app.RunInTransaction(func(txApp core.App) error {
@@ -70,6 +95,7 @@ func MaybeSuggestTagsForLink(app core.App, linkID string) {
 				return err
 			}
 			updatedLink.Set("tags_suggested_at", types.NowDateTime())
+			linkstatus.Apply(updatedLink, linkstatus.StageTags, linkstatus.Succeeded, nil)
 			if err := txApp.Save(updatedLink); err != nil {
 				return err
 			}
@@ -77,35 +103,61 @@ func MaybeSuggestTagsForLink(app core.App, linkID string) {
 		})
 		if err != nil {
 			logger.Error("Tag suggestion failed, failed to update link's tags_suggested_at after no existing tags", "error", err)
+			return fmt.Errorf("failed to update link: %w", err)
 		}
-		return
+		return nil
 	}
 
-	tagger := NewOpenRouterTagger()
-	suggestedTags, err := tagger.SuggestTags(link.GetString("raw_text_content"), existingTags, apiKey, taggingModel)
+	tagger := &Tagger{Provider: provider, Logger: logger}
+	suggestions, err := tagger.SuggestTags(link.GetString("raw_text_content"), existingTags, Options{
+		Model:        taggingModel,
+		MaxTags:      userSettings.GetInt("max_suggested_tags"),
+		Instructions: userSettings.GetString("tagging_instructions"),
+		AllowNewTags: allowNewTags,
+	})
 
 	if err != nil {
 		logger.Error("Tag suggestion failed due to API error", "error", err)
-		return
+		linkstatus.Record(app, linkID, linkstatus.StageTags, linkstatus.Failed, err)
+		return err
 	}
 
+	autoApplyThreshold := userSettings.GetFloat("auto_apply_tags_threshold")
+	autoAppliedCount := 0
 	err = app.RunInTransaction(func(txApp core.App) error {
 		updatedLink, err := txApp.FindRecordById("links", linkID)
 		if err != nil {
 			return err
 		}
 
-		if len(suggestedTags) > 0 {
-			// Map suggested tag names to tag IDs
-			var suggestedTagIDs []string
-			for _, tagName := range suggestedTags {
-				if tagID, exists := tagNameToID[tagName]; exists {
+		if len(suggestions.Existing) > 0 {
+			// Map suggested tag names to tag IDs. Confident suggestions
+			// are applied directly if the user opted in, and the rest
+			// are left for the user to review.
+			var suggestedTagIDs, autoAppliedTagIDs []string
+			for _, suggestion := range suggestions.Existing {
+				tagID, exists := tagNameToID[suggestion.Name]
+				if !exists {
+					continue
+				}
+				if autoApplyThreshold > 0 && suggestion.Confidence >= autoApplyThreshold {
+					autoAppliedTagIDs = append(autoAppliedTagIDs, tagID)
+				} else {
 					suggestedTagIDs = append(suggestedTagIDs, tagID)
 				}
 			}
 			updatedLink.Set("suggested_tags", suggestedTagIDs)
+			if len(autoAppliedTagIDs) > 0 {
+				updatedLink.Set("tags+", autoAppliedTagIDs)
+				updatedLink.Set("auto_applied_tags+", autoAppliedTagIDs)
+				autoAppliedCount = len(autoAppliedTagIDs)
+			}
+		}
+		if len(suggestions.New) > 0 {
+			updatedLink.Set("suggested_new_tags", suggestions.New)
 		}
 		updatedLink.Set("tags_suggested_at", types.NowDateTime())
+		linkstatus.Apply(updatedLink, linkstatus.StageTags, linkstatus.Succeeded, nil)
 
 		if err := txApp.Save(updatedLink); err != nil {
 			return err
@@ -115,12 +167,23 @@ func MaybeSuggestTagsForLink(app core.App, linkID string) {
 	})
 	if err != nil {
 		logger.Error("Tag suggestion failed, failed to update link", "error", err)
-		return
+		return fmt.Errorf("failed to update link: %w", err)
 	}
 
-	if len(suggestedTags) == 0 {
+	if len(suggestions.Existing) == 0 && len(suggestions.New) == 0 {
 		logger.Info("No tags suggested for link, tags_suggested_at updated.")
 	} else {
-		logger.Info("Successfully suggested tags for link", "model", taggingModel, "suggestedCount", len(suggestedTags))
+		logger.Info("Successfully suggested tags for link", "model", taggingModel, "suggestedCount", len(suggestions.Existing), "autoAppliedCount", autoAppliedCount, "newCount", len(suggestions.New))
+	}
+	return nil
+}
+
+func taggingModelForSettings(userSettings *core.Record) string {
+	if model := userSettings.GetString("tagging_model"); model != "" {
+		return model
+	}
+	if llm.ProviderName(userSettings) == llm.ProviderOpenRouter {
+		return defaultOpenRouterTaggingModel
 	}
+	return userSettings.GetString("summarize_model")
 }
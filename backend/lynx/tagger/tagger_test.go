@@ -0,0 +1,143 @@
+package tagger
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/llm"
+)
+
+const testLinkID = "8n3iq8dt6vwi4ph"
+
+func setupTestApp(t *testing.T, provider llm.Provider) (*tests.TestApp, *core.Record) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+
+	originalNewProvider := newProvider
+	newProvider = func(settings *core.Record) (llm.Provider, error) {
+		return provider, nil
+	}
+	t.Cleanup(func() {
+		newProvider = originalNewProvider
+	})
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	link.Set("raw_text_content", "An article about SQLite internals.")
+	require.NoError(t, testApp.Save(link))
+
+	return testApp, link
+}
+
+func createUserSettings(t *testing.T, app core.App, userID string, values map[string]any) {
+	collection, err := app.FindCollectionByNameOrId("user_settings")
+	require.NoError(t, err)
+
+	settings := core.NewRecord(collection)
+	settings.Set("user", userID)
+	settings.Set("automatically_suggest_tags_for_new_links", true)
+	for key, value := range values {
+		settings.Set(key, value)
+	}
+	require.NoError(t, app.Save(settings))
+}
+
+func createTag(t *testing.T, app core.App, userID string, name string) *core.Record {
+	collection, err := app.FindCollectionByNameOrId("tags")
+	require.NoError(t, err)
+
+	tag := core.NewRecord(collection)
+	tag.Set("user", userID)
+	tag.Set("name", name)
+	tag.Set("slug", Slugify(name))
+	require.NoError(t, app.Save(tag))
+	return tag
+}
+
+func TestMaybeSuggestTagsWithoutExistingTags(t *testing.T) {
+	provider := &fakeProvider{content: `{"suggested_tags": [], "new_tags": ["SQLite"]}`, supportsSchema: true}
+
+	t.Run("New tags not allowed", func(t *testing.T) {
+		testApp, link := setupTestApp(t, provider)
+		createUserSettings(t, testApp, link.GetString("user"), nil)
+
+		require.NoError(t, MaybeSuggestTagsForLink(testApp, testLinkID))
+
+		link, err := testApp.FindRecordById("links", testLinkID)
+		require.NoError(t, err)
+		assert.False(t, link.GetDateTime("tags_suggested_at").IsZero())
+		assert.Empty(t, provider.requests)
+	})
+
+	t.Run("New tags allowed", func(t *testing.T) {
+		testApp, link := setupTestApp(t, provider)
+		createUserSettings(t, testApp, link.GetString("user"), map[string]any{
+			"allow_new_tag_suggestions": true,
+		})
+
+		require.NoError(t, MaybeSuggestTagsForLink(testApp, testLinkID))
+
+		link, err := testApp.FindRecordById("links", testLinkID)
+		require.NoError(t, err)
+		var suggested []string
+		require.NoError(t, link.UnmarshalJSONField("suggested_new_tags", &suggested))
+		assert.Equal(t, []string{"SQLite"}, suggested)
+		assert.Equal(t, "succeeded", link.GetString("tags_status"))
+		require.Len(t, provider.requests, 1)
+		assert.Equal(t, defaultOpenRouterTaggingModel, provider.requests[0].Model)
+	})
+}
+
+func TestMaybeSuggestTagsAutoApply(t *testing.T) {
+	provider := &fakeProvider{
+		content:        `{"suggested_tags": [{"name": "SQLite", "confidence": 0.92}, {"name": "Go", "confidence": 0.5}, {"name": "Unknown", "confidence": 1}]}`,
+		supportsSchema: true,
+	}
+	testApp, link := setupTestApp(t, provider)
+	userID := link.GetString("user")
+	sqlite := createTag(t, testApp, userID, "SQLite")
+	golang := createTag(t, testApp, userID, "Go")
+	createUserSettings(t, testApp, userID, map[string]any{
+		"auto_apply_tags_threshold": 0.9,
+	})
+
+	require.NoError(t, MaybeSuggestTagsForLink(testApp, testLinkID))
+
+	link, err := testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{sqlite.Id}, link.GetStringSlice("tags"))
+	assert.Equal(t, []string{sqlite.Id}, link.GetStringSlice("auto_applied_tags"))
+	assert.Equal(t, []string{golang.Id}, link.GetStringSlice("suggested_tags"))
+}
+
+func TestAcceptNewTag(t *testing.T) {
+	testApp, link := setupTestApp(t, &fakeProvider{})
+	existing := createTag(t, testApp, link.GetString("user"), "SQLite")
+
+	link.Set("suggested_new_tags", []string{"sqlite", "Storage Engines"})
+	require.NoError(t, testApp.Save(link))
+
+	_, err := AcceptNewTag(testApp, testLinkID, "Cooking")
+	assert.ErrorIs(t, err, ErrTagNotSuggested)
+
+	// A tag with the same slug already exists, so it is reused
+	tag, err := AcceptNewTag(testApp, testLinkID, "sqlite")
+	require.NoError(t, err)
+	assert.Equal(t, existing.Id, tag.Id)
+
+	tag, err = AcceptNewTag(testApp, testLinkID, "Storage Engines")
+	require.NoError(t, err)
+	assert.Equal(t, "storage-engines", tag.GetString("slug"))
+
+	link, err = testApp.FindRecordById("links", testLinkID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{existing.Id, tag.Id}, link.GetStringSlice("tags"))
+	var remaining []string
+	require.NoError(t, link.UnmarshalJSONField("suggested_new_tags", &remaining))
+	assert.Empty(t, remaining)
+}
@@ -0,0 +1,277 @@
+package tagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"main/lynx/llm"
+)
+
+const DefaultMaxSuggestedTags = 5
+
+type Tagger struct {
+	Provider llm.Provider
+	// Defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+type Options struct {
+	Model string
+	// Zero means DefaultMaxSuggestedTags
+	MaxTags int
+	// Extra guidance from the user appended to the system prompt
+	Instructions string
+	// Let the model propose tags the user doesn't have yet
+	AllowNewTags bool
+}
+
+type SuggestedTag struct {
+	Name string `json:"name"`
+	// How sure the model is that the tag applies, from 0 to 1. Zero
+	// if the model didn't say.
+	Confidence float64 `json:"confidence"`
+}
+
+// Models answering without a schema sometimes return plain tag names
+// rather than objects, so accept both.
+func (s *SuggestedTag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = SuggestedTag{Name: name}
+		return nil
+	}
+
+	type plain SuggestedTag
+	var tag plain
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+	*s = SuggestedTag(tag)
+	return nil
+}
+
+type Suggestions struct {
+	// The user's existing tags
+	Existing []SuggestedTag
+	// Names of tags that don't exist yet, only set with AllowNewTags
+	New []string
+}
+
+type tagResponse struct {
+	SuggestedTags []SuggestedTag `json:"suggested_tags"`
+	NewTags       []string       `json:"new_tags"`
+}
+
+func (t *Tagger) SuggestTags(text string, existingTags []string, opts Options) (*Suggestions, error) {
+	if text == "" {
+		return nil, fmt.Errorf("empty input text")
+	}
+
+	maxTags := opts.MaxTags
+	if maxTags <= 0 {
+		maxTags = DefaultMaxSuggestedTags
+	}
+
+	// Not every model supports structured output. Those that don't are
+	// asked for plain JSON instead, which is parsed more leniently, and
+	// so are models whose capabilities couldn't be checked.
+	useSchema, err := t.Provider.SupportsSchema(opts.Model)
+	if err != nil {
+		logger := t.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Warn("Failed to check model capabilities, asking for plain JSON", "model", opts.Model, "error", err)
+		useSchema = false
+	}
+
+	// Create the system prompt
+	var systemPrompt string
+	if opts.AllowNewTags {
+		systemPrompt = fmt.Sprintf(`You are a helpful assistant that suggests relevant tags for articles based on their content. 
+
+Given the following article content, suggest up to %d relevant tags in total. Prefer tags from the user's existing tags list and put those in suggested_tags.
+If no existing tag describes an important topic of the article, you may propose new short, general tag names in new_tags. Do not propose new tags that duplicate an existing one.
+It is ok to suggest empty lists if nothing is relevant.
+
+Existing tags: %s
+
+Respond with a JSON object containing an array of suggested existing tags, each with a confidence between 0 and 1 that the tag applies, and an array of new tag names.`, maxTags, formatTagsList(existingTags))
+	} else {
+		systemPrompt = fmt.Sprintf(`You are a helpful assistant that suggests relevant tags for articles based on their content. 
+
+Given the following article content, suggest up to %d relevant tags from the user's existing tags list. Only suggest tags that already exist in the list - do not create new tags.
+It is ok to suggest an empty list if none of the existing tags are relevant.
+
+Existing tags: %s
+
+Respond with a JSON object containing an array of suggested tags, each with a confidence between 0 and 1 that the tag applies.`, maxTags, formatTagsList(existingTags))
+	}
+
+	if !useSchema {
+		if opts.AllowNewTags {
+			systemPrompt += `
+Respond with only the JSON object and no other text, in the form {"suggested_tags": [{"name": "existing tag", "confidence": 0.9}], "new_tags": ["new tag"]}.`
+		} else {
+			systemPrompt += `
+Respond with only the JSON object and no other text, in the form {"suggested_tags": [{"name": "tag one", "confidence": 0.9}, {"name": "tag two", "confidence": 0.6}]}.`
+		}
+	}
+	if opts.Instructions != "" {
+		systemPrompt += "\n\nAdditional instructions from the user:\n" + opts.Instructions
+	}
+
+	req := llm.Request{
+		Model:  opts.Model,
+		System: systemPrompt,
+		Messages: []llm.Message{
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
+	}
+	if useSchema {
+		req.Schema = &llm.Schema{
+			Name:   "tag_suggestions",
+			Schema: responseSchema(opts.AllowNewTags),
+		}
+	}
+
+	resp, err := t.Provider.Complete(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := parseTagResponse(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := &Suggestions{}
+	for _, tag := range response.SuggestedTags {
+		if len(suggestions.Existing) < maxTags {
+			suggestions.Existing = append(suggestions.Existing, tag)
+		}
+	}
+	if opts.AllowNewTags {
+		for _, name := range response.NewTags {
+			name = strings.TrimSpace(name)
+			// Models don't always respect the split between the two
+			// lists, so catch existing tags proposed as new ones.
+			if existing := findTagName(existingTags, name); existing != "" {
+				if !containsSuggestion(suggestions.Existing, existing) && len(suggestions.Existing)+len(suggestions.New) < maxTags {
+					// The model didn't think the tag existed, so don't
+					// trust it enough to apply automatically.
+					suggestions.Existing = append(suggestions.Existing, SuggestedTag{Name: existing})
+				}
+				continue
+			}
+			if name == "" || Slugify(name) == "" || containsTagName(suggestions.New, name) {
+				continue
+			}
+			if len(suggestions.Existing)+len(suggestions.New) < maxTags {
+				suggestions.New = append(suggestions.New, name)
+			}
+		}
+	}
+	return suggestions, nil
+}
+
+// Create JSON schema for structured output
+func responseSchema(allowNewTags bool) map[string]interface{} {
+	properties := map[string]interface{}{
+		"suggested_tags": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Tag name from the existing tags list",
+					},
+					"confidence": map[string]interface{}{
+						"type":        "number",
+						"description": "Confidence between 0 and 1 that the tag applies to the article",
+					},
+				},
+				"required":             []string{"name", "confidence"},
+				"additionalProperties": false,
+			},
+			"description": "Array of suggested tags from the existing tags list",
+		},
+	}
+	required := []string{"suggested_tags"}
+	if allowNewTags {
+		properties["new_tags"] = map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+			},
+			"description": "Array of proposed tag names that are not in the existing tags list",
+		}
+		required = append(required, "new_tags")
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// findTagName returns the tag in tags matching name case-insensitively,
+// or an empty string if there is none.
+func findTagName(tags []string, name string) string {
+	for _, tag := range tags {
+		if strings.EqualFold(tag, name) {
+			return tag
+		}
+	}
+	return ""
+}
+
+func containsTagName(tags []string, name string) bool {
+	return findTagName(tags, name) != ""
+}
+
+func containsSuggestion(suggestions []SuggestedTag, name string) bool {
+	for _, suggestion := range suggestions {
+		if strings.EqualFold(suggestion.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTagResponse extracts the suggestions from the model's reply.
+// Models without structured output support often wrap the JSON in a
+// Markdown code block or add a sentence around it, so fall back to
+// the outermost braces if the reply isn't valid JSON by itself.
+func parseTagResponse(content string) (*tagResponse, error) {
+	var response tagResponse
+	err := json.Unmarshal([]byte(content), &response)
+	if err == nil {
+		return &response, nil
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("failed to unmarshal tag response: %w", err)
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tag response: %w", err)
+	}
+	return &response, nil
+}
+
+func formatTagsList(tags []string) string {
+	if len(tags) == 0 {
+		return "No existing tags"
+	}
+	return strings.Join(tags, ", ")
+}
@@ -0,0 +1,134 @@
+package tagger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main/lynx/llm"
+)
+
+type fakeProvider struct {
+	requests       []llm.Request
+	content        string
+	supportsSchema bool
+	schemaErr      error
+}
+
+func (p *fakeProvider) Complete(req llm.Request) (*llm.Response, error) {
+	p.requests = append(p.requests, req)
+	return &llm.Response{Content: p.content}, nil
+}
+
+func (p *fakeProvider) SupportsSchema(model string) (bool, error) {
+	return p.supportsSchema, p.schemaErr
+}
+
+func TestSuggestTagsWithSchema(t *testing.T) {
+	provider := &fakeProvider{
+		content:        `{"suggested_tags": [{"name": "go", "confidence": 0.4}, {"name": "databases", "confidence": 0.95}]}`,
+		supportsSchema: true,
+	}
+	tagger := &Tagger{Provider: provider}
+
+	tags, err := tagger.SuggestTags("An article about SQLite", []string{"go", "databases", "cooking"}, Options{
+		Model:        "openai/gpt-4o-mini",
+		MaxTags:      3,
+		Instructions: "Prefer broad topics.",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []SuggestedTag{{Name: "go", Confidence: 0.4}, {Name: "databases", Confidence: 0.95}}, tags.Existing)
+	assert.Empty(t, tags.New)
+
+	require.Len(t, provider.requests, 1)
+	req := provider.requests[0]
+	assert.Equal(t, "openai/gpt-4o-mini", req.Model)
+	require.NotNil(t, req.Schema)
+	assert.Equal(t, "tag_suggestions", req.Schema.Name)
+	items := req.Schema.Schema["properties"].(map[string]interface{})["suggested_tags"].(map[string]interface{})["items"].(map[string]interface{})
+	assert.Equal(t, []string{"name", "confidence"}, items["required"])
+	assert.Contains(t, req.System, "suggest up to 3 relevant tags")
+	assert.Contains(t, req.System, "Existing tags: go, databases, cooking")
+	assert.Contains(t, req.System, "Additional instructions from the user:\nPrefer broad topics.")
+}
+
+func TestSuggestTagsWithoutSchema(t *testing.T) {
+	provider := &fakeProvider{
+		content: "Here are the tags:\n```json\n{\"suggested_tags\": [\"go\", \"databases\", \"cooking\"]}\n```",
+	}
+	tagger := &Tagger{Provider: provider}
+
+	tags, err := tagger.SuggestTags("An article about SQLite", []string{"go", "databases", "cooking"}, Options{
+		Model:   "llama3.2",
+		MaxTags: 2,
+	})
+	require.NoError(t, err)
+	// Plain names are accepted, but without a confidence
+	assert.Equal(t, []SuggestedTag{{Name: "go"}, {Name: "databases"}}, tags.Existing)
+
+	req := provider.requests[0]
+	assert.Nil(t, req.Schema)
+	assert.Contains(t, req.System, "Respond with only the JSON object")
+	assert.NotContains(t, req.System, "Additional instructions")
+}
+
+func TestSuggestTagsWhenSchemaSupportIsUnknown(t *testing.T) {
+	provider := &fakeProvider{
+		content:   `{"suggested_tags": [{"name": "go", "confidence": 0.8}]}`,
+		schemaErr: errors.New("failed to fetch models"),
+	}
+	tagger := &Tagger{Provider: provider}
+
+	tags, err := tagger.SuggestTags("An article about Go", []string{"go"}, Options{Model: "openai/gpt-4o-mini"})
+	require.NoError(t, err)
+	assert.Equal(t, []SuggestedTag{{Name: "go", Confidence: 0.8}}, tags.Existing)
+
+	req := provider.requests[0]
+	assert.Nil(t, req.Schema)
+	assert.Contains(t, req.System, "Respond with only the JSON object")
+}
+
+func TestSuggestTagsDefaultsAndErrors(t *testing.T) {
+	provider := &fakeProvider{content: "I couldn't find any relevant tags.", supportsSchema: true}
+	tagger := &Tagger{Provider: provider}
+
+	_, err := tagger.SuggestTags("", []string{"go"}, Options{Model: "openai/gpt-4o-mini"})
+	assert.EqualError(t, err, "empty input text")
+
+	_, err = tagger.SuggestTags("Some text", []string{"go"}, Options{Model: "openai/gpt-4o-mini"})
+	assert.ErrorContains(t, err, "failed to unmarshal tag response")
+	assert.Contains(t, provider.requests[0].System, "suggest up to 5 relevant tags")
+}
+
+func TestSuggestTagsWithNewTags(t *testing.T) {
+	provider := &fakeProvider{
+		content:        `{"suggested_tags": [{"name": "go", "confidence": 0.8}], "new_tags": ["SQLite", "Databases", "sqlite", "!!!", "Performance"]}`,
+		supportsSchema: true,
+	}
+	tagger := &Tagger{Provider: provider}
+
+	tags, err := tagger.SuggestTags("An article about SQLite", []string{"go", "databases"}, Options{
+		Model:        "openai/gpt-4o-mini",
+		MaxTags:      3,
+		AllowNewTags: true,
+	})
+	require.NoError(t, err)
+
+	// Existing tags proposed as new ones are moved over, and duplicates,
+	// unusable names and anything over the limit are dropped.
+	assert.Equal(t, []SuggestedTag{{Name: "go", Confidence: 0.8}, {Name: "databases"}}, tags.Existing)
+	assert.Equal(t, []string{"SQLite"}, tags.New)
+
+	req := provider.requests[0]
+	require.NotNil(t, req.Schema)
+	assert.Contains(t, req.Schema.Schema["properties"], "new_tags")
+	assert.Contains(t, req.System, "you may propose new short, general tag names")
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "machine-learning", Slugify("Machine Learning"))
+	assert.Equal(t, "c-tips", Slugify("  C++ Tips "))
+	assert.Equal(t, "self_hosting", Slugify("Self_Hosting!"))
+}
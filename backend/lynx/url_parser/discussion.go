@@ -0,0 +1,213 @@
+package url_parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Overridden in tests
+var (
+	hackerNewsAPIURL = "https://hn.algolia.com/api/v1"
+	redditURL        = "https://www.reddit.com"
+)
+
+// Most top level comments included with a thread
+const maxThreadComments = 10
+
+// thread is a discussion about a link, or a post of its own, along
+// with its top comments.
+type thread struct {
+	Title     string
+	Author    string
+	Published time.Time
+	// The article being discussed, if any
+	LinkURL string
+	// The post's own text, as HTML, if any
+	PostHTML string
+	Comments []threadComment
+}
+
+type threadComment struct {
+	Author string
+	// As HTML
+	Body string
+}
+
+// content puts the thread together with the article it links to, if
+// that can be loaded, followed by the post and its comments.
+func (t *thread) content() *Content {
+	var htmlContent, text strings.Builder
+	content := &Content{Title: t.Title, Author: t.Author}
+	if !t.Published.IsZero() {
+		content.PublishedTime = &t.Published
+	}
+
+	if t.LinkURL != "" {
+		fmt.Fprintf(&htmlContent, "<p><a href=\"%s\">%s</a></p>\n", html.EscapeString(t.LinkURL), html.EscapeString(t.LinkURL))
+		if article, err := fetchArticle(t.LinkURL); err == nil {
+			htmlContent.WriteString(article.HTML + "\n")
+			text.WriteString(article.Text + "\n\n")
+			content.Excerpt = article.Excerpt
+			content.ImageURL = article.ImageURL
+		}
+	}
+
+	if t.PostHTML != "" {
+		htmlContent.WriteString(t.PostHTML + "\n")
+		postText := htmlText(t.PostHTML)
+		text.WriteString(postText + "\n\n")
+		if content.Excerpt == "" {
+			content.Excerpt = truncate(firstParagraph(postText), excerptLength)
+		}
+	}
+
+	if len(t.Comments) > 0 {
+		htmlContent.WriteString("<h2>Comments</h2>\n")
+	}
+	for _, comment := range t.Comments {
+		fmt.Fprintf(&htmlContent, "<blockquote>\n<p><strong>%s</strong></p>\n%s\n</blockquote>\n", html.EscapeString(comment.Author), comment.Body)
+		fmt.Fprintf(&text, "%s: %s\n\n", comment.Author, htmlText(comment.Body))
+	}
+
+	content.HTML = htmlContent.String()
+	content.Text = strings.TrimSpace(text.String())
+	return content
+}
+
+// HackerNewsExtractor extracts Hacker News threads through the Algolia
+// Hacker News API.
+type HackerNewsExtractor struct{}
+
+type hackerNewsItem struct {
+	ID        int              `json:"id"`
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Author    string           `json:"author"`
+	URL       string           `json:"url"`
+	Text      string           `json:"text"`
+	CreatedAt time.Time        `json:"created_at"`
+	Children  []hackerNewsItem `json:"children"`
+}
+
+func (HackerNewsExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	id := pageURL.Query().Get("id")
+	if pageURL.Path != "/item" || id == "" {
+		return nil, errors.New("not a Hacker News thread")
+	}
+
+	var item hackerNewsItem
+	if err := fetchJSON(hackerNewsAPIURL+"/items/"+url.PathEscape(id), nil, &item); err != nil {
+		return nil, fmt.Errorf("failed to load thread: %w", err)
+	}
+	if item.Type != "story" && item.Type != "poll" {
+		return nil, errors.New("not a Hacker News thread")
+	}
+
+	t := &thread{
+		Title:     item.Title,
+		Author:    item.Author,
+		Published: item.CreatedAt,
+		LinkURL:   item.URL,
+		PostHTML:  item.Text,
+	}
+	for _, child := range item.Children {
+		// Deleted comments are left in without an author or text
+		if child.Author == "" || child.Text == "" {
+			continue
+		}
+		t.Comments = append(t.Comments, threadComment{Author: child.Author, Body: child.Text})
+		if len(t.Comments) == maxThreadComments {
+			break
+		}
+	}
+
+	content := t.content()
+	content.FullPageHTML = string(body)
+	return content, nil
+}
+
+// RedditExtractor extracts Reddit threads through the JSON version of
+// the thread's page.
+type RedditExtractor struct{}
+
+// Thread paths look like /r/golang/comments/abc123/some_title/
+var redditThreadPath = regexp.MustCompile(`^/r/[^/]+/comments/[^/]+`)
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Kind string          `json:"kind"`
+			Data json.RawMessage `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Title        string  `json:"title"`
+	Author       string  `json:"author"`
+	URL          string  `json:"url"`
+	IsSelf       bool    `json:"is_self"`
+	SelftextHTML string  `json:"selftext_html"`
+	CreatedUTC   float64 `json:"created_utc"`
+}
+
+type redditComment struct {
+	Author   string `json:"author"`
+	BodyHTML string `json:"body_html"`
+}
+
+func (RedditExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	threadPath := redditThreadPath.FindString(pageURL.Path)
+	if threadPath == "" {
+		return nil, errors.New("not a Reddit thread")
+	}
+
+	// Reddit turns away requests without a user agent of their own
+	var listings []redditListing
+	jsonURL := fmt.Sprintf("%s%s.json?limit=%d&raw_json=1", redditURL, threadPath, maxThreadComments)
+	if err := fetchJSON(jsonURL, map[string]string{"User-Agent": "lynx"}, &listings); err != nil {
+		return nil, fmt.Errorf("failed to load thread: %w", err)
+	}
+	if len(listings) < 2 || len(listings[0].Data.Children) == 0 {
+		return nil, errors.New("thread has no post")
+	}
+
+	var post redditPost
+	if err := json.Unmarshal(listings[0].Data.Children[0].Data, &post); err != nil {
+		return nil, fmt.Errorf("failed to decode post: %w", err)
+	}
+
+	t := &thread{
+		Title:     post.Title,
+		Author:    post.Author,
+		Published: time.Unix(int64(post.CreatedUTC), 0).UTC(),
+		PostHTML:  post.SelftextHTML,
+	}
+	if !post.IsSelf {
+		t.LinkURL = post.URL
+	}
+	for _, child := range listings[1].Data.Children {
+		// Skips the "load more comments" placeholders
+		if child.Kind != "t1" {
+			continue
+		}
+		var comment redditComment
+		if err := json.Unmarshal(child.Data, &comment); err != nil || comment.BodyHTML == "" {
+			continue
+		}
+		t.Comments = append(t.Comments, threadComment{Author: comment.Author, Body: comment.BodyHTML})
+		if len(t.Comments) == maxThreadComments {
+			break
+		}
+	}
+
+	content := t.content()
+	content.FullPageHTML = string(body)
+	return content, nil
+}
@@ -0,0 +1,119 @@
+package url_parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const discussedArticle = `<html><head><title>The Article</title></head><body><article><h1>The Article</h1><p>The article being discussed, which has plenty of words in it so that readability keeps it around.</p></article></body></html>`
+
+func setupDiscussionServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(discussedArticle))
+		case "/hn/items/100":
+			w.Write([]byte(`{"id": 100, "type": "story", "title": "The Article", "author": "pg", "url": "` + server.URL + `/article", "text": null, "created_at": "2025-01-02T03:04:05.000Z", "children": [
+				{"id": 101, "type": "comment", "author": "alice", "text": "<p>Great read</p>", "children": [{"id": 103, "author": "bob", "text": "Agreed"}]},
+				{"id": 102, "type": "comment", "author": null, "text": null, "children": []},
+				{"id": 104, "type": "comment", "author": "carol", "text": "Not convinced &amp; here&#x27;s why", "children": []}
+			]}`))
+		case "/hn/items/200":
+			w.Write([]byte(`{"id": 200, "type": "story", "title": "Ask HN: Favourite editor?", "author": "dang", "url": null, "text": "<p>Which editor do you use?</p>", "created_at": "2025-01-02T03:04:05.000Z", "children": []}`))
+		case "/hn/items/101":
+			w.Write([]byte(`{"id": 101, "type": "comment", "author": "alice", "text": "Great read", "children": []}`))
+		case "/r/golang/comments/abc123.json":
+			if r.Header.Get("User-Agent") != "lynx" {
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`[
+				{"kind": "Listing", "data": {"children": [{"kind": "t3", "data": {"title": "The Article", "author": "gopher", "url": "` + server.URL + `/article", "is_self": false, "selftext_html": null, "created_utc": 1735787045.0}}]}},
+				{"kind": "Listing", "data": {"children": [
+					{"kind": "t1", "data": {"author": "alice", "body_html": "<div class=\"md\"><p>Great read</p></div>"}},
+					{"kind": "more", "data": {"count": 10}}
+				]}}
+			]`))
+		case "/r/golang/comments/self01.json":
+			w.Write([]byte(`[
+				{"kind": "Listing", "data": {"children": [{"kind": "t3", "data": {"title": "Which editor?", "author": "gopher", "url": "https://www.reddit.com/r/golang/comments/self01/which_editor/", "is_self": true, "selftext_html": "<div class=\"md\"><p>Which editor do you use?</p></div>", "created_utc": 1735787045.0}}]}},
+				{"kind": "Listing", "data": {"children": []}}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalHackerNewsAPIURL, originalRedditURL := hackerNewsAPIURL, redditURL
+	hackerNewsAPIURL, redditURL = server.URL+"/hn", server.URL
+	t.Cleanup(func() {
+		hackerNewsAPIURL, redditURL = originalHackerNewsAPIURL, originalRedditURL
+	})
+	return server
+}
+
+func TestHackerNewsExtractor(t *testing.T) {
+	server := setupDiscussionServer(t)
+
+	content, err := HackerNewsExtractor{}.Extract([]byte("<html></html>"), mustParseURL(t, "https://news.ycombinator.com/item?id=100"))
+	require.NoError(t, err)
+	assert.Equal(t, "The Article", content.Title)
+	assert.Equal(t, "pg", content.Author)
+	require.NotNil(t, content.PublishedTime)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), *content.PublishedTime)
+	assert.Contains(t, content.HTML, `<p><a href="`+server.URL+`/article">`)
+	assert.Contains(t, content.HTML, "The article being discussed")
+	assert.Contains(t, content.HTML, "<h2>Comments</h2>\n<blockquote>\n<p><strong>alice</strong></p>\n<p>Great read</p>\n</blockquote>")
+	assert.True(t, strings.HasSuffix(content.Text, "alice: Great read\n\ncarol: Not convinced & here's why"), content.Text)
+	assert.Contains(t, content.Text, "The article being discussed")
+	// Only top level comments
+	assert.NotContains(t, content.Text, "Agreed")
+	assert.Equal(t, "<html></html>", content.FullPageHTML)
+
+	content, err = HackerNewsExtractor{}.Extract(nil, mustParseURL(t, "https://news.ycombinator.com/item?id=200"))
+	require.NoError(t, err)
+	assert.Equal(t, "Ask HN: Favourite editor?", content.Title)
+	assert.Equal(t, "Which editor do you use?", content.Text)
+	assert.Equal(t, "Which editor do you use?", content.Excerpt)
+
+	// Links to comments aren't threads
+	_, err = HackerNewsExtractor{}.Extract(nil, mustParseURL(t, "https://news.ycombinator.com/item?id=101"))
+	assert.EqualError(t, err, "not a Hacker News thread")
+	_, err = HackerNewsExtractor{}.Extract(nil, mustParseURL(t, "https://news.ycombinator.com/news"))
+	assert.EqualError(t, err, "not a Hacker News thread")
+}
+
+func TestRedditExtractor(t *testing.T) {
+	server := setupDiscussionServer(t)
+
+	content, err := RedditExtractor{}.Extract(nil, mustParseURL(t, "https://old.reddit.com/r/golang/comments/abc123/the_article/"))
+	require.NoError(t, err)
+	assert.Equal(t, "The Article", content.Title)
+	assert.Equal(t, "gopher", content.Author)
+	require.NotNil(t, content.PublishedTime)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), *content.PublishedTime)
+	assert.Contains(t, content.HTML, `<p><a href="`+server.URL+`/article">`)
+	assert.Contains(t, content.HTML, "<blockquote>\n<p><strong>alice</strong></p>\n<div class=\"md\"><p>Great read</p></div>\n</blockquote>")
+	assert.True(t, strings.HasSuffix(content.Text, "alice: Great read"), content.Text)
+
+	// Self posts don't link anywhere else
+	content, err = RedditExtractor{}.Extract(nil, mustParseURL(t, "https://www.reddit.com/r/golang/comments/self01/which_editor/"))
+	require.NoError(t, err)
+	assert.Equal(t, "Which editor?", content.Title)
+	assert.Equal(t, "<div class=\"md\"><p>Which editor do you use?</p></div>\n", content.HTML)
+	assert.Equal(t, "Which editor do you use?", content.Text)
+
+	_, err = RedditExtractor{}.Extract(nil, mustParseURL(t, "https://www.reddit.com/r/golang/"))
+	assert.EqualError(t, err, "not a Reddit thread")
+	_, err = RedditExtractor{}.Extract(nil, mustParseURL(t, "https://www.reddit.com/r/golang/comments/missing/"))
+	assert.ErrorContains(t, err, "failed to load thread")
+}
@@ -0,0 +1,218 @@
+package url_parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// EPUBExtractor extracts the text and metadata of an EPUB, and keeps
+// the book itself as the link's document. Chapters are read in spine
+// order, with their images dropped since they live inside the book.
+type EPUBExtractor struct{}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Metadata struct {
+		Title       []string `xml:"title"`
+		Creator     []string `xml:"creator"`
+		Date        []string `xml:"date"`
+		Description []string `xml:"description"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func (EPUBExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPUB: %w", err)
+	}
+
+	var container epubContainer
+	if err := readEPUBXML(archive, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New("EPUB has no package document")
+	}
+	packagePath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readEPUBXML(archive, packagePath, &pkg); err != nil {
+		return nil, err
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html" {
+			hrefs[item.ID] = item.Href
+		}
+	}
+
+	var htmlContent, textContent strings.Builder
+	for _, itemRef := range pkg.Spine {
+		href, ok := hrefs[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		// Hrefs are relative to the package document, and URL escaped
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		chapter, err := readEPUBFile(archive, path.Join(path.Dir(packagePath), href))
+		if err != nil {
+			return nil, err
+		}
+		chapterHTML := epubChapterHTML(chapter)
+		if chapterHTML == "" {
+			continue
+		}
+		htmlContent.WriteString("<section>" + chapterHTML + "</section>\n")
+		if text := htmlText(chapterHTML); text != "" {
+			if textContent.Len() > 0 {
+				textContent.WriteString("\n\n")
+			}
+			textContent.WriteString(text)
+		}
+	}
+
+	document, err := filesystem.NewFileFromBytes(body, documentFilename(pageURL, ".epub"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document file: %w", err)
+	}
+
+	content := &Content{
+		Title:    firstValue(pkg.Metadata.Title),
+		Author:   strings.Join(pkg.Metadata.Creator, ", "),
+		HTML:     htmlContent.String(),
+		Text:     textContent.String(),
+		Document: document,
+	}
+	if description := htmlText(firstValue(pkg.Metadata.Description)); description != "" {
+		content.Excerpt = truncate(firstParagraph(description), excerptLength)
+	} else {
+		content.Excerpt = truncate(firstParagraph(content.Text), excerptLength)
+	}
+	if published, ok := parseEPUBDate(firstValue(pkg.Metadata.Date)); ok {
+		content.PublishedTime = &published
+	}
+	return content, nil
+}
+
+// epubChapterHTML returns the contents of a chapter's body, without
+// anything that can't be shown outside of the book.
+func epubChapterHTML(chapter []byte) string {
+	doc, err := html.Parse(bytes.NewReader(chapter))
+	if err != nil {
+		return ""
+	}
+	var body *html.Node
+	var find func(node *html.Node)
+	find = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.Body {
+			body = node
+			return
+		}
+		for child := node.FirstChild; child != nil && body == nil; child = child.NextSibling {
+			find(child)
+		}
+	}
+	find(doc)
+	if body == nil {
+		return ""
+	}
+
+	var remove func(node *html.Node)
+	remove = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; {
+			next := child.NextSibling
+			if child.Type == html.ElementNode {
+				switch child.DataAtom {
+				case atom.Img, atom.Image, atom.Svg, atom.Script, atom.Style, atom.Link:
+					node.RemoveChild(child)
+				default:
+					remove(child)
+				}
+			}
+			child = next
+		}
+	}
+	remove(body)
+
+	var result bytes.Buffer
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&result, child); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimSpace(result.String())
+}
+
+func readEPUBFile(archive *zip.Reader, name string) ([]byte, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPUB: %w", err)
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func readEPUBXML(archive *zip.Reader, name string, v any) error {
+	data, err := readEPUBFile(archive, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to read EPUB %s: %w", name, err)
+	}
+	return nil
+}
+
+// parseEPUBDate parses a publication date, which can be anything from
+// a year to a full timestamp.
+func parseEPUBDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01", "2006"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func firstValue(values []string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// isEPUB reports whether the body is an EPUB, which starts with an
+// uncompressed "mimetype" file naming its content type.
+func isEPUB(body []byte) bool {
+	return bytes.HasPrefix(body, []byte("PK\x03\x04")) &&
+		len(body) > 30 && bytes.HasPrefix(body[30:], []byte("mimetypeapplication/epub+zip"))
+}
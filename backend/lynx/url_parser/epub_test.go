@@ -0,0 +1,116 @@
+package url_parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestEPUB writes a minimal EPUB with the given package metadata
+// and chapters, in order.
+func buildTestEPUB(t *testing.T, metadata string, chapters ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	write := func(name string, method uint16, content string) {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	write("mimetype", zip.Store, "application/epub+zip")
+	write("META-INF/container.xml", zip.Deflate, `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`)
+
+	var manifest, spine string
+	for i, chapter := range chapters {
+		id := string(rune('a' + i))
+		manifest += `<item id="` + id + `" href="text/chapter%20` + id + `.xhtml" media-type="application/xhtml+xml"/>`
+		spine += `<itemref idref="` + id + `"/>`
+		write("OEBPS/text/chapter "+id+".xhtml", zip.Deflate, `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Chapter</title><link rel="stylesheet" href="style.css"/></head><body>`+chapter+`</body></html>`)
+	}
+	write("OEBPS/content.opf", zip.Deflate, `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">`+metadata+`</metadata>
+  <manifest><item id="css" href="style.css" media-type="text/css"/>`+manifest+`</manifest>
+  <spine>`+spine+`</spine>
+</package>`)
+
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestEPUBExtractor(t *testing.T) {
+	body := buildTestEPUB(t,
+		`<dc:title>A Short Book</dc:title><dc:creator>Jane Doe</dc:creator><dc:creator>John Roe</dc:creator><dc:date>2021-06-01</dc:date><dc:description>&lt;p&gt;A book about &lt;em&gt;things&lt;/em&gt;.&lt;/p&gt;</dc:description>`,
+		`<h1>Chapter One</h1><p>It begins.</p><img src="../images/cover.jpg"/>`,
+		`<h1>Chapter Two</h1><p>It ends.</p><script>alert(1)</script>`,
+	)
+
+	content, err := EPUBExtractor{}.Extract(body, mustParseURL(t, "https://example.com/books/short.epub"))
+	require.NoError(t, err)
+	assert.Equal(t, "A Short Book", content.Title)
+	assert.Equal(t, "Jane Doe, John Roe", content.Author)
+	assert.Equal(t, "A book about things.", content.Excerpt)
+	assert.Equal(t,
+		"<section><h1>Chapter One</h1><p>It begins.</p></section>\n<section><h1>Chapter Two</h1><p>It ends.</p></section>\n",
+		content.HTML,
+	)
+	assert.Equal(t, "Chapter One\nIt begins.\n\nChapter Two\nIt ends.", content.Text)
+	require.NotNil(t, content.PublishedTime)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), *content.PublishedTime)
+	require.NotNil(t, content.Document)
+	assert.Equal(t, "short.epub", content.Document.OriginalName)
+
+	// Without metadata the excerpt comes from the text
+	content, err = EPUBExtractor{}.Extract(buildTestEPUB(t, "", `<p>Only a paragraph.</p>`), nil)
+	require.NoError(t, err)
+	assert.Empty(t, content.Title)
+	assert.Equal(t, "Only a paragraph.", content.Excerpt)
+	assert.Nil(t, content.PublishedTime)
+	assert.Equal(t, "document.epub", content.Document.OriginalName)
+}
+
+func TestEPUBExtractorInvalid(t *testing.T) {
+	_, err := EPUBExtractor{}.Extract([]byte("not a zip"), nil)
+	assert.ErrorContains(t, err, "failed to read EPUB")
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	require.NoError(t, archive.Close())
+	_, err = EPUBExtractor{}.Extract(buf.Bytes(), nil)
+	assert.ErrorContains(t, err, "failed to read EPUB")
+}
+
+func TestParseEPUBDate(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{value: "2021-06-01T10:00:00+02:00", expected: time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC), ok: true},
+		{value: "2021-06-01", expected: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), ok: true},
+		{value: "2021-06", expected: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), ok: true},
+		{value: "2021", expected: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), ok: true},
+		{value: "", ok: false},
+		{value: "June 2021", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			date, ok := parseEPUBDate(tc.value)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.expected, date)
+			}
+		})
+	}
+}
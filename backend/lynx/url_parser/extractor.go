@@ -0,0 +1,418 @@
+package url_parser
+
+// Pulls the readable content out of whatever a URL returns. Which
+// extractor is used depends first on the response's content type, so
+// PDFs get their own extractor, and then on the site, for pages like
+// videos and discussion threads that readability doesn't handle well.
+// Everything else is run through readability.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-shiori/go-readability"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Kinds of links, so that clients can tell articles from videos and
+// notes
+const (
+	KindArticle = "article"
+	KindNote    = "note"
+	KindPDF     = "pdf"
+	KindVideo   = "video"
+)
+
+// Content is what an extractor found in a page or document, ready to
+// be saved on a link.
+type Content struct {
+	Title         string
+	Author        string
+	Excerpt       string
+	HTML          string
+	Text          string
+	ImageURL      string
+	PublishedTime *time.Time
+	// How long the content takes to watch or listen to, for content
+	// like videos where that's more useful than the time to read the
+	// text
+	Duration time.Duration
+	// The page as it was fetched, for pages that are HTML
+	FullPageHTML string
+	// The original document, for anything that isn't a web page,
+	// which is attached to the link
+	Document *filesystem.File
+	// Where the content says it came from, for saved pages that
+	// record their original URL
+	URL string
+	// What kind of link the content makes, KindArticle if empty
+	Kind string
+}
+
+// Longest excerpt extractors take from the start of the text, when
+// there isn't a summary to use
+const excerptLength = 300
+
+type Extractor interface {
+	Extract(body []byte, pageURL *url.URL) (*Content, error)
+}
+
+// Extractors for content types that aren't web pages
+var contentTypeExtractors = map[string]Extractor{
+	"application/pdf":           PDFExtractor{},
+	"application/epub+zip":      EPUBExtractor{},
+	"multipart/related":         MHTMLExtractor{},
+	"message/rfc822":            MHTMLExtractor{},
+	"application/x-mimearchive": MHTMLExtractor{},
+	"text/plain":                TextExtractor{},
+	"text/markdown":             MarkdownExtractor{},
+}
+
+// Extractors for particular sites, keyed by hostname. Each one also
+// handles the subdomains of its hostname.
+var (
+	siteExtractorsMu sync.RWMutex
+	siteExtractors   = map[string]Extractor{
+		"youtube.com":          YouTubeExtractor{},
+		"youtu.be":             YouTubeExtractor{},
+		"github.com":           GitHubExtractor{},
+		"news.ycombinator.com": HackerNewsExtractor{},
+		"reddit.com":           RedditExtractor{},
+	}
+)
+
+// RegisterSiteExtractor sets the extractor used for pages on the
+// hostname and its subdomains, replacing any existing one. A nil
+// extractor removes it, leaving the site to readability.
+func RegisterSiteExtractor(hostname string, extractor Extractor) {
+	siteExtractorsMu.Lock()
+	defer siteExtractorsMu.Unlock()
+	hostname = strings.ToLower(hostname)
+	if extractor == nil {
+		delete(siteExtractors, hostname)
+	} else {
+		siteExtractors[hostname] = extractor
+	}
+}
+
+// siteExtractor finds the extractor registered for the most specific
+// part of the hostname, so that an extractor for old.reddit.com would
+// win over one for reddit.com.
+func siteExtractor(hostname string) (Extractor, bool) {
+	siteExtractorsMu.RLock()
+	defer siteExtractorsMu.RUnlock()
+	hostname = strings.ToLower(hostname)
+	for hostname != "" {
+		if extractor, ok := siteExtractors[hostname]; ok {
+			return extractor, true
+		}
+		_, hostname, _ = strings.Cut(hostname, ".")
+	}
+	return nil, false
+}
+
+// ExtractorFor returns the extractor for a page at pageURL that was
+// returned with the given Content-Type header. If the header is
+// missing or too vague to go on, the content type is sniffed from the
+// body instead. Site extractors fall back to readability if they fail.
+func ExtractorFor(pageURL *url.URL, contentType string, body []byte) Extractor {
+	mediaType := mediaTypeOf(contentType, body)
+	if extractor, ok := contentTypeExtractors[mediaType]; ok {
+		return extractor
+	}
+	if pageURL != nil {
+		if extractor, ok := siteExtractor(pageURL.Hostname()); ok {
+			return fallbackExtractor{extractor, ReadabilityExtractor{}}
+		}
+	}
+	return ReadabilityExtractor{}
+}
+
+// ExtractorForUpload returns the extractor for an uploaded file, which
+// is picked by content type alone. Site extractors aren't used since
+// they load the page or an API for themselves rather than reading the
+// upload.
+func ExtractorForUpload(contentType string, body []byte) Extractor {
+	if extractor, ok := contentTypeExtractors[mediaTypeOf(contentType, body)]; ok {
+		return extractor
+	}
+	return ReadabilityExtractor{}
+}
+
+// mediaTypeOf returns the media type from a Content-Type header, or
+// sniffed from the body if the header is missing or too vague.
+func mediaTypeOf(contentType string, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(sniffContentType(body))
+	}
+	return mediaType
+}
+
+// sniffContentType extends http.DetectContentType with the formats it
+// doesn't know about, which it would call zip files or plain text.
+func sniffContentType(body []byte) string {
+	if isEPUB(body) {
+		return "application/epub+zip"
+	}
+	if isMHTML(body) {
+		return "multipart/related"
+	}
+	return http.DetectContentType(body)
+}
+
+// fallbackExtractor uses the fallback extractor when the first one
+// fails, for when a site's page or API isn't what was expected.
+type fallbackExtractor struct {
+	extractor Extractor
+	fallback  Extractor
+}
+
+func (e fallbackExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	content, err := e.extractor.Extract(body, pageURL)
+	if err == nil {
+		return content, nil
+	}
+	content, fallbackErr := e.fallback.Extract(body, pageURL)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w (after %v)", fallbackErr, err)
+	}
+	return content, nil
+}
+
+// ReadabilityExtractor extracts the article from an HTML page.
+type ReadabilityExtractor struct{}
+
+func (ReadabilityExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	article, err := readability.FromReader(bytes.NewReader(body), pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webpage content: %w", err)
+	}
+	return &Content{
+		Title:         article.Title,
+		Author:        article.Byline,
+		Excerpt:       article.Excerpt,
+		HTML:          article.Content,
+		Text:          article.TextContent,
+		ImageURL:      article.Image,
+		PublishedTime: article.PublishedTime,
+		FullPageHTML:  string(body),
+	}, nil
+}
+
+// ApplyContent copies extracted content onto a link, along with the
+// reading time worked out from it.
+func ApplyContent(record *core.Record, content *Content) {
+	record.Set("title", content.Title)
+	record.Set("excerpt", content.Excerpt)
+	record.Set("author", content.Author)
+	record.Set("article_html", content.HTML)
+	record.Set("raw_text_content", content.Text)
+	record.Set("header_image_url", content.ImageURL)
+	record.Set("full_page_html", content.FullPageHTML)
+	if content.PublishedTime != nil {
+		record.Set("article_date", content.PublishedTime)
+	} else {
+		record.Set("article_date", time.Now().UTC().Format(time.RFC3339))
+	}
+	if content.Kind != "" {
+		record.Set("kind", content.Kind)
+	} else {
+		record.Set("kind", KindArticle)
+	}
+	if content.Document != nil {
+		record.Set("document", content.Document)
+	} else {
+		// A link that used to be a document, and is now a web page
+		record.Set("document", "")
+	}
+
+	// Calculate read time, using 285 wpm as read rate, unless it's
+	// something to watch
+	readTime := content.Duration
+	if readTime <= 0 {
+		words := strings.Fields(content.Text)
+		wordCount := len(words)
+		minutes := float64(wordCount) / float64(285)
+		readTime = time.Duration(minutes * float64(time.Minute))
+	}
+	record.Set("read_time_seconds", int(math.Round(readTime.Seconds())))
+	record.Set("read_time_display", fmt.Sprintf("%d min", int(math.Round(readTime.Minutes()))))
+}
+
+// Used by extractors that need more than the page itself, like a
+// site's API
+var extractorClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetch makes a GET request on behalf of an extractor, failing on
+// anything but a successful response.
+func fetch(rawURL string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := extractorClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status from %s: %s", req.URL.Host, resp.Status)
+	}
+	return body, nil
+}
+
+func fetchJSON(rawURL string, headers map[string]string, v any) error {
+	body, err := fetch(rawURL, headers)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", rawURL, err)
+	}
+	return nil
+}
+
+// fetchArticle loads and extracts a page that another page links to,
+// like the article a discussion thread is about.
+func fetchArticle(rawURL string) (*Content, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	body, err := fetch(rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ReadabilityExtractor{}.Extract(body, pageURL)
+}
+
+// Elements that start a new line in the text of some HTML
+var blockElements = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"tr": true, "ul": true,
+}
+
+// htmlText returns the text of an HTML fragment, with a line break
+// wherever a block element starts or ends.
+func htmlText(fragment string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return ""
+	}
+
+	var text strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			text.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			if node.Data == "script" || node.Data == "style" {
+				return
+			}
+		}
+		block := node.Type == html.ElementNode && blockElements[node.Data]
+		if block {
+			text.WriteByte('\n')
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			text.WriteByte('\n')
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textToHTML turns plain text into paragraphs of HTML, one for each
+// block of lines separated by a blank line.
+func textToHTML(text string) string {
+	var result strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(strings.TrimSpace(line))
+		}
+		result.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>\n")
+	}
+	return result.String()
+}
+
+// documentFilename is the name a document is stored under, taken from
+// the URL it was saved from if that has a name with the extension.
+func documentFilename(pageURL *url.URL, ext string) string {
+	if pageURL != nil {
+		name := path.Base(pageURL.Path)
+		if strings.EqualFold(path.Ext(name), ext) && len(name) > len(ext) {
+			return name
+		}
+	}
+	return "document" + ext
+}
+
+// firstParagraph returns the first line of text that isn't blank.
+func firstParagraph(text string) string {
+	for _, paragraph := range strings.Split(text, "\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			return paragraph
+		}
+	}
+	return ""
+}
+
+// truncate shortens text to at most length characters, breaking at a
+// space where it can.
+func truncate(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	runes := []rune(text)
+	truncated := strings.TrimSpace(string(runes[:length]))
+	if space := strings.LastIndex(truncated, " "); space > length/2 {
+		truncated = truncated[:space]
+	}
+	return truncated + "…"
+}
@@ -0,0 +1,150 @@
+package url_parser
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubExtractor struct {
+	content *Content
+	err     error
+}
+
+func (e stubExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	return e.content, e.err
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestExtractorFor(t *testing.T) {
+	pdfBody := buildTestPDF("", [][][]string{{{"Text"}}})
+	htmlBody := []byte("<html></html>")
+	article := mustParseURL(t, "https://example.com/post")
+
+	assert.IsType(t, PDFExtractor{}, ExtractorFor(article, "application/pdf", pdfBody))
+	assert.IsType(t, PDFExtractor{}, ExtractorFor(article, "application/pdf; charset=binary", nil))
+	assert.IsType(t, PDFExtractor{}, ExtractorFor(article, "application/octet-stream", pdfBody))
+	assert.IsType(t, PDFExtractor{}, ExtractorFor(article, "", pdfBody))
+	assert.IsType(t, ReadabilityExtractor{}, ExtractorFor(article, "text/html; charset=utf-8", htmlBody))
+	assert.IsType(t, ReadabilityExtractor{}, ExtractorFor(article, "", htmlBody))
+
+	// Formats that don't have a distinctive enough start for
+	// http.DetectContentType are still recognised
+	assert.IsType(t, EPUBExtractor{}, ExtractorFor(article, "application/octet-stream", buildTestEPUB(t, "", "<p>Text</p>")))
+	assert.IsType(t, MHTMLExtractor{}, ExtractorFor(article, "", buildTestMHTML("", "base64")))
+	assert.IsType(t, TextExtractor{}, ExtractorFor(article, "text/plain; charset=utf-8", nil))
+
+	// PDFs are PDFs wherever they come from
+	assert.IsType(t, PDFExtractor{}, ExtractorFor(mustParseURL(t, "https://github.com/a/b/paper.pdf"), "application/pdf", pdfBody))
+
+	testCases := []struct {
+		url      string
+		expected Extractor
+	}{
+		{url: "https://www.youtube.com/watch?v=abc", expected: YouTubeExtractor{}},
+		{url: "https://youtu.be/abc", expected: YouTubeExtractor{}},
+		{url: "https://github.com/golang/go", expected: GitHubExtractor{}},
+		{url: "https://news.ycombinator.com/item?id=1", expected: HackerNewsExtractor{}},
+		{url: "https://old.reddit.com/r/golang/comments/abc/title/", expected: RedditExtractor{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			extractor := ExtractorFor(mustParseURL(t, tc.url), "text/html", htmlBody)
+			require.IsType(t, fallbackExtractor{}, extractor)
+			assert.Equal(t, tc.expected, extractor.(fallbackExtractor).extractor)
+		})
+	}
+}
+
+func TestExtractorForUpload(t *testing.T) {
+	htmlBody := []byte("<html></html>")
+
+	assert.IsType(t, PDFExtractor{}, ExtractorForUpload("", buildTestPDF("", [][][]string{{{"Text"}}})))
+	assert.IsType(t, EPUBExtractor{}, ExtractorForUpload("application/epub+zip", nil))
+	assert.IsType(t, MarkdownExtractor{}, ExtractorForUpload("text/markdown", nil))
+	assert.IsType(t, TextExtractor{}, ExtractorForUpload("", []byte("Just some text")))
+	assert.IsType(t, ReadabilityExtractor{}, ExtractorForUpload("text/html", htmlBody))
+	assert.IsType(t, ReadabilityExtractor{}, ExtractorForUpload("", htmlBody))
+}
+
+func TestRegisterSiteExtractor(t *testing.T) {
+	custom := stubExtractor{content: &Content{Title: "Custom"}}
+	RegisterSiteExtractor("Blog.Example.com", custom)
+	t.Cleanup(func() { RegisterSiteExtractor("blog.example.com", nil) })
+
+	extractor := ExtractorFor(mustParseURL(t, "https://www.blog.example.com/post"), "text/html", nil)
+	require.IsType(t, fallbackExtractor{}, extractor)
+	assert.Equal(t, custom, extractor.(fallbackExtractor).extractor)
+
+	assert.IsType(t, ReadabilityExtractor{}, ExtractorFor(mustParseURL(t, "https://example.com/post"), "text/html", nil))
+
+	RegisterSiteExtractor("blog.example.com", nil)
+	assert.IsType(t, ReadabilityExtractor{}, ExtractorFor(mustParseURL(t, "https://blog.example.com/post"), "text/html", nil))
+}
+
+func TestFallbackExtractor(t *testing.T) {
+	fallback := stubExtractor{content: &Content{Title: "Fallback"}}
+
+	content, err := fallbackExtractor{stubExtractor{content: &Content{Title: "First"}}, fallback}.Extract(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "First", content.Title)
+
+	content, err = fallbackExtractor{stubExtractor{err: errors.New("no video")}, fallback}.Extract(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Fallback", content.Title)
+
+	_, err = fallbackExtractor{stubExtractor{err: errors.New("no video")}, stubExtractor{err: errors.New("no article")}}.Extract(nil, nil)
+	assert.EqualError(t, err, "no article (after no video)")
+}
+
+func TestApplyContentDuration(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+	collection, err := testApp.FindCollectionByNameOrId("links")
+	require.NoError(t, err)
+
+	content := &Content{Text: "A few words", Duration: 90 * time.Second}
+	link := core.NewRecord(collection)
+	ApplyContent(link, content)
+	assert.Equal(t, 90, link.GetInt("read_time_seconds"))
+	assert.Equal(t, "2 min", link.GetString("read_time_display"))
+	assert.Equal(t, KindArticle, link.GetString("kind"))
+
+	content.Kind = KindVideo
+	ApplyContent(link, content)
+	assert.Equal(t, KindVideo, link.GetString("kind"))
+}
+
+func TestHTMLText(t *testing.T) {
+	assert.Equal(t,
+		"Title\nFirst paragraph with a link.\nOne\nTwo\nLine\nbreak",
+		htmlText(`<h1>Title</h1><p>First   paragraph with <a href="/">a link</a>.</p><ul><li>One</li><li>Two</li></ul><p>Line<br>break</p><script>alert(1)</script>`),
+	)
+	assert.Equal(t, "", htmlText(""))
+}
+
+func TestTextToHTML(t *testing.T) {
+	assert.Equal(t,
+		"<p>First line<br>second line</p>\n<p>&lt;b&gt; isn&#39;t bold</p>\n",
+		textToHTML("First line\nsecond line\n\n\n<b> isn't bold\n"),
+	)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "one two…", truncate("one two three four", 10))
+	assert.Equal(t, "abcdefghij…", truncate("abcdefghijklmnop", 10))
+}
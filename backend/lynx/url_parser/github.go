@@ -0,0 +1,154 @@
+package url_parser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Overridden in tests
+var githubAPIURL = "https://api.github.com"
+
+// GitHubExtractor extracts repositories, through their README, and
+// issues and pull requests, through their description and comments,
+// using the GitHub API. Requests are made with the token in the
+// GITHUB_TOKEN environment variable if it's set, which raises GitHub's
+// rate limit.
+type GitHubExtractor struct{}
+
+// Most comments included with an issue
+const githubMaxComments = 50
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubRepo struct {
+	FullName    string     `json:"full_name"`
+	Description string     `json:"description"`
+	Owner       githubUser `json:"owner"`
+	PushedAt    time.Time  `json:"pushed_at"`
+}
+
+type githubIssue struct {
+	Title     string     `json:"title"`
+	User      githubUser `json:"user"`
+	BodyHTML  string     `json:"body_html"`
+	BodyText  string     `json:"body_text"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type githubComment struct {
+	User     githubUser `json:"user"`
+	BodyHTML string     `json:"body_html"`
+	BodyText string     `json:"body_text"`
+}
+
+func (GitHubExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	// Other subdomains, like gist.github.com, aren't repositories
+	if host := strings.ToLower(pageURL.Hostname()); host != "github.com" && host != "www.github.com" {
+		return nil, errors.New("not a GitHub repository or issue")
+	}
+	parts := strings.Split(strings.Trim(pageURL.Path, "/"), "/")
+
+	var content *Content
+	var err error
+	switch {
+	case len(parts) == 2:
+		content, err = githubRepoContent(parts[0], parts[1])
+	case len(parts) == 4 && parts[2] == "issues":
+		content, err = githubIssueContent(parts[0], parts[1], parts[3], "Issue")
+	case len(parts) == 4 && parts[2] == "pull":
+		// Pull requests are issues as far as their description and
+		// comments go
+		content, err = githubIssueContent(parts[0], parts[1], parts[3], "Pull Request")
+	default:
+		return nil, errors.New("not a GitHub repository or issue")
+	}
+	if err != nil {
+		return nil, err
+	}
+	content.FullPageHTML = string(body)
+	return content, nil
+}
+
+func githubRepoContent(owner string, name string) (*Content, error) {
+	repoPath := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+
+	var repo githubRepo
+	if err := fetchJSON(githubAPIURL+repoPath, githubHeaders("application/vnd.github+json"), &repo); err != nil {
+		return nil, fmt.Errorf("failed to load repository: %w", err)
+	}
+
+	// Some repositories don't have a README, and are saved with just
+	// their description
+	readme, err := fetch(githubAPIURL+repoPath+"/readme", githubHeaders("application/vnd.github.html+json"))
+	if err != nil {
+		readme = nil
+	}
+
+	content := &Content{
+		Title:   repo.FullName,
+		Author:  repo.Owner.Login,
+		Excerpt: repo.Description,
+		HTML:    textToHTML(repo.Description) + string(readme),
+		Text:    strings.TrimSpace(repo.Description + "\n\n" + htmlText(string(readme))),
+	}
+	if !repo.PushedAt.IsZero() {
+		content.PublishedTime = &repo.PushedAt
+	}
+	return content, nil
+}
+
+func githubIssueContent(owner string, name string, number string, kind string) (*Content, error) {
+	issuePath := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name) + "/issues/" + url.PathEscape(number)
+	headers := githubHeaders("application/vnd.github.full+json")
+
+	var issue githubIssue
+	if err := fetchJSON(githubAPIURL+issuePath, headers, &issue); err != nil {
+		return nil, fmt.Errorf("failed to load issue: %w", err)
+	}
+
+	var comments []githubComment
+	if err := fetchJSON(fmt.Sprintf("%s%s/comments?per_page=%d", githubAPIURL, issuePath, githubMaxComments), headers, &comments); err != nil {
+		return nil, fmt.Errorf("failed to load comments: %w", err)
+	}
+
+	var htmlContent, text strings.Builder
+	htmlContent.WriteString(issue.BodyHTML)
+	text.WriteString(issue.BodyText)
+	if len(comments) > 0 {
+		htmlContent.WriteString("\n<h2>Comments</h2>\n")
+	}
+	for _, comment := range comments {
+		fmt.Fprintf(&htmlContent, "<blockquote>\n<p><strong>%s</strong></p>\n%s\n</blockquote>\n", html.EscapeString(comment.User.Login), comment.BodyHTML)
+		fmt.Fprintf(&text, "\n\n%s: %s", comment.User.Login, comment.BodyText)
+	}
+
+	content := &Content{
+		Title:   fmt.Sprintf("%s · %s #%s · %s/%s", issue.Title, kind, number, owner, name),
+		Author:  issue.User.Login,
+		Excerpt: truncate(firstParagraph(issue.BodyText), excerptLength),
+		HTML:    htmlContent.String(),
+		Text:    strings.TrimSpace(text.String()),
+	}
+	if !issue.CreatedAt.IsZero() {
+		content.PublishedTime = &issue.CreatedAt
+	}
+	return content, nil
+}
+
+func githubHeaders(accept string) map[string]string {
+	headers := map[string]string{
+		"Accept":               accept,
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return headers
+}
@@ -0,0 +1,104 @@
+package url_parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupGitHubServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/widgets":
+			w.Write([]byte(`{"full_name": "octo/widgets", "description": "Widgets for everyone", "owner": {"login": "octo"}, "pushed_at": "2025-01-02T03:04:05Z"}`))
+		case "/repos/octo/widgets/readme":
+			if r.Header.Get("Accept") != "application/vnd.github.html+json" {
+				http.Error(w, "wrong accept header", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`<div id="readme"><h1>Widgets</h1><p>Install with <code>go get</code>.</p></div>`))
+		case "/repos/octo/empty":
+			w.Write([]byte(`{"full_name": "octo/empty", "description": "Nothing here yet", "owner": {"login": "octo"}}`))
+		case "/repos/octo/widgets/issues/7":
+			w.Write([]byte(`{"title": "Widgets break on Tuesdays", "user": {"login": "alice"}, "body_text": "Every Tuesday the widgets break.\nSee logs.", "body_html": "<p>Every Tuesday the widgets break.<br>See logs.</p>", "created_at": "2025-02-03T04:05:06Z"}`))
+		case "/repos/octo/widgets/issues/7/comments":
+			w.Write([]byte(`[{"user": {"login": "bob"}, "body_text": "Can't reproduce", "body_html": "<p>Can't reproduce</p>"}, {"user": {"login": "alice"}, "body_text": "Try a Tuesday", "body_html": "<p>Try a Tuesday</p>"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalURL := githubAPIURL
+	githubAPIURL = server.URL
+	t.Cleanup(func() { githubAPIURL = originalURL })
+}
+
+func TestGitHubExtractorRepo(t *testing.T) {
+	setupGitHubServer(t)
+
+	content, err := GitHubExtractor{}.Extract([]byte("<html></html>"), mustParseURL(t, "https://github.com/octo/widgets"))
+	require.NoError(t, err)
+	assert.Equal(t, "octo/widgets", content.Title)
+	assert.Equal(t, "octo", content.Author)
+	assert.Equal(t, "Widgets for everyone", content.Excerpt)
+	assert.Equal(t, "Widgets for everyone\n\nWidgets\nInstall with go get.", content.Text)
+	assert.Equal(t, `<p>Widgets for everyone</p>
+<div id="readme"><h1>Widgets</h1><p>Install with <code>go get</code>.</p></div>`, content.HTML)
+	require.NotNil(t, content.PublishedTime)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), *content.PublishedTime)
+	assert.Equal(t, "<html></html>", content.FullPageHTML)
+
+	// Without a README
+	content, err = GitHubExtractor{}.Extract(nil, mustParseURL(t, "https://github.com/octo/empty/"))
+	require.NoError(t, err)
+	assert.Equal(t, "Nothing here yet", content.Text)
+	assert.Nil(t, content.PublishedTime)
+}
+
+func TestGitHubExtractorIssue(t *testing.T) {
+	setupGitHubServer(t)
+
+	content, err := GitHubExtractor{}.Extract(nil, mustParseURL(t, "https://github.com/octo/widgets/issues/7"))
+	require.NoError(t, err)
+	assert.Equal(t, "Widgets break on Tuesdays · Issue #7 · octo/widgets", content.Title)
+	assert.Equal(t, "alice", content.Author)
+	assert.Equal(t, "Every Tuesday the widgets break.", content.Excerpt)
+	assert.Equal(t, "Every Tuesday the widgets break.\nSee logs.\n\nbob: Can't reproduce\n\nalice: Try a Tuesday", content.Text)
+	assert.Equal(t, `<p>Every Tuesday the widgets break.<br>See logs.</p>
+<h2>Comments</h2>
+<blockquote>
+<p><strong>bob</strong></p>
+<p>Can't reproduce</p>
+</blockquote>
+<blockquote>
+<p><strong>alice</strong></p>
+<p>Try a Tuesday</p>
+</blockquote>
+`, content.HTML)
+
+	// Pull requests share the issues API
+	content, err = GitHubExtractor{}.Extract(nil, mustParseURL(t, "https://github.com/octo/widgets/pull/7"))
+	require.NoError(t, err)
+	assert.Equal(t, "Widgets break on Tuesdays · Pull Request #7 · octo/widgets", content.Title)
+}
+
+func TestGitHubExtractorErrors(t *testing.T) {
+	setupGitHubServer(t)
+
+	for _, rawURL := range []string{
+		"https://github.com/octo",
+		"https://github.com/octo/widgets/blob/main/README.md",
+		"https://gist.github.com/octo/abc",
+	} {
+		_, err := GitHubExtractor{}.Extract(nil, mustParseURL(t, rawURL))
+		assert.EqualError(t, err, "not a GitHub repository or issue", rawURL)
+	}
+
+	_, err := GitHubExtractor{}.Extract(nil, mustParseURL(t, "https://github.com/octo/missing"))
+	assert.ErrorContains(t, err, "failed to load repository: unexpected status")
+}
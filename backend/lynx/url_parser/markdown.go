@@ -0,0 +1,54 @@
+package url_parser
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Renders notes and Markdown files with GitHub flavored Markdown. Raw
+// HTML isn't passed through; goldmark leaves a <!-- raw HTML omitted -->
+// comment in its place.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// MarkdownExtractor renders Markdown files, titled after their first
+// line like plain text.
+type MarkdownExtractor struct{}
+
+func (MarkdownExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	if !utf8.Valid(body) {
+		return nil, errors.New("text isn't valid UTF-8")
+	}
+
+	content, err := renderMarkdown(string(body))
+	if err != nil {
+		return nil, err
+	}
+	title, rest, _ := strings.Cut(content.Text, "\n")
+	if title = strings.TrimSpace(title); utf8.RuneCountInString(title) <= maxTextTitleLength {
+		content.Title = title
+		content.Excerpt = truncate(firstParagraph(rest), excerptLength)
+	}
+	return content, nil
+}
+
+// renderMarkdown returns the rendered HTML and text of a Markdown
+// document, with an excerpt taken from the start of the text.
+func renderMarkdown(body string) (*Content, error) {
+	var rendered bytes.Buffer
+	if err := markdown.Convert([]byte(body), &rendered); err != nil {
+		return nil, err
+	}
+
+	text := htmlText(rendered.String())
+	return &Content{
+		HTML:    rendered.String(),
+		Text:    text,
+		Excerpt: truncate(firstParagraph(text), excerptLength),
+	}, nil
+}
@@ -0,0 +1,23 @@
+package url_parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownExtractor(t *testing.T) {
+	content, err := MarkdownExtractor{}.Extract([]byte("# Release Notes\r\n\r\nAdds **dark mode**.\r\n\r\n- Faster search\r\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Release Notes", content.Title)
+	assert.Equal(t, "Adds dark mode.", content.Excerpt)
+	assert.Equal(t, "Release Notes\nAdds dark mode.\nFaster search", content.Text)
+	assert.Contains(t, content.HTML, "<h1>Release Notes</h1>")
+	assert.Contains(t, content.HTML, "<strong>dark mode</strong>")
+	assert.Contains(t, content.HTML, "<li>Faster search</li>")
+	assert.Empty(t, content.Kind)
+
+	_, err = MarkdownExtractor{}.Extract([]byte{0xff, 0xfe, 0x00}, nil)
+	assert.EqualError(t, err, "text isn't valid UTF-8")
+}
@@ -0,0 +1,91 @@
+package url_parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// MHTMLExtractor extracts the article from a page saved as MHTML, the
+// single file format browsers use for "Save page as". The file is the
+// page's HTML followed by its resources, as a MIME message.
+type MHTMLExtractor struct{}
+
+func (MHTMLExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	page, location, err := mhtmlPage(body)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceURL := pageURL
+	if parsed, err := url.Parse(location); sourceURL == nil && err == nil && parsed.Host != "" {
+		sourceURL = parsed
+	}
+
+	content, err := ReadabilityExtractor{}.Extract(page, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	content.URL = location
+	return content, nil
+}
+
+// mhtmlPage returns the HTML of the page in an MHTML file, along with
+// the URL the page was saved from, if the file says.
+func mhtmlPage(body []byte) ([]byte, string, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read MHTML: %w", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, "", errors.New("MHTML isn't a multipart message")
+	}
+	location := message.Header.Get("Snapshot-Content-Location")
+
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return nil, "", errors.New("MHTML has no HTML page")
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read MHTML: %w", err)
+		}
+		if partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); partType != "text/html" {
+			continue
+		}
+
+		// Quoted-printable parts are decoded by the multipart reader,
+		// but base64 isn't
+		var reader io.Reader = part
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			reader = base64.NewDecoder(base64.StdEncoding, part)
+		}
+		page, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read MHTML: %w", err)
+		}
+		if location == "" {
+			location = part.Header.Get("Content-Location")
+		}
+		return page, location, nil
+	}
+}
+
+// isMHTML reports whether the body looks like an MHTML file.
+func isMHTML(body []byte) bool {
+	message, err := mail.ReadMessage(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	return mediaType == "multipart/related"
+}
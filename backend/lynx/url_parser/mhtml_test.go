@@ -0,0 +1,74 @@
+package url_parser
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMHTMLArticle = `<html><head><title>Members Only</title><link rel="canonical" href="https://example.com/members/post"></head><body><article><h1>Members Only</h1><p>A post only members can read, with enough words in it that readability keeps it around.</p></article></body></html>`
+
+// buildTestMHTML writes an MHTML file like the ones browsers save,
+// with the page encoded as encoding and an image after it.
+func buildTestMHTML(location string, encoding string) []byte {
+	page := testMHTMLArticle
+	switch encoding {
+	case "quoted-printable":
+		page = strings.ReplaceAll(page, "=", "=3D")
+	case "base64":
+		page = base64.StdEncoding.EncodeToString([]byte(page))
+	}
+	return []byte(strings.ReplaceAll(`From: <Saved by Blink>
+Snapshot-Content-Location: `+location+`
+Subject: Members Only
+MIME-Version: 1.0
+Content-Type: multipart/related;
+	type="text/html";
+	boundary="----MultipartBoundary--abc----"
+
+------MultipartBoundary--abc----
+Content-Type: image/png
+Content-Transfer-Encoding: base64
+Content-Location: https://example.com/logo.png
+
+iVBORw0KGgo=
+
+------MultipartBoundary--abc----
+Content-Type: text/html
+Content-ID: <frame-1@mhtml.blink>
+Content-Transfer-Encoding: `+encoding+`
+Content-Location: https://example.com/members/post?ref=home
+
+`+page+`
+------MultipartBoundary--abc------
+`, "\n", "\r\n"))
+}
+
+func TestMHTMLExtractor(t *testing.T) {
+	for _, encoding := range []string{"quoted-printable", "base64"} {
+		t.Run(encoding, func(t *testing.T) {
+			content, err := MHTMLExtractor{}.Extract(buildTestMHTML("https://example.com/members/post?ref=snapshot", encoding), nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Members Only", content.Title)
+			assert.Contains(t, content.Text, "A post only members can read")
+			assert.Equal(t, testMHTMLArticle, content.FullPageHTML)
+			assert.Equal(t, "https://example.com/members/post?ref=snapshot", content.URL)
+		})
+	}
+
+	// Without a snapshot location, the page's own location is used
+	content, err := MHTMLExtractor{}.Extract(buildTestMHTML("", "quoted-printable"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/members/post?ref=home", content.URL)
+}
+
+func TestMHTMLExtractorInvalid(t *testing.T) {
+	_, err := MHTMLExtractor{}.Extract([]byte("<html></html>"), nil)
+	assert.Error(t, err)
+
+	_, err = MHTMLExtractor{}.Extract([]byte("Content-Type: multipart/related; boundary=b\r\n\r\n--b\r\nContent-Type: image/png\r\n\r\nxyz\r\n--b--\r\n"), nil)
+	assert.EqualError(t, err, "MHTML has no HTML page")
+}
@@ -0,0 +1,331 @@
+package url_parser
+
+// Cleans up URLs before they're fetched and stored, so that the same
+// page is always saved under the same URL. The built-in rules drop
+// tracking parameters and fragments and turn AMP and mobile variants
+// into the regular page. Users can add their own rewrite rules on top
+// of these (see ParseRewriteRules), and pages can declare their
+// canonical URL (see CanonicalURL).
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Query parameters that only track where a visitor came from, and never
+// change which page is shown.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"ref":     true,
+	"ref_src": true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"yclid":   true,
+}
+
+var trackingParamPrefixes = []string{"utm_", "pk_", "mtm_"}
+
+// Query parameters that ask for the AMP version of a page
+var ampParams = map[string]string{
+	"amp":        "",
+	"outputtype": "amp",
+}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	if trackingParams[name] {
+		return true
+	}
+	for _, prefix := range trackingParamPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanURL returns a copy of the URL with the built-in rules applied:
+// tracking parameters and the fragment are removed, and AMP and mobile
+// variants are turned into the regular page. AMP paths like /post/amp
+// are only shortened for pages served from an AMP cache, since plenty
+// of regular pages end in /amp too; other AMP pages are recognized by
+// their canonical URL instead (see CanonicalURL).
+func CleanURL(u *url.URL) *url.URL {
+	cleaned, fromAMPCache := unwrapAMPCache(u)
+	cleaned.Fragment = ""
+	cleaned.RawFragment = ""
+
+	host := removeHostLabels(strings.ToLower(cleaned.Hostname()), "amp", "m", "mobile")
+	if port := cleaned.Port(); port != "" {
+		cleaned.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6
+		cleaned.Host = "[" + host + "]"
+	} else {
+		cleaned.Host = host
+	}
+
+	if fromAMPCache {
+		for _, suffix := range []string{"/amp/", "/amp", ".amp"} {
+			if trimmed, ok := strings.CutSuffix(cleaned.Path, suffix); ok {
+				cleaned.Path = trimmed
+				if suffix == "/amp/" || cleaned.Path == "" {
+					cleaned.Path += "/"
+				}
+				cleaned.RawPath = ""
+				break
+			}
+		}
+	}
+
+	query := cleaned.Query()
+	for name, values := range query {
+		lower := strings.ToLower(name)
+		if ampValue, ok := ampParams[lower]; ok && (ampValue == "" || strings.EqualFold(values[0], ampValue)) {
+			query.Del(name)
+		} else if isTrackingParam(name) {
+			query.Del(name)
+		}
+	}
+	setQuery(cleaned, query)
+
+	return cleaned
+}
+
+// unwrapAMPCache returns the original URL of a page served from Google's
+// AMP viewer or the AMP cache and true, or a copy of the URL and false
+// otherwise.
+func unwrapAMPCache(u *url.URL) (*url.URL, bool) {
+	host := strings.ToLower(u.Hostname())
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		// e.g. example-com.cdn.ampproject.org/c/s/example.com/post
+		_, rest, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	case strings.HasPrefix(host, "google.") || strings.HasPrefix(host, "www.google."):
+		rest, _ = strings.CutPrefix(u.Path, "/amp/")
+	}
+
+	if rest != "" {
+		scheme := "http"
+		if after, ok := strings.CutPrefix(rest, "s/"); ok {
+			scheme, rest = "https", after
+		}
+		if original, err := url.Parse(scheme + "://" + rest); err == nil && original.Host != "" {
+			original.RawQuery = u.RawQuery
+			return original, true
+		}
+	}
+
+	copied := *u
+	return &copied, false
+}
+
+// removeHostLabels drops labels like "m" from a hostname, as in
+// m.example.com or en.m.wikipedia.org, as long as that leaves at least
+// a domain and top level domain.
+func removeHostLabels(host string, remove ...string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(host, ".")
+	kept := make([]string, 0, len(labels))
+	for i, label := range labels {
+		if i < len(labels)-2 && slices.Contains(remove, label) {
+			continue
+		}
+		kept = append(kept, label)
+	}
+	return strings.Join(kept, ".")
+}
+
+func setQuery(u *url.URL, query url.Values) {
+	if len(query) == 0 {
+		u.RawQuery = ""
+		u.ForceQuery = false
+		return
+	}
+	u.RawQuery = query.Encode()
+}
+
+// NormalizeURL reduces a URL to a key that is the same for every
+// variant of the URL that points at the same page, for detecting
+// duplicate links. On top of the built-in rules in CleanURL, the
+// scheme, a leading www., default ports and trailing slashes are
+// dropped and the query parameters are sorted. The result is not meant
+// to be loaded.
+func NormalizeURL(u *url.URL) string {
+	cleaned := CleanURL(u)
+
+	host := strings.TrimPrefix(cleaned.Hostname(), "www.")
+	if port := cleaned.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	normalized := host + strings.TrimRight(cleaned.EscapedPath(), "/")
+	if query := cleaned.Query(); len(query) > 0 {
+		// Sorted by name
+		normalized += "?" + query.Encode()
+	}
+	return normalized
+}
+
+// NormalizeURLString is NormalizeURL for a URL that hasn't been parsed
+// yet. Returns the input unchanged if it can't be parsed.
+func NormalizeURLString(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return NormalizeURL(u)
+}
+
+// RewriteRule is a user's own rule for cleaning up URLs on a site.
+type RewriteRule struct {
+	// The hostname the rule applies to, including its subdomains, or
+	// "*" for every site
+	Host string
+	// Replaces the hostname, e.g. to read a site through a mirror
+	RewriteHost string
+	// Query parameters to remove
+	StripParams []string
+}
+
+// ParseRewriteRules parses rewrite rules written one per line as the
+// hostname, optionally followed by "=> newhost" and then any number of
+// "-param" query parameters to remove. Blank lines and lines starting
+// with # are ignored. For example:
+//
+//	twitter.com => nitter.net -s -t
+//	* -share
+func ParseRewriteRules(text string) ([]RewriteRule, error) {
+	var rules []RewriteRule
+	for i, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		rule := RewriteRule{Host: strings.ToLower(fields[0])}
+		rest := fields[1:]
+		if len(rest) > 0 && rest[0] == "=>" {
+			if len(rest) < 2 || strings.HasPrefix(rest[1], "-") {
+				return nil, fmt.Errorf("line %d: missing hostname after =>", i+1)
+			}
+			rule.RewriteHost = strings.ToLower(rest[1])
+			rest = rest[2:]
+		}
+		for _, field := range rest {
+			param, ok := strings.CutPrefix(field, "-")
+			if !ok || param == "" {
+				return nil, fmt.Errorf("line %d: unexpected %q, expected => newhost or -param", i+1, field)
+			}
+			rule.StripParams = append(rule.StripParams, param)
+		}
+		if rule.RewriteHost == "" && len(rule.StripParams) == 0 {
+			return nil, fmt.Errorf("line %d: rule for %s doesn't change anything", i+1, rule.Host)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r RewriteRule) matches(host string) bool {
+	return r.Host == "*" || host == r.Host || strings.HasSuffix(host, "."+r.Host)
+}
+
+// ApplyRewriteRules returns a copy of the URL with every matching rule
+// applied in order.
+func ApplyRewriteRules(u *url.URL, rules []RewriteRule) *url.URL {
+	rewritten := *u
+	for _, rule := range rules {
+		host := strings.ToLower(rewritten.Hostname())
+		if !rule.matches(host) {
+			continue
+		}
+		if rule.RewriteHost != "" {
+			rewritten.Host = rule.RewriteHost
+		}
+		if len(rule.StripParams) > 0 {
+			query := rewritten.Query()
+			for _, param := range rule.StripParams {
+				query.Del(param)
+			}
+			setQuery(&rewritten, query)
+		}
+	}
+	return &rewritten
+}
+
+// CanonicalURL returns the URL a page declares as its canonical
+// address, through <link rel="canonical"> or the og:url property, or
+// nil if it doesn't declare one. Canonical URLs pointing at the site's
+// home page from another page are ignored, since that's more often a
+// misconfigured site than the truth.
+func CanonicalURL(body []byte, pageURL *url.URL) *url.URL {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var canonical, ogURL string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "link":
+				if canonical == "" && slices.Contains(strings.Fields(strings.ToLower(attr(node, "rel"))), "canonical") {
+					canonical = attr(node, "href")
+				}
+			case "meta":
+				if ogURL == "" && strings.EqualFold(attr(node, "property"), "og:url") {
+					ogURL = attr(node, "content")
+				}
+			case "body":
+				// Canonical URLs only belong in the head
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	for _, candidate := range []string{canonical, ogURL} {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		resolved, err := pageURL.Parse(candidate)
+		if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") || resolved.Host == "" {
+			continue
+		}
+		if strings.Trim(resolved.Path, "/") == "" && strings.Trim(pageURL.Path, "/") != "" {
+			continue
+		}
+		return resolved
+	}
+	return nil
+}
+
+func attr(node *html.Node, name string) string {
+	for _, a := range node.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,190 @@
+package url_parser
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://example.com", expected: "example.com"},
+		{url: "http://www.Example.com/", expected: "example.com"},
+		{url: "https://example.com:443/post/", expected: "example.com/post"},
+		{url: "https://example.com:8080/post", expected: "example.com:8080/post"},
+		{url: "https://example.com/post#comments", expected: "example.com/post"},
+		{url: "https://example.com/post?utm_source=rss&utm_medium=feed&fbclid=abc&ref=hn", expected: "example.com/post"},
+		{url: "https://example.com/watch?v=123&t=10&utm_campaign=x", expected: "example.com/watch?t=10&v=123"},
+		{url: "https://example.com/a%20b/", expected: "example.com/a%20b"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			parsed, err := url.Parse(tc.url)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, NormalizeURL(parsed))
+		})
+	}
+}
+
+func TestNormalizeURLString(t *testing.T) {
+	assert.Equal(t, "example.com/post", NormalizeURLString(" https://www.example.com/post/ "))
+	assert.Equal(t, "not a url", NormalizeURLString("not a url"))
+	assert.Equal(t, "", NormalizeURLString(""))
+}
+
+func TestCleanURL(t *testing.T) {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://Example.com/post?utm_source=rss#comments", expected: "https://example.com/post"},
+		{url: "https://m.example.com/post", expected: "https://example.com/post"},
+		{url: "https://en.m.wikipedia.org/wiki/Go", expected: "https://en.wikipedia.org/wiki/Go"},
+		{url: "https://mobile.example.com:8080/post", expected: "https://example.com:8080/post"},
+		{url: "https://m.com/post", expected: "https://m.com/post"},
+		{url: "https://amp.example.com/post", expected: "https://example.com/post"},
+		// Only pages from an AMP cache lose their AMP path, since it's
+		// often part of a regular page's address
+		{url: "https://example.com/post/amp", expected: "https://example.com/post/amp"},
+		{url: "https://github.com/ampproject/amp", expected: "https://github.com/ampproject/amp"},
+		{url: "https://www.npmjs.com/package/amp", expected: "https://www.npmjs.com/package/amp"},
+		{url: "https://example-com.cdn.ampproject.org/c/s/example.com/post/amp/", expected: "https://example.com/post/"},
+		{url: "https://example-com.cdn.ampproject.org/c/s/example.com/post.amp", expected: "https://example.com/post"},
+		{url: "https://example-com.cdn.ampproject.org/c/s/example.com/amp", expected: "https://example.com/"},
+		{url: "https://example.com/post?amp=1&page=2", expected: "https://example.com/post?page=2"},
+		{url: "https://example.com/post?outputType=amp", expected: "https://example.com/post"},
+		{url: "https://example.com/post?outputType=print", expected: "https://example.com/post?outputType=print"},
+		{url: "https://www.google.com/amp/s/example.com/post/amp", expected: "https://example.com/post"},
+		{url: "https://example-com.cdn.ampproject.org/c/s/example.com/post?amp", expected: "https://example.com/post"},
+		{url: "https://example-com.cdn.ampproject.org/c/example.com/post", expected: "http://example.com/post"},
+		{url: "http://192.168.1.1/post", expected: "http://192.168.1.1/post"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			parsed, err := url.Parse(tc.url)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, CleanURL(parsed).String())
+		})
+	}
+}
+
+func TestCleanURLDoesNotModifyInput(t *testing.T) {
+	parsed, err := url.Parse("https://m.example.com/post?utm_source=rss")
+	require.NoError(t, err)
+	CleanURL(parsed)
+	assert.Equal(t, "https://m.example.com/post?utm_source=rss", parsed.String())
+}
+
+func TestParseRewriteRules(t *testing.T) {
+	rules, err := ParseRewriteRules(`
+# Read tweets through a mirror
+Twitter.com => nitter.net -s -t
+
+* -share
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []RewriteRule{
+		{Host: "twitter.com", RewriteHost: "nitter.net", StripParams: []string{"s", "t"}},
+		{Host: "*", StripParams: []string{"share"}},
+	}, rules)
+
+	rules, err = ParseRewriteRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = ParseRewriteRules("twitter.com =>")
+	assert.EqualError(t, err, "line 1: missing hostname after =>")
+	_, err = ParseRewriteRules("\ntwitter.com nitter.net")
+	assert.EqualError(t, err, `line 2: unexpected "nitter.net", expected => newhost or -param`)
+	_, err = ParseRewriteRules("twitter.com")
+	assert.EqualError(t, err, "line 1: rule for twitter.com doesn't change anything")
+}
+
+func TestApplyRewriteRules(t *testing.T) {
+	rules, err := ParseRewriteRules("twitter.com => nitter.net -s\n* -share")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://twitter.com/user/status/1?s=20&share=1", expected: "https://nitter.net/user/status/1"},
+		{url: "https://mobile.twitter.com/user?s=20", expected: "https://nitter.net/user"},
+		{url: "https://nottwitter.com/user?s=20", expected: "https://nottwitter.com/user?s=20"},
+		{url: "https://example.com/post?share=1&page=2", expected: "https://example.com/post?page=2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			parsed, err := url.Parse(tc.url)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ApplyRewriteRules(parsed, rules).String())
+		})
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	pageURL, err := url.Parse("https://example.com/posts/1?page=2")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "Canonical link",
+			html:     `<html><head><link rel="canonical" href="https://example.com/posts/first-post"><meta property="og:url" content="https://example.com/og"></head></html>`,
+			expected: "https://example.com/posts/first-post",
+		},
+		{
+			name:     "Relative canonical link",
+			html:     `<html><head><link rel="Canonical" href="/posts/first-post"></head></html>`,
+			expected: "https://example.com/posts/first-post",
+		},
+		{
+			name:     "Open Graph URL",
+			html:     `<html><head><meta property="og:url" content="https://example.com/og"></head></html>`,
+			expected: "https://example.com/og",
+		},
+		{
+			name:     "Canonical link to the home page",
+			html:     `<html><head><link rel="canonical" href="https://example.com/"></head></html>`,
+			expected: "",
+		},
+		{
+			name:     "Canonical link in the body",
+			html:     `<html><head></head><body><link rel="canonical" href="https://example.com/elsewhere"></body></html>`,
+			expected: "",
+		},
+		{
+			name:     "Not http",
+			html:     `<html><head><link rel="canonical" href="javascript:alert(1)"></head></html>`,
+			expected: "",
+		},
+		{
+			name:     "No canonical URL",
+			html:     `<html><head><title>Post</title></head></html>`,
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			canonical := CanonicalURL([]byte(tc.html), pageURL)
+			if tc.expected == "" {
+				assert.Nil(t, canonical)
+			} else {
+				require.NotNil(t, canonical)
+				assert.Equal(t, tc.expected, canonical.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package url_parser
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Longest first line that's taken as the title of a note without one
+const maxNoteTitleLength = 200
+
+// Given a title and Markdown body, create a new Link record in
+// pocketbase for a note that isn't from anywhere on the web.
+func HandleCreateNoteRequest(app core.App, e *core.RequestEvent) (*core.Record, error) {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return nil, apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	body := e.Request.FormValue("body")
+	if strings.TrimSpace(body) == "" {
+		return nil, apis.NewBadRequestError("Missing 'body' parameter", nil)
+	}
+
+	content, err := NoteContent(e.Request.FormValue("title"), body)
+	if err != nil {
+		return nil, apis.NewBadRequestError("Failed to render note", err)
+	}
+
+	return saveContent(app, authRecord.Id, nil, nil, nil, content, nil)
+}
+
+// NoteContent renders a Markdown note as content for a link. Notes
+// without a title are titled after their first line.
+func NoteContent(title string, body string) (*Content, error) {
+	content, err := renderMarkdown(body)
+	if err != nil {
+		return nil, err
+	}
+
+	content.Title = strings.TrimSpace(title)
+	content.Kind = KindNote
+	if content.Title == "" {
+		content.Title = truncate(firstParagraph(content.Text), maxNoteTitleLength)
+	}
+	return content, nil
+}
@@ -0,0 +1,30 @@
+package url_parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoteContent(t *testing.T) {
+	content, err := NoteContent(" Shopping ", "Things to buy:\n\n- [ ] **Bread**\n- ~~Milk~~\n\n<script>alert(1)</script>\n\nSee https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Shopping", content.Title)
+	assert.Equal(t, KindNote, content.Kind)
+	assert.Equal(t, "Things to buy:", content.Excerpt)
+	assert.Contains(t, content.HTML, "<strong>Bread</strong>")
+	assert.Contains(t, content.HTML, "<del>Milk</del>")
+	assert.Contains(t, content.HTML, `<a href="https://example.com">https://example.com</a>`)
+	assert.NotContains(t, content.HTML, "<script>")
+	assert.Contains(t, content.HTML, "<!-- raw HTML omitted -->")
+	assert.Equal(t, "Things to buy:\nBread\nMilk\nSee https://example.com", content.Text)
+	assert.Empty(t, content.FullPageHTML)
+	assert.Nil(t, content.Document)
+
+	// Untitled notes are titled after their first line
+	content, err = NoteContent("", "# Idea\n\nA thought worth keeping.")
+	require.NoError(t, err)
+	assert.Equal(t, "Idea", content.Title)
+	assert.Equal(t, "Idea\nA thought worth keeping.", content.Text)
+}
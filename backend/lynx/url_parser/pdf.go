@@ -0,0 +1,142 @@
+package url_parser
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// PDFExtractor extracts the text and metadata of a PDF, and keeps the
+// PDF itself as the link's document.
+type PDFExtractor struct{}
+
+func (PDFExtractor) Extract(body []byte, pageURL *url.URL) (content *Content, err error) {
+	// The PDF reader panics on malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			content, err = nil, fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	var paragraphs []string
+	for i := 1; i <= reader.NumPage(); i++ {
+		paragraphs = append(paragraphs, pageParagraphs(reader.Page(i))...)
+	}
+
+	var htmlContent strings.Builder
+	for _, paragraph := range paragraphs {
+		htmlContent.WriteString("<p>" + html.EscapeString(paragraph) + "</p>\n")
+	}
+
+	document, err := filesystem.NewFileFromBytes(body, documentFilename(pageURL, ".pdf"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document file: %w", err)
+	}
+
+	info := reader.Trailer().Key("Info")
+	content = &Content{
+		Title:    strings.TrimSpace(info.Key("Title").Text()),
+		Author:   strings.TrimSpace(info.Key("Author").Text()),
+		HTML:     htmlContent.String(),
+		Text:     strings.Join(paragraphs, "\n\n"),
+		Document: document,
+		Kind:     KindPDF,
+	}
+	if len(paragraphs) > 0 {
+		content.Excerpt = truncate(paragraphs[0], excerptLength)
+	}
+	if created, ok := parsePDFDate(info.Key("CreationDate").Text()); ok {
+		content.PublishedTime = &created
+	}
+	return content, nil
+}
+
+// pageParagraphs puts a page's text back together from its individual
+// characters. PDFs only record where each character goes, so spaces,
+// line breaks and paragraph breaks are guessed from the gaps between
+// them.
+func pageParagraphs(page pdf.Page) []string {
+	if page.V.IsNull() {
+		return nil
+	}
+
+	var paragraphs []string
+	var current strings.Builder
+	endParagraph := func() {
+		if paragraph := strings.Join(strings.Fields(current.String()), " "); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+		current.Reset()
+	}
+
+	var last *pdf.Text
+	for _, text := range page.Content().Text {
+		if last != nil {
+			size := last.FontSize
+			if size <= 0 {
+				size = 10
+			}
+			drop := last.Y - text.Y
+			switch {
+			case drop > size*1.8 || drop < -size:
+				// A blank line, or a jump back up to another column
+				endParagraph()
+			case math.Abs(drop) > size*0.5:
+				current.WriteByte(' ')
+			case text.X-(last.X+last.W) > size*0.2:
+				current.WriteByte(' ')
+			}
+		}
+		current.WriteString(text.S)
+		last = &text
+	}
+	endParagraph()
+
+	return paragraphs
+}
+
+// parsePDFDate parses a date in the PDF format, D:YYYYMMDDHHmmSS
+// followed by an optional time zone, any part of which after the year
+// may be left out.
+func parsePDFDate(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	digits := len(value) - len(strings.TrimLeft(value, "0123456789"))
+	if digits < 4 {
+		return time.Time{}, false
+	}
+	digits = min(digits, 14)
+
+	layout := "20060102150405"[:digits]
+	date, err := time.Parse(layout, value[:digits])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Time zones look like Z, +05'30' or -08'00
+	zone := strings.ReplaceAll(value[digits:], "'", "")
+	if len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		var hours, minutes int
+		fmt.Sscanf(zone[1:3], "%d", &hours)
+		if len(zone) >= 5 {
+			fmt.Sscanf(zone[3:5], "%d", &minutes)
+		}
+		offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+		if zone[0] == '+' {
+			offset = -offset
+		}
+		date = date.Add(offset)
+	}
+	return date.UTC(), true
+}
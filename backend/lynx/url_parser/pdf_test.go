@@ -0,0 +1,145 @@
+package url_parser
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestPDF writes a minimal PDF with the given document info and
+// pages. Each page is a list of paragraphs, and each paragraph a list
+// of lines.
+func buildTestPDF(info string, pages [][][]string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Pages, filled in below
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< " + info + " >>",
+	}
+
+	var kids []string
+	for _, page := range pages {
+		var stream strings.Builder
+		stream.WriteString("BT /F1 12 Tf 72 720 Td ")
+		for i, paragraph := range page {
+			if i > 0 {
+				stream.WriteString("0 -36 Td ")
+			}
+			for j, line := range paragraph {
+				if j > 0 {
+					stream.WriteString("0 -14 Td ")
+				}
+				fmt.Fprintf(&stream, "(%s) Tj ", line)
+			}
+		}
+		stream.WriteString("ET")
+
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()))
+		contentsID := len(objects)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentsID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestPDFExtractor(t *testing.T) {
+	body := buildTestPDF(
+		"/Title (A Paper About Things) /Author (Jane Doe) /CreationDate (D:20230102030405+01'00')",
+		[][][]string{
+			{
+				{"The first paragraph starts here", "and carries on over another line."},
+				{"The second paragraph."},
+			},
+			{
+				{"A paragraph on the second page."},
+			},
+		},
+	)
+	pageURL, err := url.Parse("https://example.com/papers/things.pdf")
+	require.NoError(t, err)
+
+	content, err := PDFExtractor{}.Extract(body, pageURL)
+	require.NoError(t, err)
+	assert.Equal(t, "A Paper About Things", content.Title)
+	assert.Equal(t, "Jane Doe", content.Author)
+	assert.Equal(t, "The first paragraph starts here and carries on over another line.", content.Excerpt)
+	assert.Equal(t,
+		"The first paragraph starts here and carries on over another line.\n\nThe second paragraph.\n\nA paragraph on the second page.",
+		content.Text,
+	)
+	assert.Equal(t,
+		"<p>The first paragraph starts here and carries on over another line.</p>\n<p>The second paragraph.</p>\n<p>A paragraph on the second page.</p>\n",
+		content.HTML,
+	)
+	require.NotNil(t, content.PublishedTime)
+	assert.Equal(t, time.Date(2023, 1, 2, 2, 4, 5, 0, time.UTC), *content.PublishedTime)
+	assert.Empty(t, content.FullPageHTML)
+	require.NotNil(t, content.Document)
+	assert.Equal(t, "things.pdf", content.Document.OriginalName)
+	assert.Equal(t, KindPDF, content.Kind)
+}
+
+func TestPDFExtractorWithoutMetadata(t *testing.T) {
+	body := buildTestPDF("", [][][]string{{{"Just <some> text."}}})
+	pageURL, err := url.Parse("https://example.com/download?id=1")
+	require.NoError(t, err)
+
+	content, err := PDFExtractor{}.Extract(body, pageURL)
+	require.NoError(t, err)
+	assert.Empty(t, content.Title)
+	assert.Equal(t, "document.pdf", content.Document.OriginalName)
+	assert.Empty(t, content.Author)
+	assert.Nil(t, content.PublishedTime)
+	assert.Equal(t, "<p>Just &lt;some&gt; text.</p>\n", content.HTML)
+}
+
+func TestPDFExtractorInvalid(t *testing.T) {
+	_, err := PDFExtractor{}.Extract([]byte("%PDF-1.4\nnot really a pdf"), nil)
+	assert.Error(t, err)
+}
+
+func TestParsePDFDate(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{value: "D:20230102030405Z", expected: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), ok: true},
+		{value: "D:20230102030405-08'00'", expected: time.Date(2023, 1, 2, 11, 4, 5, 0, time.UTC), ok: true},
+		{value: "D:202301", expected: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), ok: true},
+		{value: "20230102", expected: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), ok: true},
+		{value: "", ok: false},
+		{value: "yesterday", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			date, ok := parsePDFDate(tc.value)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.expected, date)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package url_parser
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Longest first line that's taken as the title of plain text
+const maxTextTitleLength = 200
+
+// TextExtractor extracts plain text, using the first line as the
+// title if it's short enough to be one.
+type TextExtractor struct{}
+
+func (TextExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	if !utf8.Valid(body) {
+		return nil, errors.New("text isn't valid UTF-8")
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(string(body), "\r\n", "\n"))
+
+	content := &Content{
+		HTML: textToHTML(text),
+		Text: text,
+	}
+	title, rest, _ := strings.Cut(text, "\n")
+	if title = strings.TrimSpace(title); utf8.RuneCountInString(title) <= maxTextTitleLength {
+		content.Title = title
+		content.Excerpt = truncate(firstParagraph(rest), excerptLength)
+	} else {
+		content.Excerpt = truncate(title, excerptLength)
+	}
+	return content, nil
+}
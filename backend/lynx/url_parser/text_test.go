@@ -0,0 +1,29 @@
+package url_parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextExtractor(t *testing.T) {
+	content, err := TextExtractor{}.Extract([]byte("Meeting Notes\r\n\r\nWe talked about <things>.\r\nThen lunch.\r\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Meeting Notes", content.Title)
+	assert.Equal(t, "We talked about <things>.", content.Excerpt)
+	assert.Equal(t, "Meeting Notes\n\nWe talked about <things>.\nThen lunch.", content.Text)
+	assert.Equal(t, "<p>Meeting Notes</p>\n<p>We talked about &lt;things&gt;.<br>Then lunch.</p>\n", content.HTML)
+	assert.Nil(t, content.Document)
+
+	// A first line that's too long to be a title is where the text starts
+	long := strings.Repeat("words ", 50)
+	content, err = TextExtractor{}.Extract([]byte(long), nil)
+	require.NoError(t, err)
+	assert.Empty(t, content.Title)
+	assert.Equal(t, truncate(strings.TrimSpace(long), excerptLength), content.Excerpt)
+
+	_, err = TextExtractor{}.Extract([]byte{0xff, 0xfe, 0x00}, nil)
+	assert.EqualError(t, err, "text isn't valid UTF-8")
+}
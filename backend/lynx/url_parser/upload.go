@@ -0,0 +1,144 @@
+package url_parser
+
+import (
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// Content types for uploaded files that are sent without a useful one,
+// by extension
+var uploadContentTypes = map[string]string{
+	".html":  "text/html",
+	".htm":   "text/html",
+	".xhtml": "text/html",
+	".mhtml": "multipart/related",
+	".mht":   "multipart/related",
+	".pdf":   "application/pdf",
+	".epub":  "application/epub+zip",
+	".txt":   "text/plain",
+	".md":    "text/markdown",
+}
+
+// Given an uploaded file, and optionally the URL it was saved from,
+// extract the content and create a new Link record in pocketbase. This
+// is for pages that can't be fetched, e.g. because they're behind a
+// login.
+func HandleParseUploadRequest(app core.App, e *core.RequestEvent) (*core.Record, error) {
+	authRecord := e.Auth
+	if authRecord == nil {
+		return nil, apis.NewForbiddenError("Not authenticated", nil)
+	}
+
+	file, header, err := e.Request.FormFile("file")
+	if err != nil {
+		return nil, apis.NewBadRequestError("Missing 'file' upload", err)
+	}
+	defer file.Close()
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return nil, apis.NewBadRequestError("Failed to read uploaded file", err)
+	}
+
+	var pageURL *url.URL
+	if urlParam := e.Request.FormValue("url"); urlParam != "" {
+		pageURL, err = url.Parse(urlParam)
+		if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Host == "" {
+			return nil, apis.NewBadRequestError("Invalid 'url' parameter, expected an http or https URL", err)
+		}
+	}
+
+	onDuplicate := e.Request.FormValue("on_duplicate")
+	switch onDuplicate {
+	case OnDuplicateConflict, OnDuplicateReturn, OnDuplicateUpdate, OnDuplicateCreate:
+	default:
+		return nil, apis.NewBadRequestError("Invalid 'on_duplicate' parameter, expected return, update or create", nil)
+	}
+
+	contentType := uploadContentType(header.Header.Get("Content-Type"), header.Filename)
+	return HandleParseContent(app, authRecord.Id, body, contentType, header.Filename, pageURL, onDuplicate)
+}
+
+// HandleParseContent saves content the user already has, rather than
+// content fetched from the web, as a link for the user. pageURL is
+// where the content came from, if known.
+func HandleParseContent(app core.App, userId string, body []byte, contentType string, filename string, pageURL *url.URL, onDuplicate string) (*core.Record, error) {
+	extractor := ExtractorForUpload(contentType, body)
+	content, err := extractor.Extract(body, pageURL)
+	if err != nil {
+		return nil, apis.NewBadRequestError("Failed to parse uploaded content", err)
+	}
+
+	// Documents are kept under the name they were uploaded with
+	if content.Document != nil && filename != "" {
+		content.Document, err = filesystem.NewFileFromBytes(body, path.Base(filename))
+		if err != nil {
+			return nil, apis.NewBadRequestError("Failed to create document file", err)
+		}
+	}
+	if content.Title == "" {
+		content.Title = titleFromName(path.Base(filename))
+	}
+
+	return SaveContent(app, userId, content, pageURL, onDuplicate)
+}
+
+// SaveContent saves already extracted content as a link for the user.
+// pageURL is used to check for duplicates like HandleParseURLViaParams
+// does. Without it, the URL the content gives for itself is used, if
+// there is one.
+func SaveContent(app core.App, userId string, content *Content, pageURL *url.URL, onDuplicate string) (*core.Record, error) {
+	// Like fetched pages, prefer the address the page gives for itself
+	originalURL := pageURL
+	if parsed, err := url.Parse(content.URL); originalURL == nil && err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" {
+		originalURL = parsed
+	}
+	finalURL := originalURL
+	base := originalURL
+	if base == nil {
+		base = &url.URL{}
+	}
+	if canonical := CanonicalURL([]byte(content.FullPageHTML), base); canonical != nil {
+		finalURL = canonical
+	}
+	if originalURL == nil {
+		originalURL = finalURL
+	}
+
+	var existing *core.Record
+	var cleanedURL *url.URL
+	if finalURL != nil {
+		var err error
+		cleanedURL = ApplyRewriteRules(CleanURL(finalURL), userRewriteRules(app, userId))
+		existing, err = checkDuplicate(app, userId, NormalizeURL(cleanedURL), nil, onDuplicate)
+		if err != nil || (existing != nil && onDuplicate == OnDuplicateReturn) {
+			return existing, err
+		}
+	}
+
+	if content.Title == "" && cleanedURL != nil {
+		content.Title = cleanedURL.Hostname()
+	}
+	if content.Title == "" {
+		content.Title = "Untitled"
+	}
+
+	return saveContent(app, userId, existing, originalURL, cleanedURL, content, nil)
+}
+
+// uploadContentType returns the content type of an uploaded file,
+// going by its extension if it was uploaded without a specific one.
+// An empty result leaves the content type to be sniffed.
+func uploadContentType(contentType string, filename string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && mediaType != "application/octet-stream" {
+		return contentType
+	}
+	return uploadContentTypes[strings.ToLower(path.Ext(filename))]
+}
@@ -1,21 +1,43 @@
 package url_parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+)
 
-	"github.com/go-shiori/go-readability"
+// What to do when a URL being saved is already in the user's library
+const (
+	// Fail with a DuplicateLinkError
+	OnDuplicateConflict = ""
+	// Return the existing link without fetching anything
+	OnDuplicateReturn = "return"
+	// Fetch the page again and replace the existing link's content,
+	// keeping its tags, highlights and reading progress
+	OnDuplicateUpdate = "update"
+	// Save another copy
+	OnDuplicateCreate = "create"
 )
 
+// DuplicateLinkError is returned when a URL is already saved and the
+// caller asked for OnDuplicateConflict.
+type DuplicateLinkError struct {
+	Link *core.Record
+}
+
+func (e *DuplicateLinkError) Error() string {
+	return fmt.Sprintf("link is already saved as %s", e.Link.Id)
+}
+
 // Given a URL, load the URL (using relevant cookies for the authenticated
 // user), extract the article content, and create a new Link record in pocketbase.
 func HandleParseURLRequest(app core.App, e *core.RequestEvent) (*core.Record, error) {
@@ -46,10 +68,28 @@ func HandleParseURLRequest(app core.App, e *core.RequestEvent) (*core.Record, er
 			return nil, apis.NewForbiddenError("Invalid feed item", nil)
 		}
 	}
-	return HandleParseURLViaParams(app, authRecord.Id, parsedURL, feedItem)
+
+	onDuplicate := e.Request.FormValue("on_duplicate")
+	switch onDuplicate {
+	case OnDuplicateConflict, OnDuplicateReturn, OnDuplicateUpdate, OnDuplicateCreate:
+	default:
+		return nil, apis.NewBadRequestError("Invalid 'on_duplicate' parameter, expected return, update or create", nil)
+	}
+
+	return HandleParseURLViaParams(app, authRecord.Id, parsedURL, feedItem, onDuplicate)
 }
 
-func HandleParseURLViaParams(app core.App, userId string, url *url.URL, feedItem *core.Record) (*core.Record, error) {
+// HandleParseURLViaParams fetches the URL and saves it as a link for
+// the user. If the URL is already in the user's library, either before
+// or after following redirects, onDuplicate decides what happens.
+func HandleParseURLViaParams(app core.App, userId string, url *url.URL, feedItem *core.Record, onDuplicate string) (*core.Record, error) {
+	rules := userRewriteRules(app, userId)
+	fetchURL := ApplyRewriteRules(CleanURL(url), rules)
+
+	existing, err := checkDuplicate(app, userId, NormalizeURL(fetchURL), feedItem, onDuplicate)
+	if err != nil || (existing != nil && onDuplicate == OnDuplicateReturn) {
+		return existing, err
+	}
 
 	// Load user cookies
 	cookieRecords, err := app.FindRecordsByFilter(
@@ -58,7 +98,7 @@ func HandleParseURLViaParams(app core.App, userId string, url *url.URL, feedItem
 		"-created",
 		10,
 		0,
-		dbx.Params{"user": userId, "url": url.Hostname()},
+		dbx.Params{"user": userId, "url": fetchURL.Hostname()},
 	)
 	if err != nil {
 		return nil, apis.NewBadRequestError("Failed to fetch cookies", err)
@@ -75,7 +115,7 @@ func HandleParseURLViaParams(app core.App, userId string, url *url.URL, feedItem
 
 	// Build request & add cookies
 	client := &http.Client{}
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequest("GET", fetchURL.String(), nil)
 	if err != nil {
 		return nil, apis.NewBadRequestError("Failed to create request", err)
 	}
@@ -109,61 +149,185 @@ func HandleParseURLViaParams(app core.App, userId string, url *url.URL, feedItem
 		return nil, apis.NewBadRequestError("Failed to read response body", err)
 	}
 
-	// Use go-readability to parse the webpage
-	bodyReader := strings.NewReader(string(bodyContent))
-	article, err := readability.FromReader(bodyReader, url)
+	// Pick an extractor based on what was returned and where from, e.g.
+	// a web page, a PDF or a YouTube video
+	extractor := ExtractorFor(resp.Request.URL, resp.Header.Get("Content-Type"), bodyContent)
+	content, err := extractor.Extract(bodyContent, resp.Request.URL)
 	if err != nil {
 		return nil, apis.NewBadRequestError("Failed to parse webpage content", err)
 	}
 
-	// Create a new record in the links collection
-	collection, err := app.FindCollectionByNameOrId("links")
+	// Prefer the address the page gives for itself over wherever the
+	// redirects ended up
+	finalURL := resp.Request.URL
+	if canonical := CanonicalURL([]byte(content.FullPageHTML), finalURL); canonical != nil {
+		finalURL = canonical
+	}
+	cleanedURL := ApplyRewriteRules(CleanURL(finalURL), rules)
+
+	// Redirects may lead to a page that's already saved
+	if existing == nil {
+		existing, err = checkDuplicate(app, userId, NormalizeURL(cleanedURL), feedItem, onDuplicate)
+		if err != nil || (existing != nil && onDuplicate == OnDuplicateReturn) {
+			return existing, err
+		}
+	}
+
+	if content.Title == "" {
+		content.Title = titleFromName(path.Base(cleanedURL.Path))
+	}
+	if content.Title == "" {
+		content.Title = cleanedURL.Hostname()
+	}
+
+	record, err := saveContent(app, userId, existing, url, cleanedURL, content, feedItem)
 	if err != nil {
-		return nil, apis.NewBadRequestError("Failed to find links collection", err)
+		return nil, err
 	}
 
-	record := core.NewRecord(collection)
-	record.Set("added_to_library", time.Now().Format(time.RFC3339))
-	record.Set("original_url", url.String())
-	record.Set("cleaned_url", resp.Request.URL.String())
-	record.Set("title", article.Title)
-	record.Set("hostname", resp.Request.URL.Hostname())
+	markFeedItemSaved(app, feedItem, record)
+
+	return record, nil
+}
+
+// saveContent creates a link for the user from the extracted content,
+// or refreshes the content of the existing link if there is one. The
+// URLs are nil for content that isn't from anywhere on the web.
+func saveContent(app core.App, userId string, existing *core.Record, originalURL *url.URL, cleanedURL *url.URL, content *Content, feedItem *core.Record) (*core.Record, error) {
+	record := existing
+	if record == nil {
+		collection, err := app.FindCollectionByNameOrId("links")
+		if err != nil {
+			return nil, apis.NewBadRequestError("Failed to find links collection", err)
+		}
+
+		record = core.NewRecord(collection)
+		record.Set("added_to_library", time.Now().Format(time.RFC3339))
+		if originalURL != nil {
+			record.Set("original_url", originalURL.String())
+		}
+		record.Set("reading_progress", 0)
+	}
+	if cleanedURL != nil {
+		record.Set("cleaned_url", cleanedURL.String())
+		record.Set("normalized_url", NormalizeURL(cleanedURL))
+		record.Set("hostname", cleanedURL.Hostname())
+	}
 	record.Set("user", userId)
-	record.Set("excerpt", article.Excerpt)
-	record.Set("author", article.Byline)
-	record.Set("article_html", article.Content)
-	record.Set("raw_text_content", article.TextContent)
-	record.Set("header_image_url", article.Image)
-	record.Set("full_page_html", string(bodyContent))
-	record.Set("reading_progress", 0)
-	if article.PublishedTime != nil {
-		record.Set("article_date", article.PublishedTime)
-	} else {
-		record.Set("article_date", time.Now().UTC().Format(time.RFC3339))
-	}
-	if feedItem != nil {
+	ApplyContent(record, content)
+	if feedItem != nil && record.IsNew() {
 		record.Set("created_from_feed", feedItem.GetString("feed"))
 	}
 
-	// Calculate read time, using 285 wpm as read rate
-	words := strings.Fields(article.TextContent)
-	wordCount := len(words)
-	minutes := float64(wordCount) / float64(285)
-	readTime := time.Duration(minutes * float64(time.Minute))
-	record.Set("read_time_seconds", int(math.Round(readTime.Seconds())))
-	record.Set("read_time_display", fmt.Sprintf("%d min", int(math.Round(readTime.Minutes()))))
-
 	if err := app.Save(record); err != nil {
 		return nil, apis.NewBadRequestError("Failed to save link", err)
 	}
+	return record, nil
+}
 
-	if feedItem != nil {
-		feedItem.Set("saved_as_link", record.Id)
-		if err := app.Save(feedItem); err != nil {
-			// Log the error but don't fail the request
-			app.Logger().Error("Failed to update feed item", "error", err, "feed_item", feedItem.Id, "link", record.Id)
-		}
+// titleFromName turns a file name into a title for content that
+// doesn't have one of its own, e.g. "annual-report.pdf" becomes
+// "annual-report".
+func titleFromName(name string) string {
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if name == "" || name == "." || name == "/" {
+		return ""
 	}
+	return name
+}
 
-	return record, nil
+// userRewriteRules loads the user's own URL rewrite rules. Rules are
+// checked when the settings are saved, so invalid rules are only
+// logged and ignored here.
+func userRewriteRules(app core.App, userId string) []RewriteRule {
+	userSettings, err := app.FindFirstRecordByFilter("user_settings", "user = {:user}", dbx.Params{"user": userId})
+	if err != nil {
+		return nil
+	}
+	rules, err := ParseRewriteRules(userSettings.GetString("url_rewrite_rules"))
+	if err != nil {
+		app.Logger().Warn("Ignoring invalid URL rewrite rules", "userID", userId, "error", err)
+		return nil
+	}
+	return rules
+}
+
+// checkDuplicate looks for a link the user already saved with the same
+// normalized URL. It returns an error if duplicates aren't allowed,
+// and otherwise returns the existing link, if any, unless another copy
+// should be created.
+func checkDuplicate(app core.App, userId string, normalizedURL string, feedItem *core.Record, onDuplicate string) (*core.Record, error) {
+	if onDuplicate == OnDuplicateCreate {
+		return nil, nil
+	}
+
+	existing, err := FindDuplicate(app, userId, normalizedURL, "")
+	if err != nil {
+		return nil, apis.NewBadRequestError("Failed to check for duplicate links", err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	switch onDuplicate {
+	case OnDuplicateConflict:
+		return nil, &DuplicateLinkError{Link: existing}
+	case OnDuplicateReturn:
+		markFeedItemSaved(app, feedItem, existing)
+	}
+	return existing, nil
+}
+
+// FindDuplicate returns the user's oldest link with the normalized
+// URL, other than excludeID, or nil if there isn't one.
+func FindDuplicate(app core.App, userId string, normalizedURL string, excludeID string) (*core.Record, error) {
+	if normalizedURL == "" {
+		return nil, nil
+	}
+	links, err := app.FindRecordsByFilter(
+		"links",
+		"user = {:user} && normalized_url = {:url} && id != {:exclude}",
+		"added_to_library",
+		1,
+		0,
+		dbx.Params{"user": userId, "url": normalizedURL, "exclude": excludeID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+	return links[0], nil
+}
+
+// SetNormalizedURL fills in normalized_url from the link's cleaned or
+// original URL.
+func SetNormalizedURL(link *core.Record) {
+	rawURL := link.GetString("cleaned_url")
+	if rawURL == "" {
+		rawURL = link.GetString("original_url")
+	}
+	link.Set("normalized_url", NormalizeURLString(rawURL))
+}
+
+func markFeedItemSaved(app core.App, feedItem *core.Record, link *core.Record) {
+	if feedItem == nil {
+		return
+	}
+	feedItem.Set("saved_as_link", link.Id)
+	if err := app.Save(feedItem); err != nil {
+		// Log the error but don't fail the request
+		app.Logger().Error("Failed to update feed item", "error", err, "feed_item", feedItem.Id, "link", link.Id)
+	}
+}
+
+// IsDuplicate reports whether err is a DuplicateLinkError, returning
+// the existing link.
+func IsDuplicate(err error) (*core.Record, bool) {
+	var duplicateErr *DuplicateLinkError
+	if errors.As(err, &duplicateErr) {
+		return duplicateErr.Link, true
+	}
+	return nil, false
 }
@@ -1,6 +1,7 @@
 package url_parser
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tests"
 	"github.com/pocketbase/pocketbase/tools/router"
@@ -181,6 +183,191 @@ func TestHandleParseURL(t *testing.T) {
 	}
 }
 
+func TestHandleParseURLDuplicates(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testApp.Cleanup()
+
+	user, err := createTestUser(testApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title := "First Title"
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/short" {
+			http.Redirect(w, r, "/post?utm_source=short", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>` + title + `</title></head><body><h1>` + title + `</h1><p>Content</p></body></html>`))
+	}))
+	defer server.Close()
+
+	parse := func(rawURL string, onDuplicate string) (*core.Record, error) {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return HandleParseURLViaParams(testApp, user.Id, parsedURL, nil, onDuplicate)
+	}
+
+	original, err := parse(server.URL+"/post/", OnDuplicateConflict)
+	assert.NoError(t, err)
+	assert.Equal(t, NormalizeURLString(server.URL+"/post"), original.GetString("normalized_url"))
+
+	// Tracking parameters and fragments don't make a different page
+	_, err = parse(server.URL+"/post?utm_source=rss#top", OnDuplicateConflict)
+	existing, ok := IsDuplicate(err)
+	assert.True(t, ok)
+	assert.Equal(t, original.Id, existing.Id)
+
+	// Redirects are followed before checking again
+	_, err = parse(server.URL+"/short", OnDuplicateConflict)
+	existing, ok = IsDuplicate(err)
+	assert.True(t, ok)
+	assert.Equal(t, original.Id, existing.Id)
+
+	requests = 0
+	record, err := parse(server.URL+"/post", OnDuplicateReturn)
+	assert.NoError(t, err)
+	assert.Equal(t, original.Id, record.Id)
+	assert.Equal(t, 0, requests)
+
+	title = "Second Title"
+	record, err = parse(server.URL+"/post", OnDuplicateUpdate)
+	assert.NoError(t, err)
+	assert.Equal(t, original.Id, record.Id)
+	assert.Equal(t, "Second Title", record.GetString("title"))
+	assert.Equal(t, original.GetString("original_url"), record.GetString("original_url"))
+
+	record, err = parse(server.URL+"/post", OnDuplicateCreate)
+	assert.NoError(t, err)
+	assert.NotEqual(t, original.Id, record.Id)
+
+	// Only the explicit copy was added
+	links, err := testApp.FindAllRecords("links", dbx.HashExp{"user": user.Id})
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+}
+
+func TestHandleParseURLPDF(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testApp.Cleanup()
+
+	user, err := createTestUser(testApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := buildTestPDF("/Title (Annual Report)", [][][]string{{{strings.Repeat("word ", 570)}}})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	parsedURL, err := url.Parse(server.URL + "/report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := HandleParseURLViaParams(testApp, user.Id, parsedURL, nil, OnDuplicateConflict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Annual Report", record.GetString("title"))
+	assert.Equal(t, KindPDF, record.GetString("kind"))
+	assert.Equal(t, strings.TrimSpace(strings.Repeat("word ", 570)), record.GetString("raw_text_content"))
+	assert.Equal(t, 120, record.GetInt("read_time_seconds"))
+	assert.Equal(t, "2 min", record.GetString("read_time_display"))
+	assert.Empty(t, record.GetString("full_page_html"))
+	assert.True(t, strings.HasPrefix(record.GetString("document"), "report_"), record.GetString("document"))
+
+	// The PDF is stored with the link
+	fs, err := testApp.NewFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	file, err := fs.GetReader(record.BaseFilesPath() + "/" + record.GetString("document"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	stored, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, body, stored)
+}
+
+func TestHandleParseContent(t *testing.T) {
+	testApp, err := tests.NewTestApp("../../test_pb_data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testApp.Cleanup()
+
+	user, err := createTestUser(testApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The page's own canonical URL is used over the one it was saved at
+	pageURL, err := url.Parse("https://example.com/members/post?utm_source=email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := []byte(testMHTMLArticle)
+	record, err := HandleParseContent(testApp, user.Id, html, "text/html", "post.html", pageURL, OnDuplicateConflict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Members Only", record.GetString("title"))
+	assert.Equal(t, "https://example.com/members/post?utm_source=email", record.GetString("original_url"))
+	assert.Equal(t, "https://example.com/members/post", record.GetString("cleaned_url"))
+	assert.Equal(t, "example.com", record.GetString("hostname"))
+	assert.Equal(t, testMHTMLArticle, record.GetString("full_page_html"))
+	assert.Contains(t, record.GetString("raw_text_content"), "A post only members can read")
+	assert.Empty(t, record.GetString("document"))
+
+	// Saved pages are checked for duplicates by the URL they record
+	_, err = HandleParseContent(testApp, user.Id, buildTestMHTML("https://example.com/members/post", "base64"), "", "post.mhtml", nil, OnDuplicateConflict)
+	existing, ok := IsDuplicate(err)
+	assert.True(t, ok)
+	assert.Equal(t, record.Id, existing.Id)
+
+	// Content from nowhere on the web has no URL, and is titled after
+	// its file
+	record, err = HandleParseContent(testApp, user.Id, buildTestPDF("", [][][]string{{{"Scanned letter."}}}), uploadContentType("application/octet-stream", "Letter.PDF"), "Letter.PDF", nil, OnDuplicateConflict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Letter", record.GetString("title"))
+	assert.Empty(t, record.GetString("original_url"))
+	assert.Empty(t, record.GetString("cleaned_url"))
+	assert.Empty(t, record.GetString("normalized_url"))
+	assert.True(t, strings.HasPrefix(record.GetString("document"), "letter_"), record.GetString("document"))
+
+	_, err = HandleParseContent(testApp, user.Id, []byte("%PDF-1.4\nbroken"), "application/pdf", "broken.pdf", nil, OnDuplicateConflict)
+	assert.ErrorContains(t, err, "Failed to parse uploaded content")
+}
+
+func TestUploadContentType(t *testing.T) {
+	assert.Equal(t, "text/html; charset=utf-8", uploadContentType("text/html; charset=utf-8", "page.txt"))
+	assert.Equal(t, "multipart/related", uploadContentType("application/octet-stream", "page.MHT"))
+	assert.Equal(t, "application/epub+zip", uploadContentType("", "book.epub"))
+	assert.Equal(t, "", uploadContentType("", "unknown.bin"))
+}
+
 func createTestUser(app *tests.TestApp) (*core.Record, error) {
 	collection, err := app.FindCollectionByNameOrId("users")
 	if err != nil {
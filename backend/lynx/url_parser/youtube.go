@@ -0,0 +1,165 @@
+package url_parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YouTubeExtractor extracts a video's details from its watch page,
+// along with the transcript when the video has captions.
+type YouTubeExtractor struct{}
+
+// The parts of the player response embedded in watch pages that are
+// used here
+type youtubePlayerResponse struct {
+	VideoDetails struct {
+		VideoID          string `json:"videoId"`
+		Title            string `json:"title"`
+		Author           string `json:"author"`
+		ShortDescription string `json:"shortDescription"`
+		LengthSeconds    string `json:"lengthSeconds"`
+		Thumbnail        struct {
+			Thumbnails []struct {
+				URL string `json:"url"`
+			} `json:"thumbnails"`
+		} `json:"thumbnail"`
+	} `json:"videoDetails"`
+	Microformat struct {
+		PlayerMicroformatRenderer struct {
+			PublishDate string `json:"publishDate"`
+		} `json:"playerMicroformatRenderer"`
+	} `json:"microformat"`
+	Captions struct {
+		PlayerCaptionsTracklistRenderer struct {
+			CaptionTracks []youtubeCaptionTrack `json:"captionTracks"`
+		} `json:"playerCaptionsTracklistRenderer"`
+	} `json:"captions"`
+}
+
+type youtubeCaptionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	// "asr" for captions generated by speech recognition
+	Kind string `json:"kind"`
+}
+
+func (YouTubeExtractor) Extract(body []byte, pageURL *url.URL) (*Content, error) {
+	player, err := youtubePlayer(body)
+	if err != nil {
+		return nil, err
+	}
+	video := player.VideoDetails
+	if video.VideoID == "" || video.Title == "" {
+		return nil, errors.New("page has no video details")
+	}
+
+	content := &Content{
+		Title:        video.Title,
+		Author:       video.Author,
+		Excerpt:      truncate(firstParagraph(video.ShortDescription), excerptLength),
+		FullPageHTML: string(body),
+		Kind:         KindVideo,
+	}
+	if thumbnails := video.Thumbnail.Thumbnails; len(thumbnails) > 0 {
+		// Largest last
+		content.ImageURL = thumbnails[len(thumbnails)-1].URL
+	}
+	if seconds, err := strconv.Atoi(video.LengthSeconds); err == nil {
+		content.Duration = time.Duration(seconds) * time.Second
+	}
+	if published, err := time.Parse(time.RFC3339, player.Microformat.PlayerMicroformatRenderer.PublishDate); err == nil {
+		content.PublishedTime = &published
+	} else if published, err := time.Parse(time.DateOnly, player.Microformat.PlayerMicroformatRenderer.PublishDate); err == nil {
+		content.PublishedTime = &published
+	}
+
+	var htmlContent strings.Builder
+	htmlContent.WriteString(textToHTML(video.ShortDescription))
+	text := video.ShortDescription
+
+	// The transcript is a nice to have, so the video is still saved
+	// without it
+	if transcript := youtubeTranscript(player.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks); transcript != "" {
+		htmlContent.WriteString("<h2>Transcript</h2>\n" + textToHTML(transcript))
+		text += "\n\n" + transcript
+	}
+
+	content.HTML = htmlContent.String()
+	content.Text = strings.TrimSpace(text)
+	return content, nil
+}
+
+// youtubePlayer finds the player response that watch pages assign to
+// ytInitialPlayerResponse in a script.
+func youtubePlayer(body []byte) (*youtubePlayerResponse, error) {
+	_, after, found := bytes.Cut(body, []byte("ytInitialPlayerResponse"))
+	if !found {
+		return nil, errors.New("page has no player response")
+	}
+	start := bytes.IndexByte(after, '{')
+	if start < 0 {
+		return nil, errors.New("page has no player response")
+	}
+
+	// The decoder stops at the end of the object, ignoring the rest of
+	// the script
+	var player youtubePlayerResponse
+	if err := json.NewDecoder(bytes.NewReader(after[start:])).Decode(&player); err != nil {
+		return nil, fmt.Errorf("failed to decode player response: %w", err)
+	}
+	return &player, nil
+}
+
+// youtubeTranscript loads the captions for the video, preferring ones
+// written by a person over generated ones, and English over other
+// languages. Returns an empty string if there aren't any or they can't
+// be loaded.
+func youtubeTranscript(tracks []youtubeCaptionTrack) string {
+	var best *youtubeCaptionTrack
+	bestScore := -1
+	for i, track := range tracks {
+		score := 0
+		if track.Kind != "asr" {
+			score += 2
+		}
+		if strings.HasPrefix(track.LanguageCode, "en") {
+			score++
+		}
+		if track.BaseURL != "" && score > bestScore {
+			best, bestScore = &tracks[i], score
+		}
+	}
+	if best == nil {
+		return ""
+	}
+
+	body, err := fetch(best.BaseURL, nil)
+	if err != nil {
+		return ""
+	}
+	var transcript struct {
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal(body, &transcript); err != nil {
+		return ""
+	}
+
+	// Captions are short fragments of sentences, so they're joined
+	// into one block of text
+	var lines []string
+	for _, line := range transcript.Texts {
+		// Captions are escaped once more than the XML needs
+		if line = strings.Join(strings.Fields(html.UnescapeString(line)), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
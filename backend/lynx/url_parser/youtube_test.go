@@ -0,0 +1,92 @@
+package url_parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func youtubeWatchPage(playerResponse string) []byte {
+	return []byte(`<html><head><title>Video - YouTube</title></head><body>
+<script>var ytInitialPlayerResponse = ` + playerResponse + `;var meta = document.createElement('meta');</script>
+</body></html>`)
+}
+
+func TestYouTubeExtractor(t *testing.T) {
+	requestedTrack := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedTrack = r.URL.Query().Get("lang")
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8" ?><transcript><text start="0" dur="2">Hello and</text><text start="2" dur="2">welcome, it&amp;#39;s  a video</text></transcript>`))
+	}))
+	defer server.Close()
+
+	body := youtubeWatchPage(`{
+		"videoDetails": {
+			"videoId": "abc123",
+			"title": "How to Make Bread",
+			"author": "Baking Channel",
+			"shortDescription": "Making bread at home.\n\nIngredients: flour, water {and} salt",
+			"lengthSeconds": "754",
+			"thumbnail": {"thumbnails": [{"url": "https://i.ytimg.com/small.jpg"}, {"url": "https://i.ytimg.com/large.jpg"}]}
+		},
+		"microformat": {"playerMicroformatRenderer": {"publishDate": "2024-03-05T08:00:00-08:00"}},
+		"captions": {"playerCaptionsTracklistRenderer": {"captionTracks": [
+			{"baseUrl": "` + server.URL + `/timedtext?lang=en-auto", "languageCode": "en", "kind": "asr"},
+			{"baseUrl": "` + server.URL + `/timedtext?lang=fr", "languageCode": "fr"},
+			{"baseUrl": "` + server.URL + `/timedtext?lang=en", "languageCode": "en"}
+		]}}
+	}`)
+
+	content, err := YouTubeExtractor{}.Extract(body, mustParseURL(t, "https://www.youtube.com/watch?v=abc123"))
+	require.NoError(t, err)
+	assert.Equal(t, "en", requestedTrack)
+	assert.Equal(t, "How to Make Bread", content.Title)
+	assert.Equal(t, "Baking Channel", content.Author)
+	assert.Equal(t, "Making bread at home.", content.Excerpt)
+	assert.Equal(t, "https://i.ytimg.com/large.jpg", content.ImageURL)
+	assert.Equal(t, 754*time.Second, content.Duration)
+	assert.Equal(t, KindVideo, content.Kind)
+	require.NotNil(t, content.PublishedTime)
+	assert.True(t, time.Date(2024, 3, 5, 16, 0, 0, 0, time.UTC).Equal(*content.PublishedTime))
+	assert.Equal(t,
+		"Making bread at home.\n\nIngredients: flour, water {and} salt\n\nHello and welcome, it's a video",
+		content.Text,
+	)
+	assert.Equal(t,
+		"<p>Making bread at home.</p>\n<p>Ingredients: flour, water {and} salt</p>\n<h2>Transcript</h2>\n<p>Hello and welcome, it&#39;s a video</p>\n",
+		content.HTML,
+	)
+	assert.Equal(t, string(body), content.FullPageHTML)
+}
+
+func TestYouTubeExtractorWithoutTranscript(t *testing.T) {
+	body := youtubeWatchPage(`{"videoDetails": {"videoId": "abc123", "title": "Short", "author": "Someone", "shortDescription": "", "lengthSeconds": "30"}}`)
+
+	content, err := YouTubeExtractor{}.Extract(body, mustParseURL(t, "https://www.youtube.com/watch?v=abc123"))
+	require.NoError(t, err)
+	assert.Equal(t, "Short", content.Title)
+	assert.Empty(t, content.Text)
+	assert.Equal(t, 30*time.Second, content.Duration)
+}
+
+func TestYouTubeExtractorNotAVideo(t *testing.T) {
+	pageURL := mustParseURL(t, "https://www.youtube.com/@channel")
+
+	_, err := YouTubeExtractor{}.Extract([]byte("<html><body>A channel</body></html>"), pageURL)
+	assert.EqualError(t, err, "page has no player response")
+
+	_, err = YouTubeExtractor{}.Extract(youtubeWatchPage(`{"playabilityStatus": {"status": "ERROR"}}`), pageURL)
+	assert.EqualError(t, err, "page has no video details")
+
+	// Falls back to readability
+	extractor := ExtractorFor(pageURL, "text/html", nil)
+	content, err := extractor.Extract([]byte("<html><head><title>A Channel</title></head><body><p>"+strings.Repeat("About the channel. ", 20)+"</p></body></html>"), pageURL)
+	require.NoError(t, err)
+	assert.Equal(t, "A Channel", content.Title)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"main/lynx"
+	"main/lynx/backfill"
 
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/plugins/migratecmd"
@@ -23,6 +24,10 @@ func main() {
 		Automigrate: isGoRun,
 	})
 
+	// Enable the backfill command for running enrichment over
+	// links saved before it was configured
+	backfill.MustRegister(app, app.RootCmd)
+
 	lynx.InitializePocketbase(app)
 
 	if err := app.Start(); err != nil {
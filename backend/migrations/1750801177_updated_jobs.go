@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2828234181")
+		if err != nil {
+			return err
+		}
+
+		// update collection data
+		collection.Indexes = []string{
+			"CREATE INDEX `idx_Jb7wQk2` ON `jobs` (\n  `status`,\n  `next_attempt_at`,\n  `created`\n)",
+			"CREATE INDEX `idx_tR4mXz9` ON `jobs` (\n  `link`,\n  `type`\n)",
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(6, []byte(`{
+			"hidden": false,
+			"id": "number3217549156",
+			"max": null,
+			"min": 0,
+			"name": "attempts",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(7, []byte(`{
+			"hidden": false,
+			"id": "date3681079236",
+			"max": "",
+			"min": "",
+			"name": "next_attempt_at",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "date"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2828234181")
+		if err != nil {
+			return err
+		}
+
+		// update collection data
+		collection.Indexes = []string{
+			"CREATE INDEX `idx_Jb7wQk2` ON `jobs` (\n  `status`,\n  `created`\n)",
+			"CREATE INDEX `idx_tR4mXz9` ON `jobs` (\n  `link`,\n  `type`\n)",
+		}
+
+		// remove field
+		collection.Fields.RemoveById("number3217549156")
+
+		// remove field
+		collection.Fields.RemoveById("date3681079236")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,153 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(25, []byte(`{
+			"hidden": false,
+			"id": "select2179188048",
+			"maxSelect": 1,
+			"name": "summary_status",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "select",
+			"values": [
+				"succeeded",
+				"skipped",
+				"failed"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(26, []byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text2973799279",
+			"max": 0,
+			"min": 0,
+			"name": "summary_error",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(27, []byte(`{
+			"hidden": false,
+			"id": "select2248503561",
+			"maxSelect": 1,
+			"name": "archive_status",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "select",
+			"values": [
+				"succeeded",
+				"skipped",
+				"failed"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(28, []byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text976278379",
+			"max": 0,
+			"min": 0,
+			"name": "archive_error",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(29, []byte(`{
+			"hidden": false,
+			"id": "select517724204",
+			"maxSelect": 1,
+			"name": "tags_status",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "select",
+			"values": [
+				"succeeded",
+				"skipped",
+				"failed"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(30, []byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text918112809",
+			"max": 0,
+			"min": 0,
+			"name": "tags_error",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// remove field
+		collection.Fields.RemoveById("select2179188048")
+
+		// remove field
+		collection.Fields.RemoveById("text2973799279")
+
+		// remove field
+		collection.Fields.RemoveById("select2248503561")
+
+		// remove field
+		collection.Fields.RemoveById("text976278379")
+
+		// remove field
+		collection.Fields.RemoveById("select517724204")
+
+		// remove field
+		collection.Fields.RemoveById("text918112809")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,156 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "_pb_users_auth_",
+					"hidden": false,
+					"id": "relation2375276105",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "user",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "0mucz6opmdvkaqc",
+					"hidden": false,
+					"id": "relation917281265",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "link",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "uxyi2qblr5y376o",
+					"hidden": false,
+					"id": "relation3382237236",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "highlight",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text3616895705",
+					"max": 512,
+					"min": 0,
+					"name": "model",
+					"pattern": "",
+					"presentable": false,
+					"primaryKey": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text484085629",
+					"max": 64,
+					"min": 0,
+					"name": "content_hash",
+					"pattern": "",
+					"presentable": false,
+					"primaryKey": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": true,
+					"id": "json460212315",
+					"maxSize": 0,
+					"name": "vector",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "json"
+				},
+				{
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": false,
+					"type": "autodate"
+				},
+				{
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": false,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_707271345",
+			"indexes": [
+				"CREATE INDEX ` + "`" + `idx_Vr5nEm2` + "`" + ` ON ` + "`" + `embeddings` + "`" + ` (\n  ` + "`" + `user` + "`" + `,\n  ` + "`" + `model` + "`" + `\n)",
+				"CREATE INDEX ` + "`" + `idx_Kp8sEm4` + "`" + ` ON ` + "`" + `embeddings` + "`" + ` (` + "`" + `link` + "`" + `)",
+				"CREATE INDEX ` + "`" + `idx_Hw3tEm7` + "`" + ` ON ` + "`" + `embeddings` + "`" + ` (` + "`" + `highlight` + "`" + `)"
+			],
+			"listRule": "user = @request.auth.id",
+			"name": "embeddings",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": "user = @request.auth.id"
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), &collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_707271345")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// search_index is an FTS5 table covering links and highlights. It's
+// kept up to date by record hooks (see lynx/fulltext) rather than
+// being a PocketBase collection, since collections can't be virtual
+// tables.
+func init() {
+	m.Register(func(app core.App) error {
+		_, err := app.DB().NewQuery(`
+			CREATE VIRTUAL TABLE search_index USING fts5(
+				record_id UNINDEXED,
+				link_id UNINDEXED,
+				user UNINDEXED,
+				type UNINDEXED,
+				title,
+				author,
+				excerpt,
+				raw_text,
+				summary,
+				highlighted_text,
+				tokenize = 'unicode61 remove_diacritics 2'
+			)
+		`).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = app.DB().NewQuery(`
+			INSERT INTO search_index (record_id, link_id, user, type, title, author, excerpt, raw_text, summary, highlighted_text)
+			SELECT id, id, user, 'link', title, author, excerpt, raw_text_content, summary, ''
+			FROM links
+		`).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = app.DB().NewQuery(`
+			INSERT INTO search_index (record_id, link_id, user, type, title, author, excerpt, raw_text, summary, highlighted_text)
+			SELECT highlights.id, highlights.link, highlights.user, 'highlight',
+				COALESCE(NULLIF(links.title, ''), highlights.link_backup_title), '', '', '', '', highlights.highlighted_text
+			FROM highlights
+			LEFT JOIN links ON links.id = highlights.link
+		`).Execute()
+		return err
+	}, func(app core.App) error {
+		_, err := app.DB().NewQuery("DROP TABLE IF EXISTS search_index").Execute()
+		return err
+	})
+}
@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(6, []byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text1129549174",
+			"max": 0,
+			"min": 0,
+			"name": "normalized_url",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		collection.AddIndex("idx_Nu4rLq7", false, "`user`, `normalized_url`", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Backfill existing links so they're found as duplicates. This
+		// skips the record hooks since nothing else about the links
+		// has changed.
+		links, err := app.FindAllRecords(collection)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			rawURL := link.GetString("cleaned_url")
+			if rawURL == "" {
+				rawURL = link.GetString("original_url")
+			}
+			_, err := app.DB().Update(
+				"links",
+				dbx.Params{"normalized_url": normalizeURL1753272903(rawURL)},
+				dbx.HashExp{"id": link.Id},
+			).Execute()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// remove field
+		collection.Fields.RemoveById("text1129549174")
+		collection.RemoveIndex("idx_Nu4rLq7")
+
+		return app.Save(collection)
+	})
+}
+
+// normalizeURL1753272903 is a frozen copy of how links were normalized
+// when this migration was written, so that the migration keeps giving
+// the same result however the app's normalization changes later.
+func normalizeURL1753272903(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	query := u.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		switch lower {
+		case "fbclid", "gclid", "dclid", "msclkid", "igshid", "mc_cid", "mc_eid",
+			"ref", "ref_src", "_hsenc", "_hsmi", "yclid":
+			query.Del(name)
+			continue
+		}
+		for _, prefix := range []string{"utm_", "pk_", "mtm_"} {
+			if strings.HasPrefix(lower, prefix) {
+				query.Del(name)
+				break
+			}
+		}
+	}
+
+	normalized := host + path
+	if len(query) > 0 {
+		normalized += "?" + query.Encode()
+	}
+	return normalized
+}
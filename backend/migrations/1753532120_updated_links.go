@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(4, []byte(`{
+			"exceptDomains": null,
+			"hidden": false,
+			"id": "oij8mjq5",
+			"name": "original_url",
+			"onlyDomains": null,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "url"
+		}`)); err != nil {
+			return err
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(5, []byte(`{
+			"exceptDomains": null,
+			"hidden": false,
+			"id": "lowrasxw",
+			"name": "cleaned_url",
+			"onlyDomains": null,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "url"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(4, []byte(`{
+			"exceptDomains": null,
+			"hidden": false,
+			"id": "oij8mjq5",
+			"name": "original_url",
+			"onlyDomains": null,
+			"presentable": false,
+			"required": true,
+			"system": false,
+			"type": "url"
+		}`)); err != nil {
+			return err
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(5, []byte(`{
+			"exceptDomains": null,
+			"hidden": false,
+			"id": "lowrasxw",
+			"name": "cleaned_url",
+			"onlyDomains": null,
+			"presentable": false,
+			"required": true,
+			"system": false,
+			"type": "url"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	})
+}
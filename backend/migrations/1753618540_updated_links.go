@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(29, []byte(`{
+			"hidden": false,
+			"id": "select1002749145",
+			"maxSelect": 1,
+			"name": "kind",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "select",
+			"values": [
+				"article",
+				"note",
+				"pdf",
+				"video"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Existing links were all saved from the web, so the kind can be
+		// worked out from the document and hostname
+		_, err = app.DB().NewQuery(`
+			UPDATE links SET kind = CASE
+				WHEN document LIKE '%.pdf' THEN 'pdf'
+				WHEN hostname IN ('youtube.com', 'www.youtube.com', 'm.youtube.com', 'youtu.be') THEN 'video'
+				ELSE 'article'
+			END
+		`).Execute()
+		return err
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("0mucz6opmdvkaqc")
+		if err != nil {
+			return err
+		}
+
+		// remove field
+		collection.Fields.RemoveById("select1002749145")
+
+		return app.Save(collection)
+	})
+}
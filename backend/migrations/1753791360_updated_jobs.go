@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2828234181")
+		if err != nil {
+			return err
+		}
+
+		// update collection data
+		collection.Indexes = []string{
+			"CREATE INDEX `idx_Jb7wQk2` ON `jobs` (\n  `status`,\n  `next_attempt_at`,\n  `created`\n)",
+			"CREATE INDEX `idx_tR4mXz9` ON `jobs` (\n  `link`,\n  `type`\n)",
+			"CREATE INDEX `idx_Hq3vLp8` ON `jobs` (\n  `highlight`,\n  `type`\n)",
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(2, []byte(`{
+			"cascadeDelete": true,
+			"collectionId": "0mucz6opmdvkaqc",
+			"hidden": false,
+			"id": "relation917281265",
+			"maxSelect": 1,
+			"minSelect": 0,
+			"name": "link",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "relation"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(3, []byte(`{
+			"cascadeDelete": true,
+			"collectionId": "uxyi2qblr5y376o",
+			"hidden": false,
+			"id": "relation3382237236",
+			"maxSelect": 1,
+			"minSelect": 0,
+			"name": "highlight",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "relation"
+		}`)); err != nil {
+			return err
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(4, []byte(`{
+			"hidden": false,
+			"id": "select2363381545",
+			"maxSelect": 1,
+			"name": "type",
+			"presentable": false,
+			"required": true,
+			"system": false,
+			"type": "select",
+			"values": [
+				"summarize",
+				"archive",
+				"suggest_tags",
+				"embed",
+				"embed_highlight"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2828234181")
+		if err != nil {
+			return err
+		}
+
+		// highlight jobs have no link, so they can't survive the link
+		// field becoming required again
+		if _, err := app.DB().NewQuery("DELETE FROM jobs WHERE type = 'embed_highlight'").Execute(); err != nil {
+			return err
+		}
+
+		// update collection data
+		collection.Indexes = []string{
+			"CREATE INDEX `idx_Jb7wQk2` ON `jobs` (\n  `status`,\n  `next_attempt_at`,\n  `created`\n)",
+			"CREATE INDEX `idx_tR4mXz9` ON `jobs` (\n  `link`,\n  `type`\n)",
+		}
+
+		// remove field
+		collection.Fields.RemoveById("relation3382237236")
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(2, []byte(`{
+			"cascadeDelete": true,
+			"collectionId": "0mucz6opmdvkaqc",
+			"hidden": false,
+			"id": "relation917281265",
+			"maxSelect": 1,
+			"minSelect": 0,
+			"name": "link",
+			"presentable": false,
+			"required": true,
+			"system": false,
+			"type": "relation"
+		}`)); err != nil {
+			return err
+		}
+
+		// update field
+		if err := collection.Fields.AddMarshaledJSONAt(3, []byte(`{
+			"hidden": false,
+			"id": "select2363381545",
+			"maxSelect": 1,
+			"name": "type",
+			"presentable": false,
+			"required": true,
+			"system": false,
+			"type": "select",
+			"values": [
+				"summarize",
+				"archive",
+				"suggest_tags",
+				"embed"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Links are now normalized after the built-in URL cleanup rules (AMP and
+// mobile variants, fragments), so recompute normalized_url for links
+// saved before that, or their duplicates won't be found.
+func init() {
+	m.Register(func(app core.App) error {
+		return renormalizeLinks(app, normalizeURL1753877760)
+	}, func(app core.App) error {
+		return renormalizeLinks(app, normalizeURL1753272903)
+	})
+}
+
+// renormalizeLinks sets normalized_url for every link. This skips the
+// record hooks since nothing else about the links has changed.
+func renormalizeLinks(app core.App, normalize func(rawURL string) string) error {
+	links, err := app.FindAllRecords("links")
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		rawURL := link.GetString("cleaned_url")
+		if rawURL == "" {
+			rawURL = link.GetString("original_url")
+		}
+		if rawURL == "" {
+			continue
+		}
+		normalized := normalize(rawURL)
+		if normalized == link.GetString("normalized_url") {
+			continue
+		}
+		_, err := app.DB().Update(
+			"links",
+			dbx.Params{"normalized_url": normalized},
+			dbx.HashExp{"id": link.Id},
+		).Execute()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeURL1753877760 is a frozen copy of how links were normalized
+// when this migration was written, so that the migration keeps giving
+// the same result however the app's normalization changes later.
+func normalizeURL1753877760(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	// Unwrap pages served from Google's AMP viewer or the AMP cache
+	cleaned := *u
+	fromAMPCache := false
+	var rest string
+	switch host := strings.ToLower(u.Hostname()); {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		_, rest, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	case strings.HasPrefix(host, "google.") || strings.HasPrefix(host, "www.google."):
+		rest, _ = strings.CutPrefix(u.Path, "/amp/")
+	}
+	if rest != "" {
+		scheme := "http"
+		if after, ok := strings.CutPrefix(rest, "s/"); ok {
+			scheme, rest = "https", after
+		}
+		if original, err := url.Parse(scheme + "://" + rest); err == nil && original.Host != "" {
+			original.RawQuery = u.RawQuery
+			cleaned = *original
+			fromAMPCache = true
+		}
+	}
+
+	// Drop AMP and mobile host labels, as long as that leaves at least
+	// a domain and top level domain
+	host := strings.ToLower(cleaned.Hostname())
+	if net.ParseIP(host) == nil {
+		labels := strings.Split(host, ".")
+		kept := make([]string, 0, len(labels))
+		for i, label := range labels {
+			if i < len(labels)-2 && slices.Contains([]string{"amp", "m", "mobile"}, label) {
+				continue
+			}
+			kept = append(kept, label)
+		}
+		host = strings.Join(kept, ".")
+	}
+	host = strings.TrimPrefix(host, "www.")
+	if port := cleaned.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	// Plenty of regular pages end in /amp, so AMP paths are only
+	// shortened for pages from an AMP cache
+	path := cleaned.EscapedPath()
+	if fromAMPCache {
+		for _, suffix := range []string{"/amp/", "/amp", ".amp"} {
+			if trimmed, ok := strings.CutSuffix(cleaned.Path, suffix); ok {
+				path = (&url.URL{Path: trimmed}).EscapedPath()
+				break
+			}
+		}
+	}
+
+	query := cleaned.Query()
+	for name, values := range query {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "amp",
+			lower == "outputtype" && strings.EqualFold(values[0], "amp"),
+			slices.Contains([]string{"fbclid", "gclid", "dclid", "msclkid", "igshid", "mc_cid", "mc_eid",
+				"ref", "ref_src", "_hsenc", "_hsmi", "yclid"}, lower),
+			strings.HasPrefix(lower, "utm_"),
+			strings.HasPrefix(lower, "pk_"),
+			strings.HasPrefix(lower, "mtm_"):
+			query.Del(name)
+		}
+	}
+
+	normalized := host + strings.TrimRight(path, "/")
+	if len(query) > 0 {
+		normalized += "?" + query.Encode()
+	}
+	return normalized
+}
@@ -25,6 +25,7 @@ import {
   IconCircleCheck,
   IconDotsVertical,
   IconFileDownload,
+  IconFileTypePdf,
   IconFileTextAi,
   IconPencil,
   IconStar,
@@ -83,7 +84,7 @@ const MetadataRow = ({
           {link.feed.name}
         </Link>
       ) : (
-        link.hostname
+        link.hostname || (link.kind === "note" ? "Note" : null)
       )}
     </span>,
     link.read_time_display,
@@ -329,6 +330,23 @@ const LinkCard = ({
                         Create Archive
                       </Menu.Item>
                     )}
+                    {link.document && (
+                      <Menu.Item
+                        leftSection={
+                          <IconFileTypePdf
+                            className={dropdownClasses.dropdownIcon}
+                          />
+                        }
+                        component="a"
+                        href={pb.files.getUrl(
+                          { id: link.id, collectionName: "links" },
+                          link.document,
+                        )}
+                        target="_blank"
+                      >
+                        View Original Document
+                      </Menu.Item>
+                    )}
                     <Menu.Label>Danger Zone</Menu.Label>
                     <Menu.Item
                       onClick={() => deleteMutator.mutate()}
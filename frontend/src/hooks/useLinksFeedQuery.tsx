@@ -1,5 +1,6 @@
 import { usePocketBase } from "@/hooks/usePocketBase";
 import type FeedLink from "@/types/FeedLink";
+import type { LinkKind } from "@/types/FeedLink";
 import { GenericLynxMutator } from "@/types/Mutations";
 import type Tag from "@/types/Tag";
 import {
@@ -55,6 +56,8 @@ export type FeedQueryItem = {
   title: string | null;
   user: string;
   archive: string | null;
+  document: string | null;
+  kind: LinkKind | null;
   reading_progress: number | null;
   starred_at: string | null;
 };
@@ -77,6 +80,8 @@ const getFields = () =>
     "tags",
     "suggested_tags",
     "archive",
+    "document",
+    "kind",
     "user",
     "expand.tags.*",
     "expand.created_from_feed.id",
@@ -125,6 +130,8 @@ export const convertFeedQueryItemToFeedLink = (
           }
         : undefined,
     archive: item.archive,
+    document: item.document || null,
+    kind: item.kind || null,
     reading_progress: item.reading_progress,
     starred_at: item.starred_at ? new Date(item.starred_at) : null,
   };
@@ -18,6 +18,7 @@ export default {
   COOKIES: "/settings/cookies",
   IMPORT: "/settings/import",
   API_KEYS: "/settings/api_keys",
+  EMAIL_ADDRESSES: "/settings/email",
 
   LINK_VIEWER_TEMPLATE: "/link/:id/view",
   LINK_VIEWER: (id: string) => `/link/${id}/view`,
@@ -0,0 +1,302 @@
+import React, { useState } from "react";
+import {
+  Button,
+  Center,
+  Container,
+  Group,
+  Loader,
+  Table,
+  Text,
+  TextInput,
+  ActionIcon,
+  Menu,
+  rem,
+  Alert,
+  Switch,
+} from "@mantine/core";
+import { IconDots, IconCopy, IconTrash, IconPlus } from "@tabler/icons-react";
+import { useDisclosure } from "@mantine/hooks";
+import { notifications } from "@mantine/notifications";
+import { usePocketBase } from "@/hooks/usePocketBase";
+import DrawerDialog from "@/components/DrawerDialog";
+import { usePageTitle } from "@/hooks/usePageTitle";
+import {
+  keepPreviousData,
+  useQuery,
+  useMutation,
+  useQueryClient,
+} from "@tanstack/react-query";
+
+type EmailAddress = {
+  id: string;
+  name: string;
+  address: string;
+  save_links: boolean;
+  last_used_at: string;
+};
+
+const fields = "id,name,address,save_links,last_used_at";
+
+const EmailAddresses: React.FC = () => {
+  usePageTitle("Email Addresses");
+  const { pb, user } = usePocketBase();
+  const [newAddressName, setNewAddressName] = useState("");
+  const [selectedAddressId, setSelectedAddressId] = useState<string | null>(
+    null,
+  );
+  const [deleteOpened, { open: openDelete, close: closeDelete }] =
+    useDisclosure(false);
+  const queryClient = useQueryClient();
+
+  const queryKey = ["emailAddresses", user?.id];
+  const emailAddressQuery = useQuery({
+    queryKey,
+    queryFn: async () => {
+      return await pb
+        .collection("email_addresses")
+        .getFullList<EmailAddress>({ sort: "-created", fields });
+    },
+    enabled: !!user,
+    staleTime: 60 * 10 * 1000,
+    placeholderData: keepPreviousData,
+  });
+
+  const addAddressMutation = useMutation({
+    mutationFn: async ({ name }: { name: string }) => {
+      const formData = new FormData();
+      formData.append("name", name);
+      await pb.send("/lynx/generate_email_address", {
+        method: "POST",
+        body: formData,
+      });
+      setNewAddressName("");
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey });
+    },
+    onError: (err) => {
+      console.error("Error adding email address:", err);
+      notifications.show({
+        message: "Failed to add email address",
+        color: "red",
+      });
+    },
+  });
+
+  const deleteAddressMutation = useMutation({
+    mutationFn: async ({ id }: { id: string | null }) => {
+      if (!id) {
+        return;
+      }
+      await pb.collection("email_addresses").delete(id);
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey });
+      closeDelete();
+      setSelectedAddressId(null);
+      notifications.show({
+        message: "Email address deleted successfully",
+        color: "green",
+      });
+    },
+    onError: (err) => {
+      console.error("Error deleting email address:", err);
+      notifications.show({
+        message: "Failed to delete email address",
+        color: "red",
+      });
+    },
+  });
+
+  const saveLinksMutation = useMutation({
+    mutationFn: async ({
+      id,
+      saveLinks,
+    }: {
+      id: string;
+      saveLinks: boolean;
+    }) => {
+      return await pb
+        .collection("email_addresses")
+        .update<EmailAddress>(id, { save_links: saveLinks }, { fields });
+    },
+    onSuccess: (data) => {
+      queryClient.setQueryData(queryKey, (oldData: EmailAddress[]) => {
+        return oldData.map((oldAddress) =>
+          oldAddress.id === data.id ? data : oldAddress,
+        );
+      });
+    },
+    onError: (err) => {
+      console.error("Error updating email address:", err);
+      notifications.show({
+        message: "Failed to update email address",
+        color: "red",
+      });
+    },
+  });
+
+  const copyToClipboard = (text: string) => {
+    navigator.clipboard.writeText(text).then(
+      () => {
+        notifications.show({ message: "Copied to clipboard", color: "green" });
+      },
+      (err) => {
+        console.error("Could not copy text: ", err);
+        notifications.show({
+          message: "Failed to copy to clipboard",
+          color: "red",
+        });
+      },
+    );
+  };
+
+  if (emailAddressQuery.isPending) {
+    return (
+      <Container mt="md">
+        <Center>
+          <Loader />
+        </Center>
+      </Container>
+    );
+  } else if (emailAddressQuery.isError) {
+    return (
+      <Container mt="md">
+        <Alert>{String(emailAddressQuery.error)}</Alert>
+      </Container>
+    );
+  }
+
+  const emailAddresses = emailAddressQuery.data;
+  return (
+    <Container mt="md">
+      <Text size="sm" c="dimmed" mb="md">
+        Mail sent to these addresses is saved to your library, if your Lynx
+        server is set up to accept email.
+      </Text>
+      <form
+        onSubmit={(e) => {
+          e.preventDefault();
+          addAddressMutation.mutate({ name: newAddressName });
+        }}
+      >
+        <TextInput
+          value={newAddressName}
+          onChange={(e) => setNewAddressName(e.target.value)}
+          radius="xl"
+          size="md"
+          mb="lg"
+          placeholder="Add Email Address"
+          required
+          rightSectionWidth={42}
+          rightSection={
+            <ActionIcon type="submit" size={32} radius="xl" variant="filled">
+              <IconPlus
+                style={{ width: rem(18), height: rem(18) }}
+                stroke={1.5}
+              />
+            </ActionIcon>
+          }
+        />
+      </form>
+
+      {emailAddressQuery.isPlaceholderData && (
+        <Center mb="md">
+          <Loader />
+        </Center>
+      )}
+
+      <Table.ScrollContainer minWidth={400}>
+        <Table>
+          <Table.Caption>{`${emailAddresses.length} Email Address${emailAddresses.length !== 1 ? "es" : ""}`}</Table.Caption>
+          <Table.Thead>
+            <Table.Tr>
+              <Table.Th>Name</Table.Th>
+              <Table.Th>Address</Table.Th>
+              <Table.Th>Save Links</Table.Th>
+              <Table.Th>Last Used</Table.Th>
+              <Table.Th>Actions</Table.Th>
+            </Table.Tr>
+          </Table.Thead>
+          <Table.Tbody>
+            {emailAddresses.map((address) => (
+              <Table.Tr key={address.id}>
+                <Table.Td>{address.name}</Table.Td>
+                <Table.Td>{address.address}</Table.Td>
+                <Table.Td>
+                  <Switch
+                    checked={address.save_links}
+                    onChange={(e) =>
+                      saveLinksMutation.mutate({
+                        id: address.id,
+                        saveLinks: e.currentTarget.checked,
+                      })
+                    }
+                  />
+                </Table.Td>
+                <Table.Td>
+                  {address.last_used_at
+                    ? new Date(address.last_used_at).toLocaleString()
+                    : "Never"}
+                </Table.Td>
+                <Table.Td>
+                  <Menu>
+                    <Menu.Target>
+                      <ActionIcon>
+                        <IconDots size={16} />
+                      </ActionIcon>
+                    </Menu.Target>
+                    <Menu.Dropdown>
+                      <Menu.Item
+                        leftSection={<IconCopy size={14} />}
+                        onClick={() => copyToClipboard(address.address)}
+                      >
+                        Copy Address
+                      </Menu.Item>
+                      <Menu.Item
+                        leftSection={<IconTrash size={14} />}
+                        onClick={() => {
+                          setSelectedAddressId(address.id);
+                          openDelete();
+                        }}
+                        color="red"
+                      >
+                        Delete
+                      </Menu.Item>
+                    </Menu.Dropdown>
+                  </Menu>
+                </Table.Td>
+              </Table.Tr>
+            ))}
+          </Table.Tbody>
+        </Table>
+      </Table.ScrollContainer>
+
+      <DrawerDialog
+        open={deleteOpened}
+        onClose={closeDelete}
+        title="Delete Email Address"
+      >
+        <Text>
+          Are you sure you want to delete this email address? Mail sent to it
+          will no longer be saved. This action cannot be undone.
+        </Text>
+        <Group justify="flex-end" mt="md">
+          <Button variant="outline" onClick={closeDelete}>
+            Cancel
+          </Button>
+          <Button
+            color="red"
+            onClick={() =>
+              deleteAddressMutation.mutate({ id: selectedAddressId })
+            }
+          >
+            Delete
+          </Button>
+        </Group>
+      </DrawerDialog>
+    </Container>
+  );
+};
+
+export default EmailAddresses;
@@ -8,6 +8,9 @@ import {
   Text,
   Paper,
   Alert,
+  Select,
+  NumberInput,
+  Textarea,
 } from "@mantine/core";
 import { IconAlertTriangle } from "@tabler/icons-react";
 import { useForm } from "@mantine/form";
@@ -15,15 +18,63 @@ import { notifications } from "@mantine/notifications";
 import { usePocketBase } from "@/hooks/usePocketBase";
 import { usePageTitle } from "@/hooks/usePageTitle";
 
+const summaryStyleOptions = [
+  { value: "concise", label: "Concise summary" },
+  { value: "tldr", label: "One sentence TL;DR" },
+  { value: "bullets", label: "Bullet points" },
+  { value: "key_takeaways", label: "Key takeaways with quotes" },
+  { value: "custom", label: "Custom prompt" },
+];
+
+const providerOptions = [
+  { value: "openrouter", label: "OpenRouter" },
+  { value: "openai", label: "OpenAI-compatible (OpenAI, Ollama, llama.cpp)" },
+  { value: "anthropic", label: "Anthropic" },
+];
+
+type ProviderValues = {
+  llm_provider: string;
+  openrouter_api_key: string;
+  openai_api_key: string;
+  openai_base_url: string;
+  anthropic_api_key: string;
+};
+
+// Mirrors the checks the backend makes before calling the provider
+const isProviderConfigured = (values: ProviderValues): boolean => {
+  switch (values.llm_provider) {
+    case "openai":
+      return !!values.openai_api_key || !!values.openai_base_url;
+    case "anthropic":
+      return !!values.anthropic_api_key;
+    default:
+      return !!values.openrouter_api_key;
+  }
+};
+
 const General: React.FC = () => {
   usePageTitle("Settings");
   const { pb, user } = usePocketBase();
   const form = useForm({
     initialValues: {
+      llm_provider: "openrouter",
       openrouter_api_key: "",
+      openai_api_key: "",
+      openai_base_url: "",
+      anthropic_api_key: "",
       automatically_summarize_new_links: false,
       summarize_model: "",
+      summarize_max_input_chars: 0,
+      summary_style: "concise",
+      summary_prompt_template: "",
       automatically_suggest_tags_for_new_links: false,
+      tagging_model: "",
+      max_suggested_tags: 5,
+      tagging_instructions: "",
+      allow_new_tag_suggestions: false,
+      auto_apply_tags_threshold: 0,
+      embedding_model: "",
+      url_rewrite_rules: "",
       id: "",
     },
   });
@@ -41,12 +92,26 @@ const General: React.FC = () => {
         .collection("user_settings")
         .getFirstListItem(`user="${user.id}"`);
       form.setValues({
+        llm_provider: record.llm_provider || "openrouter",
         openrouter_api_key: record.openrouter_api_key || "",
+        openai_api_key: record.openai_api_key || "",
+        openai_base_url: record.openai_base_url || "",
+        anthropic_api_key: record.anthropic_api_key || "",
         automatically_summarize_new_links:
           record.automatically_summarize_new_links || false,
         summarize_model: record.summarize_model || "",
+        summarize_max_input_chars: record.summarize_max_input_chars || 0,
+        summary_style: record.summary_style || "concise",
+        summary_prompt_template: record.summary_prompt_template || "",
         automatically_suggest_tags_for_new_links:
           record.automatically_suggest_tags_for_new_links || false,
+        tagging_model: record.tagging_model || "",
+        max_suggested_tags: record.max_suggested_tags || 5,
+        tagging_instructions: record.tagging_instructions || "",
+        allow_new_tag_suggestions: record.allow_new_tag_suggestions || false,
+        auto_apply_tags_threshold: record.auto_apply_tags_threshold || 0,
+        embedding_model: record.embedding_model || "",
+        url_rewrite_rules: record.url_rewrite_rules || "",
         id: record.id,
       });
       form.resetDirty();
@@ -109,13 +174,52 @@ const General: React.FC = () => {
     <Container mt="md">
       <Paper p="md" radius="md">
         <form onSubmit={handleSubmit}>
-          <TextInput
-            label="OpenRouter API Key"
-            type="password"
-            {...form.getInputProps("openrouter_api_key")}
+          <Select
+            label="AI Provider"
+            data={providerOptions}
+            allowDeselect={false}
+            {...form.getInputProps("llm_provider")}
             mb="md"
             size="md"
           />
+          {form.values.llm_provider === "openrouter" && (
+            <TextInput
+              label="OpenRouter API Key"
+              type="password"
+              {...form.getInputProps("openrouter_api_key")}
+              mb="md"
+              size="md"
+            />
+          )}
+          {form.values.llm_provider === "openai" && (
+            <>
+              <TextInput
+                label="Base URL"
+                description="Leave empty to use OpenAI. For a self-hosted server, use its OpenAI-compatible endpoint, e.g. http://localhost:11434/v1 for Ollama."
+                placeholder="https://api.openai.com/v1"
+                {...form.getInputProps("openai_base_url")}
+                mb="md"
+                size="md"
+              />
+              <TextInput
+                label="API Key"
+                description="Optional for self-hosted servers"
+                type="password"
+                {...form.getInputProps("openai_api_key")}
+                mb="md"
+                size="md"
+              />
+            </>
+          )}
+          {form.values.llm_provider === "anthropic" && (
+            <TextInput
+              label="Anthropic API Key"
+              type="password"
+              {...form.getInputProps("anthropic_api_key")}
+              mb="md"
+              size="md"
+            />
+          )}
           <Group mb="md">
             <Switch
               label="Automatically summarize new links"
@@ -126,7 +230,7 @@ const General: React.FC = () => {
             />
           </Group>
           {form.values.automatically_summarize_new_links &&
-            (!form.values.openrouter_api_key ||
+            (!isProviderConfigured(form.values) ||
               !form.values.summarize_model) && (
               <Alert
                 icon={<IconAlertTriangle size="1rem" />}
@@ -134,8 +238,8 @@ const General: React.FC = () => {
                 color="yellow"
                 mb="md"
               >
-                Automatic summarizations will not run if there is no OpenRouter
-                API key or summarize model set.
+                Automatic summarizations will not run if the AI provider is not
+                configured or there is no summarize model set.
               </Alert>
             )}
           <TextInput
@@ -144,6 +248,37 @@ const General: React.FC = () => {
             mb="md"
             size="md"
           />
+          <Select
+            label="Summary Style"
+            data={summaryStyleOptions}
+            allowDeselect={false}
+            {...form.getInputProps("summary_style")}
+            mb="md"
+            size="md"
+          />
+          {form.values.summary_style === "custom" && (
+            <Textarea
+              label="Summary Prompt"
+              description="Use {{title}} and {{text}} to include the article's title and text. The text is added at the end if {{text}} is missing."
+              placeholder={"Summarize {{title}} in three sentences for a busy engineer:\n\n{{text}}"}
+              autosize
+              minRows={3}
+              {...form.getInputProps("summary_prompt_template")}
+              mb="md"
+              size="md"
+            />
+          )}
+          <NumberInput
+            label="Maximum Characters to Summarize"
+            description="Longer articles are truncated before summarizing. Set to 0 to use the default of 1,000,000."
+            min={0}
+            step={10000}
+            allowDecimal={false}
+            thousandSeparator=","
+            {...form.getInputProps("summarize_max_input_chars")}
+            mb="md"
+            size="md"
+          />
           <Group mb="md">
             <Switch
               label="Automatically suggest tags for new links"
@@ -157,17 +292,80 @@ const General: React.FC = () => {
             />
           </Group>
           {form.values.automatically_suggest_tags_for_new_links &&
-            !form.values.openrouter_api_key && (
+            !isProviderConfigured(form.values) && (
               <Alert
                 icon={<IconAlertTriangle size="1rem" />}
                 title="Configuration Required for Tag Suggestion"
                 color="yellow"
                 mb="md"
               >
-                Automatic tag suggestions will not run if there is no OpenRouter
-                API key set.
+                Automatic tag suggestions will not run if the AI provider is not
+                configured.
               </Alert>
             )}
+          <Group mb="md">
+            <Switch
+              label="Allow suggesting new tags"
+              description="Let the model propose tags you don't have yet. Suggested new tags are only created once you accept them."
+              {...form.getInputProps("allow_new_tag_suggestions", {
+                type: "checkbox",
+              })}
+              size="md"
+            />
+          </Group>
+          <TextInput
+            label="Tagging Model"
+            description="Leave empty to use openai/gpt-4o-mini on OpenRouter, or the summarization model with other providers"
+            {...form.getInputProps("tagging_model")}
+            mb="md"
+            size="md"
+          />
+          <NumberInput
+            label="Maximum Suggested Tags"
+            min={1}
+            max={20}
+            allowDecimal={false}
+            {...form.getInputProps("max_suggested_tags")}
+            mb="md"
+            size="md"
+          />
+          <NumberInput
+            label="Auto-apply Confidence Threshold"
+            description="Suggested tags the model is at least this confident about (0 to 1) are added to the link automatically. Set to 0 to always review suggestions."
+            min={0}
+            max={1}
+            step={0.05}
+            decimalScale={2}
+            {...form.getInputProps("auto_apply_tags_threshold")}
+            mb="md"
+            size="md"
+          />
+          <Textarea
+            label="Tagging Instructions"
+            description="Optional extra guidance for the model when suggesting tags"
+            autosize
+            minRows={2}
+            {...form.getInputProps("tagging_instructions")}
+            mb="md"
+            size="md"
+          />
+          <TextInput
+            label="Embedding Model"
+            description="Used for semantic search. Leave empty to use text-embedding-3-small on OpenRouter or OpenAI. Anthropic doesn't support embeddings."
+            {...form.getInputProps("embedding_model")}
+            mb="md"
+            size="md"
+          />
+          <Textarea
+            label="URL Rewrite Rules"
+            description="Applied to links as they're saved, one rule per line: a hostname, then optionally => and a new hostname, then any query parameters to remove. For example: twitter.com => nitter.net -s"
+            placeholder="twitter.com => nitter.net -s"
+            autosize
+            minRows={2}
+            {...form.getInputProps("url_rewrite_rules")}
+            mb="md"
+            size="md"
+          />
           <Button
             type="submit"
             disabled={!form.isDirty()}
@@ -4,6 +4,7 @@ import { Tabs, Title } from "@mantine/core";
 import LynxShell from "@/pages/LynxShell";
 
 const APIKeys = lazy(() => import("./APIKeys"));
+const EmailAddresses = lazy(() => import("./EmailAddresses"));
 const Tags = lazy(() => import("./Tags"));
 const Cookies = lazy(() => import("./Cookies"));
 const General = lazy(() => import("./General"));
@@ -12,6 +13,7 @@ const Import = lazy(() => import("./Import"));
 
 const TabsToTitles: { [key: string]: string } = {
   api_keys: "Manage API Keys",
+  email: "Manage Email Addresses",
   cookies: "Manage Cookies",
   tags: "Manage Tags",
   import: "Import",
@@ -36,12 +38,16 @@ const Settings = () => {
           <Tabs.Tab value="feeds">Feeds</Tabs.Tab>
           <Tabs.Tab value="cookies">Cookies</Tabs.Tab>
           <Tabs.Tab value="api_keys">API Keys</Tabs.Tab>
+          <Tabs.Tab value="email">Email</Tabs.Tab>
           <Tabs.Tab value="import">Import</Tabs.Tab>
         </Tabs.List>
 
         <Tabs.Panel value="api_keys">
           <APIKeys />
         </Tabs.Panel>
+        <Tabs.Panel value="email">
+          <EmailAddresses />
+        </Tabs.Panel>
         <Tabs.Panel value="tags">
           <Tags />
         </Tabs.Panel>
@@ -1,5 +1,7 @@
 import type Tag from "./Tag";
 
+export type LinkKind = "article" | "note" | "pdf" | "video";
+
 type FeedLink = {
   id: string;
   added_to_library: Date;
@@ -16,6 +18,8 @@ type FeedLink = {
   suggested_tags?: Tag[];
   title: string | null;
   archive: string | null;
+  document: string | null;
+  kind: LinkKind | null;
   reading_progress: number | null;
   starred_at: Date | null;
 };
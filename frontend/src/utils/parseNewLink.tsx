@@ -12,6 +12,8 @@ const parseNewLink = async (
 ): Promise<FeedLink> => {
   const formData = new FormData();
   formData.append("url", url);
+  // Saving a link that's already in the library returns the existing one
+  formData.append("on_duplicate", "return");
   if (feedItemId) {
     formData.append("feed_item", feedItemId);
   }